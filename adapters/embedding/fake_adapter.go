@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/pgvector/pgvector-go"
+)
+
+// FakeEmbedder is a deterministic, offline EmbeddingService for tests and
+// local evaluation. Each lower-cased word is hashed into one dimension and the
+// result is L2-normalised, so texts sharing words end up close in cosine
+// distance.
+type FakeEmbedder struct {
+	dimensions int
+
+	mu     sync.Mutex
+	inputs []string
+	err    error
+}
+
+func NewFakeEmbedder(dimensions int) *FakeEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultDimensions
+	}
+	return &FakeEmbedder{dimensions: dimensions}
+}
+
+var _ service.EmbeddingService = (*FakeEmbedder)(nil)
+
+// FailWith makes every following call return err. Pass nil to reset.
+func (f *FakeEmbedder) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Inputs returns every text embedded so far.
+func (f *FakeEmbedder) Inputs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.inputs...)
+}
+
+func (f *FakeEmbedder) GenerateEmbeddings(ctx context.Context, text string) (pgvector.Vector, error) {
+	if err := ctx.Err(); err != nil {
+		return pgvector.Vector{}, err
+	}
+
+	f.mu.Lock()
+	f.inputs = append(f.inputs, text)
+	err := f.err
+	f.mu.Unlock()
+	if err != nil {
+		return pgvector.Vector{}, err
+	}
+
+	values := make([]float32, f.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, w := range words {
+		h := fnv.New32a()
+		h.Write([]byte(w))
+		values[h.Sum32()%uint32(f.dimensions)]++
+	}
+
+	var norm float64
+	for _, v := range values {
+		norm += float64(v * v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range values {
+			values[i] *= scale
+		}
+	}
+	return pgvector.NewVector(values), nil
+}
@@ -0,0 +1,90 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/retry"
+	"github.com/pgvector/pgvector-go"
+	"go.uber.org/zap"
+)
+
+type ollamaNativeAdapter struct {
+	httpClient *http.Client
+	baseURL    string
+	settings   Settings
+	log        logger.Logger
+}
+
+// NewOllamaNativeAdapter uses Ollama's own /api/embed endpoint.
+func NewOllamaNativeAdapter(s Settings, log logger.Logger) (service.EmbeddingService, error) {
+	if s.BaseURL == "" {
+		return nil, fmt.Errorf("ollama Host is not configured")
+	}
+
+	// Accept the OpenAI-compatible "/v1" host so one setting serves both adapters.
+	baseURL := strings.TrimSuffix(strings.TrimSuffix(strings.TrimRight(s.BaseURL, "/"), "/v1"), "/")
+	log.Info("Ollama native Embedding Adapter initialized", zap.String("base_url", baseURL), zap.String("model", s.Model))
+	return &ollamaNativeAdapter{httpClient: &http.Client{}, baseURL: baseURL, settings: s, log: log}, nil
+}
+
+type ollamaEmbedRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error"`
+}
+
+func (a *ollamaNativeAdapter) GenerateEmbeddings(ctx context.Context, text string) (pgvector.Vector, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.settings.Timeout)
+	defer cancel()
+
+	body, err := json.Marshal(ollamaEmbedRequest{Model: a.settings.Model, Input: text})
+	if err != nil {
+		return pgvector.Vector{}, fmt.Errorf("failed to marshal ollama embed request: %w", err)
+	}
+
+	var vector pgvector.Vector
+	err = retry.Do(ctx, a.settings.MaxRetries, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/api/embed", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to build ollama embed request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := a.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("ollama embedding request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("ollama embed returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		}
+
+		var out ollamaEmbedResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return fmt.Errorf("failed to decode ollama embed response: %w", err)
+		}
+		if out.Error != "" {
+			return fmt.Errorf("ollama embedding failed: %s", out.Error)
+		}
+		if len(out.Embeddings) == 0 {
+			return fmt.Errorf("ollama returned no embeddings")
+		}
+		vector = pgvector.NewVector(out.Embeddings[0])
+		return nil
+	})
+	return vector, err
+}
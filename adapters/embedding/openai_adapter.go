@@ -5,44 +5,59 @@ import (
 	"fmt"
 
 	"github.com/khoahotran/personal-os/internal/application/service"
-	"github.com/khoahotran/personal-os/internal/config"
 	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/retry"
 	"github.com/pgvector/pgvector-go"
 	"github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 )
 
-type ollamaAdapter struct {
-	client *openai.Client
-	log    logger.Logger
+type openAICompatibleAdapter struct {
+	client   *openai.Client
+	settings Settings
+	log      logger.Logger
 }
 
-func NewOllamaAdapter(cfg config.Config, log logger.Logger) (service.EmbeddingService, error) {
-	if cfg.Ollama.Host == "" {
-		return nil, fmt.Errorf("ollama Host is not configured")
+// NewOpenAICompatibleAdapter talks to any server implementing the OpenAI
+// embeddings API, including Ollama's /v1 endpoint.
+func NewOpenAICompatibleAdapter(s Settings, log logger.Logger) (service.EmbeddingService, error) {
+	if s.BaseURL == "" {
+		return nil, fmt.Errorf("embedding base URL is not configured")
 	}
 
-	config := openai.DefaultConfig("dummy-key")
-	config.BaseURL = cfg.Ollama.Host
+	apiKey := s.APIKey
+	if apiKey == "" {
+		apiKey = "dummy-key"
+	}
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = s.BaseURL
 
 	client := openai.NewClientWithConfig(config)
 
-	log.Info("Ollama Embedding Adapter initialized", zap.String("host", cfg.Ollama.Host))
-	return &ollamaAdapter{client: client, log: log}, nil
+	log.Info("OpenAI-compatible Embedding Adapter initialized", zap.String("base_url", s.BaseURL), zap.String("model", s.Model))
+	return &openAICompatibleAdapter{client: client, settings: s, log: log}, nil
 }
 
-func (a *ollamaAdapter) GenerateEmbeddings(ctx context.Context, text string) (pgvector.Vector, error) {
+func (a *openAICompatibleAdapter) GenerateEmbeddings(ctx context.Context, text string) (pgvector.Vector, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.settings.Timeout)
+	defer cancel()
+
 	req := openai.EmbeddingRequest{
 		Input: []string{text},
-		Model: "nomic-embed-text",
+		Model: openai.EmbeddingModel(a.settings.Model),
 	}
 
-	resp, err := a.client.CreateEmbeddings(ctx, req)
-	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("ollama embedding request failed: %w", err)
-	}
-	if len(resp.Data) == 0 {
-		return pgvector.Vector{}, fmt.Errorf("ollama returned no embeddings")
-	}
-	return pgvector.NewVector(resp.Data[0].Embedding), nil
+	var vector pgvector.Vector
+	err := retry.Do(ctx, a.settings.MaxRetries, func(ctx context.Context) error {
+		resp, err := a.client.CreateEmbeddings(ctx, req)
+		if err != nil {
+			return fmt.Errorf("embedding request failed: %w", err)
+		}
+		if len(resp.Data) == 0 {
+			return fmt.Errorf("embedding provider returned no embeddings")
+		}
+		vector = pgvector.NewVector(resp.Data[0].Embedding)
+		return nil
+	})
+	return vector, err
 }
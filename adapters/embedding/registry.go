@@ -0,0 +1,98 @@
+package embedding
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/config"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+const (
+	defaultModel = "nomic-embed-text"
+	// DefaultDimensions matches the vector(768) columns in the schema.
+	DefaultDimensions = 768
+	defaultTimeout    = 30 * time.Second
+)
+
+// Settings are the provider-independent options taken from the `embedding` config section.
+type Settings struct {
+	BaseURL    string
+	APIKey     string
+	Model      string
+	Dimensions int
+	Timeout    time.Duration
+	MaxRetries int
+}
+
+type Factory func(s Settings, log logger.Logger) (service.EmbeddingService, error)
+
+var providers = map[string]Factory{
+	ProviderOllama: NewOllamaNativeAdapter,
+	ProviderOpenAI: NewOpenAICompatibleAdapter,
+	ProviderFake: func(s Settings, log logger.Logger) (service.EmbeddingService, error) {
+		return NewFakeEmbedder(s.Dimensions), nil
+	},
+}
+
+// Register adds or replaces a provider. It is not safe for concurrent use and
+// should only be called during start-up.
+func Register(name string, f Factory) {
+	providers[name] = f
+}
+
+// NewEmbeddingService builds the provider selected by cfg.Embedding.Provider,
+// defaulting to the OpenAI-compatible API served by Ollama.
+func NewEmbeddingService(cfg config.Config, log logger.Logger) (service.EmbeddingService, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Embedding.Provider))
+	if name == "" {
+		name = ProviderOpenAI
+	}
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q (available: %s)", name, strings.Join(providerNames(), ", "))
+	}
+	return factory(settingsFromConfig(cfg), log)
+}
+
+func settingsFromConfig(cfg config.Config) Settings {
+	s := Settings{
+		BaseURL:    cfg.Embedding.BaseURL,
+		APIKey:     cfg.Embedding.APIKey,
+		Model:      cfg.Embedding.Model,
+		Dimensions: cfg.Embedding.Dimensions,
+		Timeout:    cfg.Embedding.Timeout,
+		MaxRetries: cfg.Embedding.MaxRetries,
+	}
+	if s.BaseURL == "" {
+		s.BaseURL = cfg.Ollama.Host
+	}
+	if s.Model == "" {
+		s.Model = defaultModel
+	}
+	if s.Dimensions <= 0 {
+		s.Dimensions = DefaultDimensions
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = defaultTimeout
+	}
+	return s
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -16,6 +16,8 @@ const (
 	TopicPostEvents  = "post.events"
 	TopicMediaEvents = "media.events"
 	TopicViewEvents  = "view.events"
+
+	TopicKnowledgeEvents = "knowledge.events"
 )
 
 type PostEventType string
@@ -25,6 +27,8 @@ const (
 	PostEventTypeUpdated   PostEventType = "post.updated"
 	PostEventTypeDeleted   PostEventType = "post.deleted"
 	PostEventTypePublished PostEventType = "post.published"
+	// PostEventTypeReprocess asks the worker to process a pending post again.
+	PostEventTypeReprocess PostEventType = "post.reprocess"
 )
 
 type PostEventPayload struct {
@@ -38,6 +42,8 @@ type MediaEventType string
 const (
 	MediaEventTypeUploaded MediaEventType = "media.uploaded"
 	MediaEventTypeDeleted  MediaEventType = "media.deleted"
+	// MediaEventTypeReprocess asks the worker to process media again.
+	MediaEventTypeReprocess MediaEventType = "media.reprocess"
 )
 
 type MediaEventPayload struct {
@@ -49,11 +55,25 @@ type MediaEventPayload struct {
 	OriginalPublicID string         `json:"original_public_id"`
 }
 
+type KnowledgeEventType string
+
+const (
+	KnowledgeEventTypeUpserted KnowledgeEventType = "knowledge.upserted"
+)
+
+type KnowledgeEventPayload struct {
+	EventType    KnowledgeEventType `json:"event_type"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   uuid.UUID          `json:"resource_id"`
+	OwnerID      uuid.UUID          `json:"owner_id"`
+}
+
 type KafkaProducerClient struct {
-	PostEventsWriter  *kafka.Writer
-	MediaEventsWriter *kafka.Writer
-	ViewEventsWriter  *kafka.Writer
-	logger            logger.Logger
+	PostEventsWriter      *kafka.Writer
+	MediaEventsWriter     *kafka.Writer
+	ViewEventsWriter      *kafka.Writer
+	KnowledgeEventsWriter *kafka.Writer
+	logger                logger.Logger
 }
 
 func NewKafkaProducerClient(cfg config.Config, log logger.Logger) (*KafkaProducerClient, error) {
@@ -72,10 +92,11 @@ func NewKafkaProducerClient(cfg config.Config, log logger.Logger) (*KafkaProduce
 	}
 
 	client := &KafkaProducerClient{
-		PostEventsWriter:  createWriter(TopicPostEvents),
-		MediaEventsWriter: createWriter(TopicMediaEvents),
-		ViewEventsWriter:  createWriter(TopicViewEvents),
-		logger:            log,
+		PostEventsWriter:      createWriter(TopicPostEvents),
+		MediaEventsWriter:     createWriter(TopicMediaEvents),
+		ViewEventsWriter:      createWriter(TopicViewEvents),
+		KnowledgeEventsWriter: createWriter(TopicKnowledgeEvents),
+		logger:                log,
 	}
 
 	log.Info("Initialize Kafka Producers successfully.")
@@ -122,6 +143,26 @@ func (c *KafkaProducerClient) PublishMediaEvent(ctx context.Context, payload Med
 	return err
 }
 
+func (c *KafkaProducerClient) PublishKnowledgeEvent(ctx context.Context, payload KnowledgeEventPayload) error {
+	msgBody, err := json.Marshal(payload)
+	if err != nil {
+		c.logger.Error("Kafka Marshal (Knowledge) failed", err, zap.String("resource_id", payload.ResourceID.String()))
+		return err
+	}
+
+	err = c.KnowledgeEventsWriter.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(payload.ResourceID.String()),
+		Value: msgBody,
+	})
+
+	if err != nil {
+		c.logger.Error("Kafka Write (Knowledge) failed", err, zap.String("resource_id", payload.ResourceID.String()))
+	} else {
+		c.logger.Info("Kafka event sent", zap.String("topic", TopicKnowledgeEvents), zap.String("resource_type", payload.ResourceType))
+	}
+	return err
+}
+
 func (c *KafkaProducerClient) Close() {
 	if c.PostEventsWriter != nil {
 		c.PostEventsWriter.Close()
@@ -132,5 +173,8 @@ func (c *KafkaProducerClient) Close() {
 	if c.ViewEventsWriter != nil {
 		c.ViewEventsWriter.Close()
 	}
+	if c.KnowledgeEventsWriter != nil {
+		c.KnowledgeEventsWriter.Close()
+	}
 	c.logger.Info("Closed Kafka Producers")
 }
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	albumUC "github.com/khoahotran/personal-os/internal/application/usecase/album"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type AlbumHandler struct {
+	useCase *albumUC.AlbumUseCase
+	logger  logger.Logger
+}
+
+func NewAlbumHandler(uc *albumUC.AlbumUseCase, log logger.Logger) *AlbumHandler {
+	return &AlbumHandler{useCase: uc, logger: log}
+}
+
+func (h *AlbumHandler) CreateAlbum(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+
+	var req CreateOrUpdateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid request data", err))
+		return
+	}
+
+	input := albumUC.CreateAlbumInput{
+		OwnerID:      ownerID,
+		Title:        req.Title,
+		Slug:         req.Slug,
+		Description:  req.Description,
+		CoverMediaID: req.CoverMediaID,
+		MediaIDs:     req.MediaIDs,
+		IsPublic:     req.IsPublic,
+	}
+
+	a, err := h.useCase.CreateAlbum(c.Request.Context(), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dto := ToAlbumDTO(a)
+	dto.MediaIDs = a.MediaIDs
+	c.JSON(http.StatusCreated, dto)
+}
+
+func (h *AlbumHandler) UpdateAlbum(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	albumID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid album ID", err))
+		return
+	}
+
+	var req CreateOrUpdateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid request data", err))
+		return
+	}
+
+	input := albumUC.UpdateAlbumInput{
+		AlbumID:      albumID,
+		OwnerID:      ownerID,
+		Title:        req.Title,
+		Slug:         req.Slug,
+		Description:  req.Description,
+		CoverMediaID: req.CoverMediaID,
+		MediaIDs:     req.MediaIDs,
+		IsPublic:     req.IsPublic,
+	}
+
+	a, err := h.useCase.UpdateAlbum(c.Request.Context(), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dto := ToAlbumDTO(a)
+	dto.MediaIDs = a.MediaIDs
+	c.JSON(http.StatusOK, dto)
+}
+
+func (h *AlbumHandler) DeleteAlbum(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	albumID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid album ID", err))
+		return
+	}
+
+	if err := h.useCase.DeleteAlbum(c.Request.Context(), albumID, ownerID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AlbumHandler) GetAlbum(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	albumID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid album ID", err))
+		return
+	}
+
+	a, err := h.useCase.GetAlbum(c.Request.Context(), albumID, ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dto := ToAlbumDTO(a)
+	dto.MediaIDs = a.MediaIDs
+	c.JSON(http.StatusOK, dto)
+}
+
+func (h *AlbumHandler) ListAlbums(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	albums, err := h.useCase.ListAlbums(c.Request.Context(), ownerID, page, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dtos := make([]AlbumDTO, len(albums))
+	for i, a := range albums {
+		dtos[i] = ToAlbumDTO(a)
+		dtos[i].MediaIDs = a.MediaIDs
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+func (h *AlbumHandler) GetPublicAlbum(c *gin.Context) {
+	a, err := h.useCase.GetPublicAlbum(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ToAlbumDTO(a))
+}
+
+func (h *AlbumHandler) ListPublicAlbums(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	albums, err := h.useCase.ListPublicAlbums(c.Request.Context(), page, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dtos := make([]AlbumDTO, len(albums))
+	for i, a := range albums {
+		dtos[i] = ToAlbumDTO(a)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
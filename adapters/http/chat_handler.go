@@ -4,21 +4,25 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	chatUC "github.com/khoahotran/personal-os/internal/application/usecase/chat"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
 )
 
 type ChatHandler struct {
-	chatUseCase *chatUC.ChatUseCase
-	logger      logger.Logger
+	chatUseCase       *chatUC.ChatUseCase
+	publicChatUseCase *chatUC.PublicChatUseCase
+	logger            logger.Logger
 }
 
-func NewChatHandler(uc *chatUC.ChatUseCase, log logger.Logger) *ChatHandler {
+func NewChatHandler(uc *chatUC.ChatUseCase, publicUC *chatUC.PublicChatUseCase, log logger.Logger) *ChatHandler {
 	return &ChatHandler{
-		chatUseCase: uc,
-		logger:      log,
+		chatUseCase:       uc,
+		publicChatUseCase: publicUC,
+		logger:            log,
 	}
 }
 
@@ -48,13 +52,99 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 		return
 	}
 
-	sourcesDTO := make([]PostSummaryDTO, len(output.Sources))
-	for i, p := range output.Sources {
-		sourcesDTO[i] = ToPostSummaryDTO(p)
+	c.JSON(http.StatusOK, ChatResponse{
+		Response:  output.Response,
+		Sources:   ToChatSourceDTOs(output.Sources, citationRefs(output.Citations)),
+		Citations: citationRefs(output.Citations),
+	})
+}
+
+func (h *ChatHandler) PublicChat(c *gin.Context) {
+	var req PublicChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid JSON body", err))
+		return
 	}
 
-	c.JSON(http.StatusOK, ChatResponse{
-		Response: output.Response,
-		Sources:  sourcesDTO,
+	output, err := h.publicChatUseCase.Execute(c.Request.Context(), chatUC.PublicChatInput{Query: req.Query})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PublicChatResponse{
+		Response:  output.Response,
+		Sources:   ToChatSourceDTOs(output.Sources, citationRefs(output.Citations)),
+		Citations: citationRefs(output.Citations),
+		Refused:   output.Refused,
 	})
 }
+
+func (h *ChatHandler) ChatStream(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid JSON body", err))
+		return
+	}
+
+	input := chatUC.ChatInput{
+		Query:   req.Query,
+		OwnerID: ownerID,
+		Limit:   req.Limit,
+	}
+
+	ctx := c.Request.Context()
+	streaming := false
+	callbacks := chatUC.ChatStreamCallbacks{
+		OnSources: func(sources []*knowledge.Source) error {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			streaming = true
+
+			c.SSEvent("sources", ToChatSourceDTOs(sources, nil))
+			c.Writer.Flush()
+			return ctx.Err()
+		},
+		OnToken: func(token string) error {
+			c.SSEvent("token", gin.H{"token": token})
+			c.Writer.Flush()
+			return ctx.Err()
+		},
+	}
+
+	output, err := h.chatUseCase.ExecuteStream(ctx, input, callbacks)
+	if err != nil {
+		if ctx.Err() != nil {
+			h.logger.Info("Chat stream client disconnected", zap.String("owner_id", ownerID.String()))
+			return
+		}
+		if !streaming {
+			c.Error(err)
+			return
+		}
+		h.logger.Error("Chat stream failed", err, zap.String("owner_id", ownerID.String()))
+		c.SSEvent("error", gin.H{"message": "failed to generate response"})
+		c.Writer.Flush()
+		return
+	}
+
+	c.SSEvent("done", gin.H{"response": output.Response, "citations": citationRefs(output.Citations)})
+	c.Writer.Flush()
+}
+
+func citationRefs(citations []chatUC.Citation) []int {
+	refs := make([]int, len(citations))
+	for i, c := range citations {
+		refs[i] = c.Ref
+	}
+	return refs
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	chatUC "github.com/khoahotran/personal-os/internal/application/usecase/chat"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type ConversationHandler struct {
+	useCase *chatUC.ConversationUseCase
+	logger  logger.Logger
+}
+
+func NewConversationHandler(uc *chatUC.ConversationUseCase, log logger.Logger) *ConversationHandler {
+	return &ConversationHandler{useCase: uc, logger: log}
+}
+
+func (h *ConversationHandler) CreateConversation(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+
+	var req CreateConversationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperror.NewInvalidInput("invalid request data", err))
+			return
+		}
+	}
+
+	conv, err := h.useCase.CreateConversation(c.Request.Context(), chatUC.CreateConversationInput{
+		OwnerID: ownerID,
+		Title:   req.Title,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, ToConversationDTO(conv))
+}
+
+func (h *ConversationHandler) ListConversations(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	convs, err := h.useCase.ListConversations(c.Request.Context(), ownerID, page, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dtos := make([]ConversationDTO, len(convs))
+	for i, conv := range convs {
+		dtos[i] = ToConversationDTO(conv)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	convID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid conversation ID", err))
+		return
+	}
+
+	output, err := h.useCase.GetConversation(c.Request.Context(), convID, ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	messages := make([]ChatMessageDTO, len(output.Messages))
+	for i, m := range output.Messages {
+		messages[i] = ToChatMessageDTO(m)
+	}
+	c.JSON(http.StatusOK, ConversationDetailDTO{
+		ConversationDTO: ToConversationDTO(output.Conversation),
+		Messages:        messages,
+	})
+}
+
+func (h *ConversationHandler) DeleteConversation(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	convID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid conversation ID", err))
+		return
+	}
+
+	if err := h.useCase.DeleteConversation(c.Request.Context(), convID, ownerID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ConversationHandler) SendMessage(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	convID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid conversation ID", err))
+		return
+	}
+
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid JSON body", err))
+		return
+	}
+
+	output, err := h.useCase.SendMessage(c.Request.Context(), chatUC.SendMessageInput{
+		ConversationID: convID,
+		OwnerID:        ownerID,
+		Query:          req.Query,
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_message":      ToChatMessageDTO(output.UserMessage),
+		"assistant_message": ToChatMessageDTO(output.AssistantMessage),
+	})
+}
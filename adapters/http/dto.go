@@ -3,13 +3,19 @@ package http
 import (
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/domain/album"
+	"github.com/khoahotran/personal-os/internal/domain/conversation"
 	"github.com/khoahotran/personal-os/internal/domain/hobby"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
 	"github.com/khoahotran/personal-os/internal/domain/media"
 	"github.com/khoahotran/personal-os/internal/domain/post"
 	"github.com/khoahotran/personal-os/internal/domain/profile"
 	"github.com/khoahotran/personal-os/internal/domain/project"
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
 	"github.com/khoahotran/personal-os/internal/domain/search"
 	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/internal/domain/uploadsession"
 )
 
 // Profile DTOs
@@ -23,6 +29,7 @@ type ProfileDTO struct {
 	Bio            string               `json:"bio"`
 	CareerTimeline []CareerMilestoneDTO `json:"career_timeline"`
 	ThemeSettings  map[string]any       `json:"theme_settings"`
+	ChatPersona    string               `json:"chat_persona"`
 	UpdatedAt      time.Time            `json:"updated_at"`
 }
 
@@ -34,12 +41,14 @@ type UpdateProfileRequest struct {
 		Description string    `json:"description"`
 	} `json:"career_timeline"`
 	ThemeSettings map[string]any `json:"theme_settings"`
+	ChatPersona   *string        `json:"chat_persona"`
 }
 
 func ToProfileDTO(p *profile.Profile) ProfileDTO {
 	dto := ProfileDTO{
 		Bio:           p.Bio,
 		ThemeSettings: p.ThemeSettings,
+		ChatPersona:   p.ChatPersona,
 		UpdatedAt:     p.UpdatedAt,
 	}
 	dto.CareerTimeline = make([]CareerMilestoneDTO, len(p.CareerTimeline))
@@ -68,11 +77,12 @@ func (req *UpdateProfileRequest) ToDomainMilestones() []profile.CareerMilestone
 // Post DTOs
 
 type CreatePostRequest struct {
-	Title   string   `json:"title" binding:"required"`
-	Content string   `json:"content"`
-	Slug    string   `json:"slug"`
-	Status  string   `json:"status" binding:"required,oneof=draft private public"`
-	Tags    []string `json:"tags"`
+	Title    string   `json:"title" binding:"required"`
+	Content  string   `json:"content"`
+	Slug     string   `json:"slug"`
+	Status   string   `json:"status" binding:"required,oneof=draft private public"`
+	Language string   `json:"language" binding:"omitempty,oneof=en vi"`
+	Tags     []string `json:"tags"`
 }
 
 type PostDTO struct {
@@ -81,19 +91,56 @@ type PostDTO struct {
 	Title           string     `json:"title"`
 	ContentMarkdown string     `json:"content_markdown"`
 	Status          string     `json:"status"`
+	Language        string     `json:"language"`
 	OgImageURL      *string    `json:"og_image_url"`
 	PublishedAt     *time.Time `json:"published_at"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 	Tags            []string   `json:"tags"`
+	Description     string     `json:"description,omitempty"`
+	SEODescription  string     `json:"seo_description,omitempty"`
+	// Suggestions is only filled in on admin endpoints.
+	Suggestions *PostSuggestionsDTO `json:"suggestions,omitempty"`
+	// Albums holds the public albums embedded in the content with
+	// {{album:<slug>}}, on public endpoints.
+	Albums []AlbumDTO `json:"albums,omitempty"`
+}
+
+type PostSuggestionsDTO struct {
+	Summary        string    `json:"summary"`
+	SEODescription string    `json:"seo_description"`
+	Tags           []string  `json:"tags"`
+	NewTags        []string  `json:"new_tags"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+type AcceptSuggestionsRequest struct {
+	Summary        bool     `json:"summary"`
+	SEODescription bool     `json:"seo_description"`
+	Tags           []string `json:"tags"`
+}
+
+func ToPostSuggestionsDTO(p *post.Post) *PostSuggestionsDTO {
+	s, ok := p.Suggestions()
+	if !ok {
+		return nil
+	}
+	return &PostSuggestionsDTO{
+		Summary:        s.Summary,
+		SEODescription: s.SEODescription,
+		Tags:           s.Tags,
+		NewTags:        s.NewTags,
+		GeneratedAt:    s.GeneratedAt,
+	}
 }
 
 type UpdatePostRequest struct {
-	Title   string   `json:"title" binding:"required"`
-	Content string   `json:"content"`
-	Slug    string   `json:"slug" binding:"required"`
-	Status  string   `json:"status" binding:"required,oneof=draft private public"`
-	Tags    []string `json:"tags"`
+	Title    string   `json:"title" binding:"required"`
+	Content  string   `json:"content"`
+	Slug     string   `json:"slug" binding:"required"`
+	Status   string   `json:"status" binding:"required,oneof=draft private public"`
+	Language string   `json:"language" binding:"omitempty,oneof=en vi"`
+	Tags     []string `json:"tags"`
 }
 
 func (r *UpdatePostRequest) ToDomainPostStatus() post.PostStatus {
@@ -112,6 +159,7 @@ type PostSummaryDTO struct {
 	Slug        string     `json:"slug"`
 	Title       string     `json:"title"`
 	Status      string     `json:"status"`
+	Description string     `json:"description,omitempty"`
 	OgImageURL  *string    `json:"og_image_url,omitempty"`
 	PublishedAt *time.Time `json:"published_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
@@ -124,6 +172,7 @@ func ToPostSummaryDTO(p *post.Post) PostSummaryDTO {
 		Slug:        p.Slug,
 		Title:       p.Title,
 		Status:      string(p.Status),
+		Description: p.Summary(),
 		OgImageURL:  p.OgImageURL,
 		PublishedAt: p.PublishedAt,
 		CreatedAt:   p.CreatedAt,
@@ -143,11 +192,14 @@ func ToPostDTO(p *post.Post, tags []tag.Tag) PostDTO {
 		Title:           p.Title,
 		ContentMarkdown: p.ContentMarkdown,
 		Status:          string(p.Status),
+		Language:        string(p.Language),
 		OgImageURL:      p.OgImageURL,
 		PublishedAt:     p.PublishedAt,
 		CreatedAt:       p.CreatedAt,
 		UpdatedAt:       p.UpdatedAt,
 		Tags:            tagNames,
+		Description:     p.Summary(),
+		SEODescription:  p.SEODescription(),
 	}
 }
 
@@ -241,14 +293,54 @@ func ToProjectSummaryDTO(p *project.Project) ProjectSummaryDTO {
 }
 
 // Media DTOs
+type MediaVariantDTO struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+}
+
 type MediaDTO struct {
-	ID           string         `json:"id"`
-	URL          string         `json:"url"`
-	ThumbnailURL *string        `json:"thumbnail_url,omitempty"`
-	Status       string         `json:"status"`
-	Metadata     map[string]any `json:"metadata"`
-	IsPublic     bool           `json:"is_public"`
-	CreatedAt    time.Time      `json:"created_at"`
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	URL           string            `json:"url"`
+	ThumbnailURL  *string           `json:"thumbnail_url,omitempty"`
+	Status        string            `json:"status"`
+	Metadata      map[string]any    `json:"metadata"`
+	IsPublic      bool              `json:"is_public"`
+	Width         int               `json:"width,omitempty"`
+	Height        int               `json:"height,omitempty"`
+	DominantColor string            `json:"dominant_color,omitempty"`
+	BlurHash      string            `json:"blurhash,omitempty"`
+	Variants      []MediaVariantDTO `json:"variants"`
+	// DurationSeconds, the codecs and PosterURL are set for video and audio
+	// once processed.
+	DurationSeconds float64    `json:"duration_seconds,omitempty"`
+	VideoCodec      string     `json:"video_codec,omitempty"`
+	AudioCodec      string     `json:"audio_codec,omitempty"`
+	PosterURL       *string    `json:"poster_url,omitempty"`
+	TakenAt         *time.Time `json:"taken_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	// Tags and Processing are only filled in on admin endpoints.
+	Tags       []string            `json:"tags,omitempty"`
+	Processing *MediaProcessingDTO `json:"processing,omitempty"`
+}
+
+type MediaProcessingDTO struct {
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+}
+
+func ToMediaProcessingDTO(m *media.Media) *MediaProcessingDTO {
+	return &MediaProcessingDTO{
+		Attempts:      m.ProcessingAttempts,
+		LastError:     m.ProcessingError,
+		LastAttemptAt: m.LastAttemptAt,
+		ProcessedAt:   m.ProcessedAt,
+	}
 }
 
 type UpdateMediaRequest struct {
@@ -256,15 +348,94 @@ type UpdateMediaRequest struct {
 	IsPublic bool           `json:"is_public"`
 }
 
+type BulkMediaRequest struct {
+	Action   string      `json:"action" binding:"required"`
+	MediaIDs []uuid.UUID `json:"media_ids" binding:"required"`
+	Tags     []string    `json:"tags"`
+}
+
+type StartUploadRequest struct {
+	FileName     string         `json:"file_name"`
+	Size         int64          `json:"size" binding:"required"`
+	SHA256       string         `json:"sha256" binding:"required"`
+	Metadata     map[string]any `json:"metadata"`
+	IsPublic     bool           `json:"is_public"`
+	KeepLocation bool           `json:"keep_location"`
+}
+
+// UploadSessionDTO tells a client resuming an upload which parts to send.
+type UploadSessionDTO struct {
+	ID            string    `json:"id"`
+	FileName      string    `json:"file_name"`
+	Size          int64     `json:"size"`
+	SHA256        string    `json:"sha256"`
+	PartSize      int64     `json:"part_size"`
+	PartCount     int       `json:"part_count"`
+	ReceivedBytes int64     `json:"received_bytes"`
+	ReceivedParts []int     `json:"received_parts"`
+	MissingParts  []int     `json:"missing_parts"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func ToUploadSessionDTO(s *uploadsession.Session) UploadSessionDTO {
+	return UploadSessionDTO{
+		ID:            s.ID.String(),
+		FileName:      s.FileName,
+		Size:          s.Size,
+		SHA256:        s.SHA256,
+		PartSize:      s.PartSize,
+		PartCount:     s.PartCount(),
+		ReceivedBytes: s.ReceivedBytes(),
+		ReceivedParts: s.Parts,
+		MissingParts:  s.MissingParts(),
+		ExpiresAt:     s.ExpiresAt,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+// BulkMediaResultDTO reports one item of a bulk request. Status is the HTTP
+// status the item would have had as a request of its own.
+type BulkMediaResultDTO struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
 func ToMediaDTO(m *media.Media) MediaDTO {
+	variants := make([]MediaVariantDTO, len(m.Variants))
+	for i, v := range m.Variants {
+		variants[i] = MediaVariantDTO{
+			Name:        v.Name,
+			URL:         v.URL,
+			Width:       v.Width,
+			Height:      v.Height,
+			ContentType: v.ContentType,
+		}
+	}
+	var posterURL *string
+	if u, ok := m.PosterURL(); ok {
+		posterURL = &u
+	}
 	return MediaDTO{
-		ID:           m.ID.String(),
-		URL:          m.URL,
-		ThumbnailURL: m.ThumbnailURL,
-		Status:       string(m.Status),
-		Metadata:     m.Metadata,
-		IsPublic:     m.IsPublic,
-		CreatedAt:    m.CreatedAt,
+		ID:              m.ID.String(),
+		Type:            string(m.Kind),
+		URL:             m.URL,
+		ThumbnailURL:    m.ThumbnailURL,
+		Status:          string(m.Status),
+		Metadata:        m.Metadata,
+		IsPublic:        m.IsPublic,
+		Width:           m.Width,
+		Height:          m.Height,
+		DominantColor:   m.DominantColor,
+		BlurHash:        m.BlurHash,
+		Variants:        variants,
+		DurationSeconds: m.Duration.Seconds(),
+		VideoCodec:      m.VideoCodec,
+		AudioCodec:      m.AudioCodec,
+		PosterURL:       posterURL,
+		TakenAt:         m.TakenAt,
+		CreatedAt:       m.CreatedAt,
 	}
 }
 
@@ -306,14 +477,107 @@ func ToHobbyItemDTO(hi *hobby.HobbyItem) HobbyItemDTO {
 	}
 }
 
+// Album DTOs
+
+type AlbumDTO struct {
+	ID                string     `json:"id"`
+	Slug              string     `json:"slug"`
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	CoverMediaID      *uuid.UUID `json:"cover_media_id"`
+	CoverURL          string     `json:"cover_url,omitempty"`
+	CoverThumbnailURL *string    `json:"cover_thumbnail_url,omitempty"`
+	IsPublic          bool       `json:"is_public"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	// MediaIDs is only filled in on admin endpoints, where it may include
+	// media that are private or still processing.
+	MediaIDs []uuid.UUID `json:"media_ids,omitempty"`
+	// Items is only filled in when a single album is fetched.
+	Items []MediaDTO `json:"items,omitempty"`
+}
+
+type CreateOrUpdateAlbumRequest struct {
+	Title        string      `json:"title" binding:"required"`
+	Slug         string      `json:"slug"`
+	Description  string      `json:"description"`
+	CoverMediaID *uuid.UUID  `json:"cover_media_id"`
+	MediaIDs     []uuid.UUID `json:"media_ids"`
+	IsPublic     bool        `json:"is_public"`
+}
+
+func ToAlbumDTO(a *album.Album) AlbumDTO {
+	dto := AlbumDTO{
+		ID:                a.ID.String(),
+		Slug:              a.Slug,
+		Title:             a.Title,
+		Description:       a.Description,
+		CoverMediaID:      a.CoverMediaID,
+		CoverURL:          a.CoverURL,
+		CoverThumbnailURL: a.CoverThumbnailURL,
+		IsPublic:          a.IsPublic,
+		CreatedAt:         a.CreatedAt,
+		UpdatedAt:         a.UpdatedAt,
+	}
+	if a.Items != nil {
+		dto.Items = make([]MediaDTO, len(a.Items))
+		for i, m := range a.Items {
+			dto.Items[i] = ToMediaDTO(m)
+		}
+	}
+	return dto
+}
+
 type ChatRequest struct {
 	Query string `json:"query" binding:"required"`
 	Limit int    `json:"limit"`
 }
 
+// ChatSourceDTO is a source given to the LLM. Ref is the number the answer
+// uses to cite it, e.g. [2].
+type ChatSourceDTO struct {
+	Ref          int    `json:"ref"`
+	ID           string `json:"id"`
+	ResourceType string `json:"resource_type"`
+	Slug         string `json:"slug"`
+	Title        string `json:"title"`
+	Cited        bool   `json:"cited"`
+}
+
 type ChatResponse struct {
-	Response string           `json:"response"`
-	Sources  []PostSummaryDTO `json:"sources"`
+	Response  string          `json:"response"`
+	Sources   []ChatSourceDTO `json:"sources"`
+	Citations []int           `json:"citations"`
+}
+
+type PublicChatRequest struct {
+	Query string `json:"query" binding:"required"`
+}
+
+type PublicChatResponse struct {
+	Response  string          `json:"response"`
+	Sources   []ChatSourceDTO `json:"sources"`
+	Citations []int           `json:"citations"`
+	Refused   bool            `json:"refused"`
+}
+
+func ToChatSourceDTOs(sources []*knowledge.Source, citedRefs []int) []ChatSourceDTO {
+	cited := make(map[int]bool, len(citedRefs))
+	for _, ref := range citedRefs {
+		cited[ref] = true
+	}
+	dtos := make([]ChatSourceDTO, len(sources))
+	for i, s := range sources {
+		dtos[i] = ChatSourceDTO{
+			Ref:          i + 1,
+			ID:           s.ResourceID.String(),
+			ResourceType: string(s.ResourceType),
+			Slug:         s.Slug,
+			Title:        s.Title,
+			Cited:        cited[i+1],
+		}
+	}
+	return dtos
 }
 
 type SearchResultDTO struct {
@@ -324,10 +588,15 @@ type SearchResultDTO struct {
 	Snippet      string    `json:"snippet"`
 	Rank         float32   `json:"rank"`
 	IsPublic     bool      `json:"is_public"`
+	Tags         []string  `json:"tags"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 func ToSearchResultDTO(s search.SearchResult) SearchResultDTO {
+	tags := s.Tags
+	if tags == nil {
+		tags = []string{}
+	}
 	return SearchResultDTO{
 		ID:           s.ID.String(),
 		ResourceType: s.ResourceType,
@@ -336,6 +605,158 @@ func ToSearchResultDTO(s search.SearchResult) SearchResultDTO {
 		Snippet:      s.Snippet,
 		Rank:         s.Rank,
 		IsPublic:     s.IsPublic,
+		Tags:         tags,
 		UpdatedAt:    s.UpdatedAt,
 	}
 }
+
+type SearchFacetDTO struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type SearchFacetsDTO struct {
+	Types []SearchFacetDTO `json:"types"`
+	Tags  []SearchFacetDTO `json:"tags"`
+}
+
+type SearchResponse struct {
+	Results    []SearchResultDTO `json:"results"`
+	Mode       string            `json:"mode"`
+	Facets     *SearchFacetsDTO  `json:"facets,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+func toSearchFacetDTOs(counts []search.FacetCount) []SearchFacetDTO {
+	dtos := make([]SearchFacetDTO, len(counts))
+	for i, fc := range counts {
+		dtos[i] = SearchFacetDTO{Value: fc.Value, Count: fc.Count}
+	}
+	return dtos
+}
+
+func ToSearchResponse(results []search.SearchResult, mode search.Mode, facets *search.Facets, nextCursor string) SearchResponse {
+	resp := SearchResponse{
+		Results:    make([]SearchResultDTO, len(results)),
+		Mode:       string(mode),
+		NextCursor: nextCursor,
+	}
+	for i, res := range results {
+		resp.Results[i] = ToSearchResultDTO(res)
+	}
+	if facets != nil {
+		resp.Facets = &SearchFacetsDTO{
+			Types: toSearchFacetDTOs(facets.Types),
+			Tags:  toSearchFacetDTOs(facets.Tags),
+		}
+	}
+	return resp
+}
+
+type SearchSuggestionDTO struct {
+	ResourceType string `json:"resource_type"`
+	Title        string `json:"title"`
+	Slug         string `json:"slug"`
+}
+
+func ToSearchSuggestionDTO(s search.Suggestion) SearchSuggestionDTO {
+	return SearchSuggestionDTO{
+		ResourceType: s.ResourceType,
+		Title:        s.Title,
+		Slug:         s.Slug,
+	}
+}
+
+// Conversation DTOs
+
+type CreateConversationRequest struct {
+	Title string `json:"title"`
+}
+
+type SendMessageRequest struct {
+	Query string `json:"query" binding:"required"`
+	Limit int    `json:"limit"`
+}
+
+type ConversationDTO struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ChatMessageDTO struct {
+	ID        string          `json:"id"`
+	Role      string          `json:"role"`
+	Content   string          `json:"content"`
+	Sources   []ChatSourceDTO `json:"sources"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ConversationDetailDTO struct {
+	ConversationDTO
+	Messages []ChatMessageDTO `json:"messages"`
+}
+
+func ToConversationDTO(c *conversation.Conversation) ConversationDTO {
+	return ConversationDTO{
+		ID:        c.ID.String(),
+		Title:     c.Title,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func ToChatMessageDTO(m *conversation.Message) ChatMessageDTO {
+	sources := make([]ChatSourceDTO, len(m.Sources))
+	for i, s := range m.Sources {
+		sources[i] = ChatSourceDTO{
+			Ref:          i + 1,
+			ID:           s.ResourceID.String(),
+			ResourceType: s.ResourceType,
+			Slug:         s.Slug,
+			Title:        s.Title,
+			Cited:        s.Cited,
+		}
+	}
+	return ChatMessageDTO{
+		ID:        m.ID.String(),
+		Role:      string(m.Role),
+		Content:   m.Content,
+		Sources:   sources,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// Prompt template DTOs
+
+type SavePromptTemplateRequest struct {
+	System string `json:"system"`
+	User   string `json:"user" binding:"required"`
+}
+
+type PromptTemplateDTO struct {
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	System    string    `json:"system"`
+	User      string    `json:"user"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PromptTemplateDetailDTO struct {
+	Active   PromptTemplateDTO   `json:"active"`
+	Default  PromptTemplateDTO   `json:"default"`
+	Versions []PromptTemplateDTO `json:"versions"`
+}
+
+func ToPromptTemplateDTO(t *prompt.Template) PromptTemplateDTO {
+	return PromptTemplateDTO{
+		Name:      t.Name,
+		Version:   t.Version,
+		System:    t.System,
+		User:      t.User,
+		IsActive:  t.IsActive,
+		CreatedAt: t.CreatedAt,
+	}
+}
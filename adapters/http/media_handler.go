@@ -2,36 +2,54 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	mediaUC "github.com/khoahotran/personal-os/internal/application/usecase/media"
+	"github.com/khoahotran/personal-os/internal/domain/media"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/upload"
 )
 
 type MediaHandler struct {
 	uploadMediaUC *mediaUC.UploadMediaUseCase
+	listMediaUC   *mediaUC.ListMediaUseCase
 	listPublicUC  *mediaUC.ListPublicMediaUseCase
 	updateMediaUC *mediaUC.UpdateMediaUseCase
 	deleteMediaUC *mediaUC.DeleteMediaUseCase
+	bulkMediaUC   *mediaUC.BulkMediaUseCase
+	reprocessUC   *mediaUC.ReprocessMediaUseCase
+	uploadPolicy  upload.Policy
 	logger        logger.Logger
 }
 
 func NewMediaHandler(
 	uploadUC *mediaUC.UploadMediaUseCase,
+	listUC *mediaUC.ListMediaUseCase,
 	listPublicUC *mediaUC.ListPublicMediaUseCase,
 	updateUC *mediaUC.UpdateMediaUseCase,
 	deleteUC *mediaUC.DeleteMediaUseCase,
+	bulkUC *mediaUC.BulkMediaUseCase,
+	reprocessUC *mediaUC.ReprocessMediaUseCase,
+	uploadPolicy upload.Policy,
 	log logger.Logger,
 ) *MediaHandler {
 	return &MediaHandler{
 		uploadMediaUC: uploadUC,
+		listMediaUC:   listUC,
 		listPublicUC:  listPublicUC,
 		updateMediaUC: updateUC,
 		deleteMediaUC: deleteUC,
+		bulkMediaUC:   bulkUC,
+		reprocessUC:   reprocessUC,
+		uploadPolicy:  uploadPolicy,
 		logger:        log,
 	}
 }
@@ -43,22 +61,18 @@ func (h *MediaHandler) UploadMedia(c *gin.Context) {
 		return
 	}
 
-	fileHeader, err := c.FormFile("file")
+	file, err := formFile(c, "file", h.uploadPolicy)
 	if err != nil {
-		c.Error(apperror.NewInvalidInput("'file' is required", err))
-		return
-	}
-	file, err := fileHeader.Open()
-	if err != nil {
-		c.Error(apperror.NewInternal("failed to open file", err))
+		c.Error(err)
 		return
 	}
-	defer file.Close()
+	defer file.File.Close()
 
 	dataJSON := c.PostForm("data")
 	var reqData struct {
-		Metadata map[string]any `json:"metadata"`
-		IsPublic bool           `json:"is_public"`
+		Metadata     map[string]any `json:"metadata"`
+		IsPublic     bool           `json:"is_public"`
+		KeepLocation bool           `json:"keep_location"`
 	}
 	if dataJSON != "" {
 		if err := json.Unmarshal([]byte(dataJSON), &reqData); err != nil {
@@ -69,14 +83,15 @@ func (h *MediaHandler) UploadMedia(c *gin.Context) {
 	if reqData.Metadata == nil {
 		reqData.Metadata = make(map[string]any)
 	}
-	reqData.Metadata["original_filename"] = fileHeader.Filename
+	reqData.Metadata["original_filename"] = file.Header.Filename
 
 	input := mediaUC.UploadMediaInput{
-		OwnerID:  ownerID,
-		File:     file,
-		Metadata: reqData.Metadata,
-		IsPublic: reqData.IsPublic,
-		Provider: "cloudinary",
+		OwnerID:      ownerID,
+		File:         file.File,
+		ContentType:  file.ContentType,
+		Metadata:     reqData.Metadata,
+		IsPublic:     reqData.IsPublic,
+		KeepLocation: reqData.KeepLocation,
 	}
 
 	output, err := h.uploadMediaUC.Execute(c.Request.Context(), input)
@@ -84,7 +99,11 @@ func (h *MediaHandler) UploadMedia(c *gin.Context) {
 		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "Upload media successfully, processing...", "media_id": output.MediaID})
+	message := "Upload media successfully, processing..."
+	if output.Deduplicated {
+		message = "Media already uploaded, linked to the existing file"
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": message, "media_id": output.MediaID, "deduplicated": output.Deduplicated})
 }
 
 func (h *MediaHandler) UpdateMedia(c *gin.Context) {
@@ -119,6 +138,29 @@ func (h *MediaHandler) UpdateMedia(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Update media successfully"})
 }
 
+// ReprocessMedia queues pending or errored media for processing again.
+func (h *MediaHandler) ReprocessMedia(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	mediaID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid media ID", err))
+		return
+	}
+
+	m, err := h.reprocessUC.Execute(c.Request.Context(), mediaUC.ReprocessMediaInput{OwnerID: ownerID, MediaID: mediaID})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dto := ToMediaDTO(m)
+	dto.Processing = ToMediaProcessingDTO(m)
+	c.JSON(http.StatusAccepted, dto)
+}
+
 func (h *MediaHandler) DeleteMedia(c *gin.Context) {
 	ownerID, ok := GetOwnerIDFromGinContext(c)
 	if !ok {
@@ -143,7 +185,13 @@ func (h *MediaHandler) ListPublicMedia(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
 
-	input := mediaUC.ListPublicMediaInput{Limit: limit, Offset: (page - 1) * limit}
+	sort, err := media.ParseSort(c.Query("sort"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput(err.Error(), err))
+		return
+	}
+
+	input := mediaUC.ListPublicMediaInput{Limit: limit, Offset: (page - 1) * limit, Sort: sort}
 	output, err := h.listPublicUC.Execute(c.Request.Context(), input)
 	if err != nil {
 		c.Error(err)
@@ -156,3 +204,146 @@ func (h *MediaHandler) ListPublicMedia(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, dtos)
 }
+
+// ListMedia lists the owner's media. Filters: status, visibility
+// (public|private), type (a MIME type or family such as "image"), from and to
+// (RFC 3339 or YYYY-MM-DD, applied to the sort date), album (ID) and tag
+// (slug). sort is created_at or taken_at; order is desc (default) or asc.
+func (h *MediaHandler) ListMedia(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+
+	filter, err := parseMediaFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	input := mediaUC.ListMediaInput{OwnerID: ownerID, Filter: filter, Page: page, Limit: limit}
+	output, err := h.listMediaUC.Execute(c.Request.Context(), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dtos := make([]MediaDTO, len(output.Medias))
+	for i, m := range output.Medias {
+		dtos[i] = ToMediaDTO(m)
+		dtos[i].Processing = ToMediaProcessingDTO(m)
+		for _, t := range output.Tags[m.ID] {
+			dtos[i].Tags = append(dtos[i].Tags, t.Name)
+		}
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+func parseMediaFilter(c *gin.Context) (media.ListFilter, error) {
+	var f media.ListFilter
+	var err error
+
+	if f.Status, err = media.ParseStatus(c.Query("status")); err != nil {
+		return f, apperror.NewInvalidInput(err.Error(), err)
+	}
+	if f.Sort, err = media.ParseSort(c.Query("sort")); err != nil {
+		return f, apperror.NewInvalidInput(err.Error(), err)
+	}
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return f, apperror.NewInvalidInput("order must be one of asc, desc", nil)
+	}
+	switch visibility := c.Query("visibility"); visibility {
+	case "":
+	case "public", "private":
+		public := visibility == "public"
+		f.IsPublic = &public
+	default:
+		return f, apperror.NewInvalidInput("visibility must be one of public, private", nil)
+	}
+	f.ContentType = strings.ToLower(c.Query("type"))
+	f.TagSlug = c.Query("tag")
+
+	if v := c.Query("album"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return f, apperror.NewInvalidInput("invalid album ID", err)
+		}
+		f.AlbumID = &id
+	}
+	if f.From, err = parseDateQuery(c, "from", false); err != nil {
+		return f, err
+	}
+	if f.To, err = parseDateQuery(c, "to", true); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// parseDateQuery reads an RFC 3339 time or a plain date. A plain date used as
+// an upper bound covers the whole day.
+func parseDateQuery(c *gin.Context, key string, endOfDay bool) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("'%s' must be an RFC 3339 time or a YYYY-MM-DD date", key), err)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func (h *MediaHandler) BulkMedia(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+
+	var req BulkMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid request data", err))
+		return
+	}
+
+	input := mediaUC.BulkMediaInput{
+		OwnerID:  ownerID,
+		Action:   mediaUC.BulkAction(req.Action),
+		MediaIDs: req.MediaIDs,
+		TagNames: req.Tags,
+	}
+	output, err := h.bulkMediaUC.Execute(c.Request.Context(), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results := make([]BulkMediaResultDTO, len(output.Results))
+	for i, r := range output.Results {
+		results[i] = BulkMediaResultDTO{ID: r.MediaID.String(), Status: http.StatusOK}
+		if r.Err == nil {
+			continue
+		}
+		results[i].Status = apperror.ToHTTPStatus(r.Err)
+		var appErr *apperror.AppError
+		if errors.As(r.Err, &appErr) && results[i].Status < 500 {
+			results[i].Error = appErr.Message
+		} else {
+			results[i].Error = "internal server error"
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"action": req.Action, "results": results})
+}
@@ -3,14 +3,18 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/auth"
 	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
@@ -50,6 +54,37 @@ func AuthMiddleware(jwtSvc *auth.JWTService, log logger.Logger) gin.HandlerFunc
 	}
 }
 
+// RateLimitMiddleware allows at most limit requests per client IP in each
+// fixed window, counted in Redis under the given name. Requests are let
+// through when Redis is unavailable.
+func RateLimitMiddleware(rdb *redis.Client, name string, limit int, window time.Duration, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		bucket := time.Now().Unix() / int64(window.Seconds())
+		key := fmt.Sprintf("ratelimit:%s:%s:%d", name, c.ClientIP(), bucket)
+
+		pipe := rdb.TxPipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, window)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Warn("Rate limiter unavailable, allowing request", zap.String("limiter", name), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		count := incr.Val()
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(max(int64(limit)-count, 0), 10))
+		if count > int64(limit) {
+			c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
+			c.Error(apperror.NewRateLimited(fmt.Sprintf("rate limit '%s' exceeded for %s", name, c.ClientIP())))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 func GetOwnerIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	ownerID, ok := ctx.Value(GinContextKeyOwnerID).(uuid.UUID)
 	return ownerID, ok
@@ -12,6 +12,7 @@ import (
 	"github.com/khoahotran/personal-os/internal/domain/post"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/upload"
 )
 
 type PostHandler struct {
@@ -22,6 +23,9 @@ type PostHandler struct {
 	deletePostUseCase      *postUC.DeletePostUseCase
 	getPostUseCase         *postUC.GetPostUseCase
 	getPublicPostUseCase   *postUC.GetPublicPostUseCase
+	acceptSuggestionsUC    *postUC.AcceptSuggestionsUseCase
+	reprocessPostUC        *postUC.ReprocessPostUseCase
+	coverPolicy            upload.Policy
 	logger                 logger.Logger
 }
 
@@ -33,6 +37,9 @@ func NewPostHandler(
 	deleteUC *postUC.DeletePostUseCase,
 	getUC *postUC.GetPostUseCase,
 	getPublicUC *postUC.GetPublicPostUseCase,
+	acceptSuggestionsUC *postUC.AcceptSuggestionsUseCase,
+	reprocessPostUC *postUC.ReprocessPostUseCase,
+	coverPolicy upload.Policy,
 	log logger.Logger,
 ) *PostHandler {
 	return &PostHandler{
@@ -43,6 +50,9 @@ func NewPostHandler(
 		deletePostUseCase:      deleteUC,
 		getPostUseCase:         getUC,
 		getPublicPostUseCase:   getPublicUC,
+		acceptSuggestionsUC:    acceptSuggestionsUC,
+		reprocessPostUC:        reprocessPostUC,
+		coverPolicy:            coverPolicy,
 		logger:                 log,
 	}
 }
@@ -55,18 +65,12 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	fileHeader, err := c.FormFile("file")
+	cover, err := formFile(c, "file", h.coverPolicy)
 	if err != nil {
-		c.Error(apperror.NewInvalidInput("'file' is required", err))
-		return
-	}
-
-	file, err := fileHeader.Open()
-	if err != nil {
-		c.Error(apperror.NewInternal("failed to open file", err))
+		c.Error(err)
 		return
 	}
-	defer file.Close()
+	defer cover.File.Close()
 
 	dataJSON := c.PostForm("data")
 	if dataJSON == "" {
@@ -74,11 +78,12 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 	var reqData struct {
-		Title   string   `json:"title"`
-		Content string   `json:"content"`
-		Slug    string   `json:"slug"`
-		Status  string   `json:"status"`
-		Tags    []string `json:"tags"`
+		Title    string   `json:"title"`
+		Content  string   `json:"content"`
+		Slug     string   `json:"slug"`
+		Status   string   `json:"status"`
+		Language string   `json:"language"`
+		Tags     []string `json:"tags"`
 	}
 	if err := json.Unmarshal([]byte(dataJSON), &reqData); err != nil {
 		c.Error(apperror.NewInvalidInput("'data' field is not valid JSON", err))
@@ -106,8 +111,10 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		Slug:            reqData.Slug,
 		RequestedStatus: reqStatus,
 		TagNames:        reqData.Tags,
-		File:            file,
-		Metadata:        map[string]any{"original_filename": fileHeader.Filename, "requested_status": string(reqStatus)},
+		Language:        post.Language(reqData.Language),
+		File:            cover.File,
+		ContentType:     cover.ContentType,
+		Metadata:        map[string]any{"original_filename": cover.Header.Filename, "requested_status": string(reqStatus)},
 	}
 
 	output, err := h.createPostUseCase.Execute(c.Request.Context(), input)
@@ -192,13 +199,14 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 	}
 
 	input := postUC.UpdatePostInput{
-		PostID:  postID,
-		OwnerID: ownerID,
-		Title:   req.Title,
-		Content: req.Content,
-		Slug:    req.Slug,
-		Status:  req.ToDomainPostStatus(),
-		Tags:    req.Tags,
+		PostID:   postID,
+		OwnerID:  ownerID,
+		Title:    req.Title,
+		Content:  req.Content,
+		Slug:     req.Slug,
+		Status:   req.ToDomainPostStatus(),
+		Language: post.Language(req.Language),
+		Tags:     req.Tags,
 	}
 
 	output, err := h.updatePostUseCase.Execute(c.Request.Context(), input)
@@ -261,7 +269,67 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, ToPostDTO(output.Post, output.Tags))
+	dto := ToPostDTO(output.Post, output.Tags)
+	dto.Suggestions = ToPostSuggestionsDTO(output.Post)
+	c.JSON(http.StatusOK, dto)
+}
+
+func (h *PostHandler) AcceptSuggestions(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid post ID", err))
+		return
+	}
+
+	var req AcceptSuggestionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid JSON body", err))
+		return
+	}
+
+	output, err := h.acceptSuggestionsUC.Execute(c.Request.Context(), postUC.AcceptSuggestionsInput{
+		PostID:               postID,
+		OwnerID:              ownerID,
+		AcceptSummary:        req.Summary,
+		AcceptSEODescription: req.SEODescription,
+		Tags:                 req.Tags,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dto := ToPostDTO(output.Post, output.Tags)
+	dto.Suggestions = ToPostSuggestionsDTO(output.Post)
+	c.JSON(http.StatusOK, dto)
+}
+
+// ReprocessPost re-emits the processing event of a post stuck in 'pending'.
+func (h *PostHandler) ReprocessPost(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid post ID", err))
+		return
+	}
+
+	input := postUC.ReprocessPostInput{PostID: postID, OwnerID: ownerID}
+	if err := h.reprocessPostUC.Execute(c.Request.Context(), input); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Post queued for reprocessing"})
 }
 
 func (h *PostHandler) GetPublicPost(c *gin.Context) {
@@ -274,5 +342,9 @@ func (h *PostHandler) GetPublicPost(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, ToPostDTO(output.Post, output.Tags))
+	dto := ToPostDTO(output.Post, output.Tags)
+	for _, a := range output.Albums {
+		dto.Albums = append(dto.Albums, ToAlbumDTO(a))
+	}
+	c.JSON(http.StatusOK, dto)
 }
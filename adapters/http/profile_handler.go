@@ -58,6 +58,7 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 		Bio:            req.Bio,
 		CareerTimeline: req.ToDomainMilestones(),
 		ThemeSettings:  req.ThemeSettings,
+		ChatPersona:    req.ChatPersona,
 	}
 	output, err := h.profileUseCase.ExecuteUpdateProfile(c.Request.Context(), input)
 	if err != nil {
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	promptUC "github.com/khoahotran/personal-os/internal/application/usecase/prompt"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type PromptHandler struct {
+	useCase *promptUC.PromptUseCase
+	logger  logger.Logger
+}
+
+func NewPromptHandler(uc *promptUC.PromptUseCase, log logger.Logger) *PromptHandler {
+	return &PromptHandler{useCase: uc, logger: log}
+}
+
+func (h *PromptHandler) ListPrompts(c *gin.Context) {
+	templates, err := h.useCase.ListActive(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	dtos := make([]PromptTemplateDTO, len(templates))
+	for i, t := range templates {
+		dtos[i] = ToPromptTemplateDTO(t)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+func (h *PromptHandler) GetPrompt(c *gin.Context) {
+	output, err := h.useCase.GetTemplate(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	versions := make([]PromptTemplateDTO, len(output.Versions))
+	for i, t := range output.Versions {
+		versions[i] = ToPromptTemplateDTO(t)
+	}
+	c.JSON(http.StatusOK, PromptTemplateDetailDTO{
+		Active:   ToPromptTemplateDTO(output.Active),
+		Default:  ToPromptTemplateDTO(output.Default),
+		Versions: versions,
+	})
+}
+
+func (h *PromptHandler) SavePrompt(c *gin.Context) {
+	var req SavePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid JSON body", err))
+		return
+	}
+
+	t, err := h.useCase.SaveTemplate(c.Request.Context(), promptUC.SaveTemplateInput{
+		Name:   c.Param("name"),
+		System: req.System,
+		User:   req.User,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, ToPromptTemplateDTO(t))
+}
+
+func (h *PromptHandler) ActivatePrompt(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.Error(apperror.NewInvalidInput("invalid template version", err))
+		return
+	}
+	if err := h.useCase.ActivateVersion(c.Request.Context(), c.Param("name"), version); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
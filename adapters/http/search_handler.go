@@ -3,11 +3,14 @@ package http
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	searchUC "github.com/khoahotran/personal-os/internal/application/usecase/search"
+	"github.com/khoahotran/personal-os/internal/domain/search"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
 )
@@ -32,6 +35,16 @@ func (h *SearchHandler) handleSearch(c *gin.Context, isPublic bool) {
 		return
 	}
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	mode, ok := search.ParseMode(c.Query("mode"))
+	if !ok {
+		c.Error(apperror.NewInvalidInput("'mode' must be one of keyword, semantic, hybrid", nil))
+		return
+	}
+	filters, err := parseSearchFilters(c, isPublic)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
 	var ownerID uuid.UUID
 	if !isPublic {
@@ -48,6 +61,9 @@ func (h *SearchHandler) handleSearch(c *gin.Context, isPublic bool) {
 		OwnerID:  ownerID,
 		IsPublic: isPublic,
 		Limit:    limit,
+		Mode:     mode,
+		Filters:  filters,
+		Cursor:   c.Query("cursor"),
 	}
 	output, err := h.searchUseCase.Execute(c.Request.Context(), input)
 	if err != nil {
@@ -55,17 +71,84 @@ func (h *SearchHandler) handleSearch(c *gin.Context, isPublic bool) {
 		return
 	}
 
-	dtos := make([]SearchResultDTO, len(output.Results))
-	for i, res := range output.Results {
-		dtos[i] = ToSearchResultDTO(res)
+	c.JSON(http.StatusOK, ToSearchResponse(output.Results, output.Mode, output.Facets, output.NextCursor))
+}
+
+// parseSearchFilters reads type, tag, from, to and (admin only) status. List
+// params accept repeated keys and comma-separated values alike.
+func parseSearchFilters(c *gin.Context, isPublic bool) (search.Filters, error) {
+	f := search.Filters{
+		Types: queryList(c, "type"),
+		Tags:  queryList(c, "tag"),
 	}
-	c.JSON(http.StatusOK, dtos)
+	if !isPublic {
+		f.Statuses = queryList(c, "status")
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, _, err := parseSearchDate(v)
+		if err != nil {
+			return f, apperror.NewInvalidInput("'from' must be a date (YYYY-MM-DD) or RFC3339 timestamp", err)
+		}
+		f.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, dateOnly, err := parseSearchDate(v)
+		if err != nil {
+			return f, apperror.NewInvalidInput("'to' must be a date (YYYY-MM-DD) or RFC3339 timestamp", err)
+		}
+		// A bare date includes the whole day.
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		f.To = &to
+	}
+	return f, nil
+}
+
+func parseSearchDate(v string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, false, err
+}
+
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
 }
 
 func (h *SearchHandler) SearchPublic(c *gin.Context) {
 	h.handleSearch(c, true)
 }
 
+func (h *SearchHandler) SuggestPublic(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+
+	suggestions, err := h.searchUseCase.Suggest(c.Request.Context(), searchUC.SuggestInput{
+		Query: c.Query("q"),
+		Limit: limit,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dtos := make([]SearchSuggestionDTO, len(suggestions))
+	for i, s := range suggestions {
+		dtos[i] = ToSearchSuggestionDTO(s)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
 func (h *SearchHandler) SearchPrivate(c *gin.Context) {
 	h.handleSearch(c, false)
 }
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/signedurl"
+	"go.uber.org/zap"
+)
+
+// PublicMediaFS serves a local storage root at its permanent URLs, without
+// listing directories and without the objects under service.PrivatePrefix,
+// which only signed URLs reach.
+func PublicMediaFS(root string) http.FileSystem {
+	return publicMediaFS{gin.Dir(root, false)}
+}
+
+type publicMediaFS struct {
+	http.FileSystem
+}
+
+func (fs publicMediaFS) Open(name string) (http.File, error) {
+	if service.IsPrivateKey(strings.TrimPrefix(path.Clean(name), "/") + "/") {
+		return nil, os.ErrNotExist
+	}
+	return fs.FileSystem.Open(name)
+}
+
+// SignedMediaHandler serves objects at the signed URLs issued by storage
+// drivers that have no signing of their own, such as local storage.
+type SignedMediaHandler struct {
+	storage service.Storage
+	signer  *signedurl.Signer
+	logger  logger.Logger
+}
+
+func NewSignedMediaHandler(s service.Storage, signer *signedurl.Signer, log logger.Logger) *SignedMediaHandler {
+	return &SignedMediaHandler{storage: s, signer: signer, logger: log}
+}
+
+// ServeSigned streams the object named by the *key path parameter once its
+// signature and expiry check out.
+func (h *SignedMediaHandler) ServeSigned(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	query := c.Request.URL.Query()
+	now := time.Now()
+	if err := h.signer.Verify(key, query, now); err != nil {
+		c.Error(apperror.NewPermissionDenied(err.Error()))
+		return
+	}
+
+	rc, err := h.storage.Open(c.Request.Context(), key)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer rc.Close()
+
+	// Caches may keep the object no longer than the link is valid.
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	c.Header("Cache-Control", "private, max-age="+strconv.FormatInt(expires-now.Unix(), 10))
+
+	if rs, ok := rc.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, path.Base(key), time.Time{}, rs)
+		return
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, rc); err != nil {
+		h.logger.Warn("Failed to stream signed media", zap.String("key", key), zap.Error(err))
+	}
+}
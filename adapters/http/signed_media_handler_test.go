@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/adapters/media_storage"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/signedurl"
+)
+
+func TestSignedMediaHandler_ServeSigned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewZapLogger("development")
+	storage, err := media_storage.NewLocalStorage(media_storage.LocalSettings{
+		Root:          t.TempDir(),
+		BaseURL:       "/media",
+		SignedBaseURL: "/api/media/signed",
+		Signer:        signedurl.New("secret", time.Minute),
+	}, log)
+	require.NoError(t, err)
+
+	key := "users/abc/media/originals/private photo"
+	_, err = storage.Put(context.Background(), key, strings.NewReader("secret bytes"), "text/plain")
+	require.NoError(t, err)
+	signed, err := storage.SignedURL(context.Background(), key, "text/plain")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, "/api/media/signed/users/abc/media/originals/private%20photo?"))
+
+	router := gin.New()
+	router.Use(ErrorMiddleware(log))
+	h := NewSignedMediaHandler(storage, storage.Signer(), log)
+	router.GET(storage.SignedServePath()+"/*key", h.ServeSigned)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, signed, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "secret bytes", w.Body.String())
+	assert.Contains(t, w.Header().Get("Cache-Control"), "private")
+
+	tampered := strings.Replace(signed, "private%20photo", "other", 1)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tampered, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.Split(signed, "?")[0], nil))
+	assert.Equal(t, http.StatusForbidden, w.Code, "unsigned requests are refused")
+}
+
+func TestPublicMediaFS_HidesPrivateObjects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewZapLogger("development")
+	storage, err := media_storage.NewLocalStorage(media_storage.LocalSettings{Root: t.TempDir(), BaseURL: "/media"}, log)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.StaticFS(storage.ServePath(), PublicMediaFS(storage.Root()))
+
+	public, private := "users/abc/media/originals/public", "private/users/abc/media/originals/private"
+	for _, key := range []string{public, private} {
+		_, err := storage.Put(context.Background(), key, strings.NewReader("bytes"), "text/plain")
+		require.NoError(t, err)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, storage.URL(public, "text/plain"), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, u := range []string{storage.URL(private, "text/plain"), "/media/private/", "/media/users/../private/users/abc/media/originals/private"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, u)
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/upload"
+)
+
+// formOverhead leaves room for the other form fields and multipart framing.
+const formOverhead = 1 << 20
+
+// uploadedFile is a validated multipart file. File must be closed.
+type uploadedFile struct {
+	File        multipart.File
+	Header      *multipart.FileHeader
+	ContentType string
+}
+
+// formFile opens the named file field and checks it against policy. The
+// request body is capped first, so an oversized upload is cut off while it
+// is being received instead of being spooled in full.
+func formFile(c *gin.Context, field string, policy upload.Policy) (*uploadedFile, error) {
+	if policy.MaxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, policy.MaxSize+formOverhead)
+	}
+
+	header, err := c.FormFile(field)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, policy.TooLarge()
+		}
+		return nil, apperror.NewInvalidInput("'"+field+"' is required", err)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to open file", err)
+	}
+	contentType, err := policy.Check(header.Size, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &uploadedFile{File: file, Header: header, ContentType: contentType}, nil
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	mediaUC "github.com/khoahotran/personal-os/internal/application/usecase/media"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// UploadSessionHandler serves resumable uploads: a session is started with
+// the file's size and SHA-256, parts are sent as raw request bodies in any
+// order, and completing the session turns the file into media.
+type UploadSessionHandler struct {
+	useCase *mediaUC.ResumableUploadUseCase
+	logger  logger.Logger
+}
+
+func NewUploadSessionHandler(uc *mediaUC.ResumableUploadUseCase, log logger.Logger) *UploadSessionHandler {
+	return &UploadSessionHandler{useCase: uc, logger: log}
+}
+
+func (h *UploadSessionHandler) StartUpload(c *gin.Context) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return
+	}
+
+	var req StartUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.NewInvalidInput("invalid request data", err))
+		return
+	}
+
+	input := mediaUC.StartUploadInput{
+		OwnerID:      ownerID,
+		FileName:     req.FileName,
+		Size:         req.Size,
+		SHA256:       req.SHA256,
+		Metadata:     req.Metadata,
+		IsPublic:     req.IsPublic,
+		KeepLocation: req.KeepLocation,
+	}
+
+	s, err := h.useCase.StartUpload(c.Request.Context(), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, ToUploadSessionDTO(s))
+}
+
+func (h *UploadSessionHandler) GetUpload(c *gin.Context) {
+	ownerID, sessionID, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+
+	s, err := h.useCase.GetUpload(c.Request.Context(), sessionID, ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ToUploadSessionDTO(s))
+}
+
+// UploadPart reads the part from the raw request body.
+func (h *UploadSessionHandler) UploadPart(c *gin.Context) {
+	ownerID, sessionID, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+	part, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid part number", err))
+		return
+	}
+
+	input := mediaUC.UploadPartInput{
+		SessionID: sessionID,
+		OwnerID:   ownerID,
+		Part:      part,
+		Body:      c.Request.Body,
+	}
+
+	s, err := h.useCase.UploadPart(c.Request.Context(), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ToUploadSessionDTO(s))
+}
+
+func (h *UploadSessionHandler) CompleteUpload(c *gin.Context) {
+	ownerID, sessionID, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+
+	output, err := h.useCase.CompleteUpload(c.Request.Context(), sessionID, ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	message := "Upload media successfully, processing..."
+	if output.Deduplicated {
+		message = "Media already uploaded, linked to the existing file"
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": message, "media_id": output.MediaID, "deduplicated": output.Deduplicated})
+}
+
+func (h *UploadSessionHandler) AbortUpload(c *gin.Context) {
+	ownerID, sessionID, ok := uploadSessionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.useCase.AbortUpload(c.Request.Context(), sessionID, ownerID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func uploadSessionParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	ownerID, ok := GetOwnerIDFromGinContext(c)
+	if !ok {
+		c.Error(apperror.NewPermissionDenied("ownerID not found in context"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.NewInvalidInput("invalid upload session ID", err))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return ownerID, sessionID, true
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/upload"
+)
+
+func multipartRequest(t *testing.T, field string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	require.NoError(t, w.WriteField("data", `{}`))
+	part, err := w.CreateFormFile(field, "upload.bin")
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func runFormFile(t *testing.T, req *http.Request, policy upload.Policy) (*uploadedFile, error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return formFile(c, "file", policy)
+}
+
+func TestFormFile(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 100)...)
+	policy := upload.Policy{MaxSize: 1 << 10, Allowed: upload.ImageTypes}
+
+	f, err := runFormFile(t, multipartRequest(t, "file", png), policy)
+	require.NoError(t, err)
+	defer f.File.Close()
+	assert.Equal(t, "image/png", f.ContentType)
+	assert.Equal(t, "upload.bin", f.Header.Filename)
+
+	_, err = runFormFile(t, multipartRequest(t, "other", png), policy)
+	assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+
+	_, err = runFormFile(t, multipartRequest(t, "file", []byte("plain text")), policy)
+	assert.True(t, errors.Is(err, apperror.ErrUnsupported))
+
+	_, err = runFormFile(t, multipartRequest(t, "file", append(png, make([]byte, 2<<10)...)), policy)
+	assert.True(t, errors.Is(err, apperror.ErrTooLarge), "over the limit but within the body allowance")
+
+	_, err = runFormFile(t, multipartRequest(t, "file", append(png, make([]byte, 2<<20)...)), policy)
+	assert.True(t, errors.Is(err, apperror.ErrTooLarge), "body cut off while reading")
+}
@@ -0,0 +1,548 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+const (
+	jpegSOI  = 0xD8
+	jpegSOS  = 0xDA
+	jpegEOI  = 0xD9
+	jpegAPP1 = 0xE1
+
+	tagGPSInfo = 0x8825
+
+	// maxMetadataSize caps the EXIF blocks read into memory. Larger blocks
+	// are ignored when extracting and dropped or cleared when stripping.
+	maxMetadataSize = 1 << 20
+)
+
+var (
+	exifHeader   = []byte("Exif\x00\x00")
+	xmpHeader    = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+	// emptyXMP replaces XMP packets in files whose layout cannot lose bytes.
+	emptyXMP = []byte(`<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?><x:xmpmeta xmlns:x="adobe:ns:meta/"/><?xpacket end="w"?>`)
+)
+
+type exifService struct {
+	logger logger.Logger
+}
+
+func NewExifService(log logger.Logger) service.ExifService {
+	return &exifService{logger: log}
+}
+
+func (s *exifService) Extract(r io.Reader) (*service.PhotoMetadata, error) {
+	block, err := findExif(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	x, err := exif.Decode(bytes.NewReader(block))
+	// Non-critical errors leave a partially decoded block, which is still
+	// worth reading.
+	if err != nil && exif.IsCriticalError(err) {
+		return nil, err
+	}
+
+	m := &service.PhotoMetadata{
+		CameraMake:  stringField(x, exif.Make),
+		CameraModel: stringField(x, exif.Model),
+		Lens:        stringField(x, exif.LensModel),
+		FNumber:     ratField(x, exif.FNumber),
+		FocalLength: ratField(x, exif.FocalLength),
+	}
+	if tag, err := x.Get(exif.ExposureTime); err == nil {
+		if num, den, err := tag.Rat2(0); err == nil && den != 0 {
+			m.ExposureTime = formatExposure(num, den)
+		}
+	}
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if iso, err := tag.Int(0); err == nil {
+			m.ISO = iso
+		}
+	}
+	if t, err := x.DateTime(); err == nil && !t.IsZero() {
+		t = t.UTC()
+		m.TakenAt = &t
+	}
+	if lat, long, err := x.LatLong(); err == nil {
+		m.Latitude, m.Longitude = &lat, &long
+	}
+	return m, nil
+}
+
+func stringField(x *exif.Exif, name exif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+	v, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(v, "\x00"))
+}
+
+func ratField(x *exif.Exif, name exif.FieldName) float64 {
+	tag, err := x.Get(name)
+	if err != nil {
+		return 0
+	}
+	r, err := tag.Rat(0)
+	if err != nil {
+		return 0
+	}
+	f, _ := r.Float64()
+	return f
+}
+
+// formatExposure renders shutter speeds the way cameras show them: "1/250"
+// below a second and "2.5" above.
+func formatExposure(num, den int64) string {
+	if num < den {
+		if num == 0 {
+			return "0"
+		}
+		return "1/" + formatFloat(float64(den)/float64(num))
+	}
+	return formatFloat(float64(num) / float64(den))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+}
+
+type format int
+
+const (
+	formatOther format = iota
+	formatTIFF
+	formatJPEG
+	formatPNG
+	formatWebP
+)
+
+// sniff tells the format of r from its first bytes, without consuming them.
+func sniff(r *bufio.Reader) format {
+	head, _ := r.Peek(12)
+	switch {
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return formatTIFF
+	case len(head) >= 2 && head[0] == 0xFF && head[1] == jpegSOI:
+		return formatJPEG
+	case bytes.HasPrefix(head, pngSignature):
+		return formatPNG
+	case len(head) == 12 && string(head[:4]) == "RIFF" && string(head[8:]) == "WEBP":
+		return formatWebP
+	}
+	return formatOther
+}
+
+// findExif returns the EXIF block of a TIFF, JPEG, PNG or WebP for
+// exif.Decode, or nil when there is none. Memory stays bounded: a JPEG is read
+// up to its image data, and PNG and WebP image chunks are skipped over, as
+// WebP keeps its EXIF after them. A TIFF, whose IFDs may point anywhere, is
+// read up to maxMetadataSize.
+func findExif(r *bufio.Reader) ([]byte, error) {
+	var block []byte
+	var err error
+	switch sniff(r) {
+	case formatTIFF:
+		block, err = io.ReadAll(io.LimitReader(r, maxMetadataSize))
+	case formatJPEG:
+		err = walkJPEG(r, func(marker byte, header []byte, payload io.Reader) error {
+			if marker != jpegAPP1 {
+				return nil
+			}
+			data, err := io.ReadAll(payload)
+			if err == nil && bytes.HasPrefix(data, exifHeader) {
+				block = data
+				return errStopWalk
+			}
+			return err
+		})
+	case formatPNG:
+		err = walkPNG(r, func(typ string, header []byte, length int64, body io.Reader) error {
+			if typ != "eXIf" || length > maxMetadataSize {
+				return nil
+			}
+			data, err := readN(body, length)
+			if err != nil {
+				return err
+			}
+			block = data
+			return errStopWalk
+		})
+	case formatWebP:
+		err = walkWebP(r, func(fourcc string, header []byte, size int64, body io.Reader) error {
+			if fourcc != "EXIF" || size > maxMetadataSize {
+				return nil
+			}
+			data, err := readN(body, size)
+			if err != nil {
+				return err
+			}
+			block = data
+			return errStopWalk
+		})
+	}
+	if err != nil && !errors.Is(err, errStopWalk) {
+		return nil, err
+	}
+	if len(block) == 0 {
+		return nil, nil
+	}
+	return block, nil
+}
+
+// StripLocation copies src to dst, clearing the GPS directory of the EXIF
+// block of a JPEG, PNG or WebP and dropping or emptying XMP packets, which may
+// repeat the coordinates. Other EXIF fields are kept, and other formats are
+// copied unchanged. Only metadata is held in memory.
+func (s *exifService) StripLocation(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	var err error
+	switch sniff(r) {
+	case formatJPEG:
+		err = s.stripJPEG(dst, r)
+	case formatPNG:
+		err = s.stripPNG(dst, r)
+	case formatWebP:
+		err = s.stripWebP(dst, r)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+func (s *exifService) stripJPEG(dst io.Writer, r *bufio.Reader) error {
+	if _, err := dst.Write([]byte{0xFF, jpegSOI}); err != nil {
+		return err
+	}
+	return walkJPEG(r, func(marker byte, header []byte, payload io.Reader) error {
+		if marker != jpegAPP1 {
+			return copySegment(dst, header, payload)
+		}
+		data, err := io.ReadAll(payload)
+		if err != nil {
+			return err
+		}
+		switch {
+		case bytes.HasPrefix(data, xmpHeader):
+			return nil
+		case bytes.HasPrefix(data, exifHeader):
+			if !clearGPS(data[len(exifHeader):]) {
+				// Unreadable EXIF: drop it rather than risk leaking the
+				// location.
+				s.logger.Warn("Dropping malformed EXIF block while stripping location")
+				return nil
+			}
+		}
+		return copySegment(dst, header, bytes.NewReader(data))
+	})
+}
+
+// stripPNG rewrites the eXIf chunk and drops text chunks carrying XMP or raw
+// EXIF profiles.
+func (s *exifService) stripPNG(dst io.Writer, r *bufio.Reader) error {
+	if _, err := dst.Write(pngSignature); err != nil {
+		return err
+	}
+	return walkPNG(r, func(typ string, header []byte, length int64, body io.Reader) error {
+		switch typ {
+		case "eXIf":
+			if length > maxMetadataSize {
+				s.logger.Warn("Dropping oversized EXIF chunk while stripping location")
+				return nil
+			}
+			data, err := readN(body, length)
+			if err != nil {
+				return err
+			}
+			if !clearGPS(bytes.TrimPrefix(data, exifHeader)) {
+				s.logger.Warn("Dropping malformed EXIF chunk while stripping location")
+				return nil
+			}
+			crc := crc32.NewIEEE()
+			crc.Write(header[4:])
+			crc.Write(data)
+			return copySegment(dst, header, bytes.NewReader(binary.BigEndian.AppendUint32(data, crc.Sum32())))
+		case "tEXt", "zTXt", "iTXt":
+			// Keywords are at most 79 bytes and end with a NUL.
+			keyword, err := readN(body, min(length, 80))
+			if err != nil {
+				return err
+			}
+			if isMetadataKeyword(keyword) {
+				return nil
+			}
+			return copySegment(dst, header, io.MultiReader(bytes.NewReader(keyword), body))
+		}
+		return copySegment(dst, header, body)
+	})
+}
+
+// isMetadataKeyword reports whether a PNG text chunk holds an XMP packet, or
+// an EXIF or XMP profile as written by ImageMagick.
+func isMetadataKeyword(text []byte) bool {
+	keyword, _, _ := bytes.Cut(text, []byte{0})
+	return string(keyword) == "XML:com.adobe.xmp" || bytes.HasPrefix(keyword, []byte("Raw profile type"))
+}
+
+// stripWebP keeps every chunk in place, since dropping one would mean
+// rewriting the RIFF size and the VP8X flags already written: the EXIF chunk
+// loses its GPS directory and the XMP chunk is emptied.
+func (s *exifService) stripWebP(dst io.Writer, r *bufio.Reader) error {
+	header, err := r.Peek(12)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	return walkWebP(r, func(fourcc string, header []byte, size int64, body io.Reader) error {
+		switch fourcc {
+		case "EXIF":
+			if size > maxMetadataSize {
+				s.logger.Warn("Clearing oversized EXIF chunk while stripping location")
+				return copySegment(dst, header, io.MultiReader(replace(body, size, nil, 0), body))
+			}
+			data, err := readN(body, size)
+			if err != nil {
+				return err
+			}
+			if !clearGPS(bytes.TrimPrefix(data, exifHeader)) {
+				s.logger.Warn("Clearing malformed EXIF chunk while stripping location")
+				clear(data)
+			}
+			return copySegment(dst, header, io.MultiReader(bytes.NewReader(data), body))
+		case "XMP ":
+			return copySegment(dst, header, io.MultiReader(replace(body, size, emptyXMP, ' '), body))
+		}
+		return copySegment(dst, header, body)
+	})
+}
+
+// replace reads n bytes from r and returns a reader of as many bytes of
+// fill, starting with prefix when it fits.
+func replace(r io.Reader, n int64, prefix []byte, fill byte) io.Reader {
+	if int64(len(prefix)) > n {
+		prefix = nil
+	}
+	return &replaceReader{r: io.LimitReader(r, n), prefix: prefix, fill: fill}
+}
+
+type replaceReader struct {
+	r      io.Reader
+	prefix []byte
+	fill   byte
+}
+
+func (rr *replaceReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	for i := range p[:n] {
+		if len(rr.prefix) > 0 {
+			p[i], rr.prefix = rr.prefix[0], rr.prefix[1:]
+		} else {
+			p[i] = rr.fill
+		}
+	}
+	return n, err
+}
+
+// errStopWalk ends a walk early without failing it.
+var errStopWalk = errors.New("stop walking")
+
+func readN(r io.Reader, n int64) ([]byte, error) {
+	data := make([]byte, n)
+	_, err := io.ReadFull(r, data)
+	return data, err
+}
+
+func copySegment(dst io.Writer, header []byte, body io.Reader) error {
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	_, err := io.Copy(dst, body)
+	return err
+}
+
+// walkJPEG reads the marker segments of a JPEG before its image data, after
+// the SOI marker, and calls fn with each: its marker and length bytes, and a
+// reader of its payload. Payload fn does not read is skipped. Walking stops
+// before the image data, leaving it in r.
+func walkJPEG(r *bufio.Reader, fn func(marker byte, header []byte, payload io.Reader) error) error {
+	if _, err := r.Discard(2); err != nil {
+		return err
+	}
+	for {
+		header, err := r.Peek(4)
+		if err != nil || header[0] != 0xFF || header[1] == jpegSOS || header[1] == jpegEOI {
+			return nil
+		}
+		length := int64(binary.BigEndian.Uint16(header[2:4]))
+		if length < 2 {
+			return nil
+		}
+		header = bytes.Clone(header)
+		r.Discard(4)
+		if err := walkStep(r, length-2, func(body io.Reader) error { return fn(header[1], header, body) }); err != nil {
+			return err
+		}
+	}
+}
+
+// walkPNG reads the chunks of a PNG, after its signature, and calls fn with
+// each: its length and type bytes, the data length, and a reader of the data
+// and CRC. What fn does not read is skipped.
+func walkPNG(r *bufio.Reader, fn func(typ string, header []byte, length int64, body io.Reader) error) error {
+	if _, err := r.Discard(len(pngSignature)); err != nil {
+		return err
+	}
+	for {
+		header, err := r.Peek(8)
+		if err != nil {
+			return nil
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		header = bytes.Clone(header)
+		r.Discard(8)
+		if err := walkStep(r, length+4, func(body io.Reader) error { return fn(string(header[4:]), header, length, body) }); err != nil {
+			return err
+		}
+	}
+}
+
+// walkWebP reads the chunks of a WebP, after its RIFF header, and calls fn
+// with each: its FourCC and size bytes, the payload size, and a reader of the
+// payload and its padding byte. What fn does not read is skipped.
+func walkWebP(r *bufio.Reader, fn func(fourcc string, header []byte, size int64, body io.Reader) error) error {
+	if _, err := r.Discard(12); err != nil {
+		return err
+	}
+	for {
+		header, err := r.Peek(8)
+		if err != nil {
+			return nil
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:]))
+		header = bytes.Clone(header)
+		r.Discard(8)
+		if err := walkStep(r, size+size&1, func(body io.Reader) error { return fn(string(header[:4]), header, size, body) }); err != nil {
+			return err
+		}
+	}
+}
+
+// walkStep hands the next n bytes of r to fn and skips what it leaves.
+func walkStep(r io.Reader, n int64, fn func(body io.Reader) error) error {
+	body := io.LimitReader(r, n)
+	if err := fn(body); err != nil {
+		return err
+	}
+	_, err := io.Copy(io.Discard, body)
+	return err
+}
+
+// clearGPS zeroes every entry of the GPS IFD in a TIFF block, including
+// values stored out of line, and marks the IFD empty. It reports false when
+// the block is malformed.
+func clearGPS(tiff []byte) bool {
+	if len(tiff) < 8 {
+		return false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return false
+	}
+
+	ifd0 := int(order.Uint32(tiff[4:8]))
+	entries, ok := ifdEntries(tiff, order, ifd0)
+	if !ok {
+		return false
+	}
+	gps := -1
+	for _, e := range entries {
+		if order.Uint16(e[0:2]) == tagGPSInfo {
+			gps = int(order.Uint32(e[8:12]))
+		}
+	}
+	if gps < 0 {
+		return true
+	}
+
+	gpsEntries, ok := ifdEntries(tiff, order, gps)
+	if !ok {
+		return false
+	}
+	for _, e := range gpsEntries {
+		size := typeSize(order.Uint16(e[2:4])) * int(order.Uint32(e[4:8]))
+		if size > 4 {
+			off := int(order.Uint32(e[8:12]))
+			if off < 0 || off+size > len(tiff) {
+				return false
+			}
+			clear(tiff[off : off+size])
+		}
+		clear(e)
+	}
+	order.PutUint16(tiff[gps:gps+2], 0)
+	return true
+}
+
+// ifdEntries returns the 12-byte entries of the IFD at offset, as slices of
+// tiff so they can be modified in place.
+func ifdEntries(tiff []byte, order binary.ByteOrder, offset int) ([][]byte, bool) {
+	if offset < 8 || offset+2 > len(tiff) {
+		return nil, false
+	}
+	n := int(order.Uint16(tiff[offset : offset+2]))
+	start := offset + 2
+	if start+n*12 > len(tiff) {
+		return nil, false
+	}
+	entries := make([][]byte, n)
+	for i := range entries {
+		entries[i] = tiff[start+i*12 : start+(i+1)*12]
+	}
+	return entries, true
+}
+
+// typeSize is the byte size of one value of a TIFF field type.
+func typeSize(t uint16) int {
+	switch t {
+	case 1, 2, 6, 7: // BYTE, ASCII, SBYTE, UNDEFINED
+		return 1
+	case 3, 8: // SHORT, SSHORT
+		return 2
+	case 4, 9, 11: // LONG, SLONG, FLOAT
+		return 4
+	case 5, 10, 12: // RATIONAL, SRATIONAL, DOUBLE
+		return 8
+	}
+	return 0
+}
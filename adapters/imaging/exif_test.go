@@ -0,0 +1,249 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type testEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+func asciiEntry(tag uint16, s string) testEntry {
+	return testEntry{tag: tag, typ: 2, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func shortEntry(tag, v uint16) testEntry {
+	return testEntry{tag: tag, typ: 3, count: 1, data: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+func rationalEntry(tag uint16, vals ...uint32) testEntry {
+	var data []byte
+	for _, v := range vals {
+		data = binary.LittleEndian.AppendUint32(data, v)
+	}
+	return testEntry{tag: tag, typ: 5, count: uint32(len(vals) / 2), data: data}
+}
+
+// buildTIFF lays out a little-endian TIFF block with IFD0 pointing at an
+// EXIF and a GPS sub-IFD.
+func buildTIFF(ifd0, exifIFD, gps []testEntry) []byte {
+	ifdSize := func(n int) int { return 2 + 12*n + 4 }
+	ifd0Off := 8
+	exifOff := ifd0Off + ifdSize(len(ifd0)+2)
+	gpsOff := exifOff + ifdSize(len(exifIFD))
+	dataOff := gpsOff + ifdSize(len(gps))
+
+	ifd0 = append(ifd0,
+		testEntry{tag: 0x8769, typ: 4, count: 1, data: binary.LittleEndian.AppendUint32(nil, uint32(exifOff))},
+		testEntry{tag: tagGPSInfo, typ: 4, count: 1, data: binary.LittleEndian.AppendUint32(nil, uint32(gpsOff))},
+	)
+
+	out := []byte("II*\x00")
+	out = binary.LittleEndian.AppendUint32(out, uint32(ifd0Off))
+	var extra []byte
+	for _, ifd := range [][]testEntry{ifd0, exifIFD, gps} {
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(ifd)))
+		for _, e := range ifd {
+			out = binary.LittleEndian.AppendUint16(out, e.tag)
+			out = binary.LittleEndian.AppendUint16(out, e.typ)
+			out = binary.LittleEndian.AppendUint32(out, e.count)
+			if len(e.data) <= 4 {
+				out = append(out, e.data...)
+				out = append(out, make([]byte, 4-len(e.data))...)
+			} else {
+				out = binary.LittleEndian.AppendUint32(out, uint32(dataOff+len(extra)))
+				extra = append(extra, e.data...)
+			}
+		}
+		out = binary.LittleEndian.AppendUint32(out, 0)
+	}
+	return append(out, extra...)
+}
+
+// testTIFF is an EXIF block from a Canon, with a location.
+func testTIFF() []byte {
+	return buildTIFF(
+		[]testEntry{asciiEntry(0x010F, "Canon"), asciiEntry(0x0110, "EOS R6")},
+		[]testEntry{
+			rationalEntry(0x829A, 1, 250),
+			rationalEntry(0x829D, 28, 10),
+			shortEntry(0x8827, 200),
+			asciiEntry(0x9003, "2024:05:01 10:20:30"),
+		},
+		[]testEntry{
+			asciiEntry(0x0001, "N"),
+			rationalEntry(0x0002, 10, 1, 46, 1, 0, 1),
+			asciiEntry(0x0003, "E"),
+			rationalEntry(0x0004, 106, 1, 40, 1, 0, 1),
+		},
+	)
+}
+
+func testPhoto(t *testing.T) []byte {
+	t.Helper()
+	tiff := testTIFF()
+
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	img.Set(0, 0, color.White)
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	encoded := buf.Bytes()
+
+	app1 := []byte{0xFF, jpegAPP1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(2+len(exifHeader)+len(tiff)))
+	app1 = append(app1, exifHeader...)
+	app1 = append(app1, tiff...)
+
+	photo := append([]byte{}, encoded[:2]...)
+	photo = append(photo, app1...)
+	return append(photo, encoded[2:]...)
+}
+
+func strip(t *testing.T, s service.ExifService, data []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	require.NoError(t, s.StripLocation(&out, bytes.NewReader(data)))
+	return out.Bytes()
+}
+
+func TestExifService_Extract(t *testing.T) {
+	s := NewExifService(logger.NewZapLogger("development"))
+
+	m, err := s.Extract(bytes.NewReader(testPhoto(t)))
+	require.NoError(t, err)
+	require.NotNil(t, m)
+
+	assert.Equal(t, "Canon", m.CameraMake)
+	assert.Equal(t, "EOS R6", m.CameraModel)
+	assert.Equal(t, "1/250", m.ExposureTime)
+	assert.InDelta(t, 2.8, m.FNumber, 0.001)
+	assert.Equal(t, 200, m.ISO)
+	require.NotNil(t, m.TakenAt)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC), m.TakenAt.UTC())
+	require.True(t, m.HasLocation())
+	assert.InDelta(t, 10.7667, *m.Latitude, 0.001)
+	assert.InDelta(t, 106.6667, *m.Longitude, 0.001)
+}
+
+func TestExifService_Extract_NoExif(t *testing.T) {
+	s := NewExifService(logger.NewZapLogger("development"))
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4)), nil))
+
+	m, err := s.Extract(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	m, err = s.Extract(bytes.NewReader([]byte("not an image")))
+	require.NoError(t, err)
+	assert.Nil(t, m)
+}
+
+func TestExifService_StripLocation(t *testing.T) {
+	s := NewExifService(logger.NewZapLogger("development"))
+	photo := testPhoto(t)
+
+	stripped := strip(t, s, photo)
+	require.Len(t, stripped, len(photo), "GPS data is cleared in place")
+
+	_, err := jpeg.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err, "image data is untouched")
+
+	m, err := s.Extract(bytes.NewReader(stripped))
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.False(t, m.HasLocation())
+	assert.Equal(t, "Canon", m.CameraMake, "other fields are kept")
+	assert.NotNil(t, m.TakenAt)
+
+	assert.Equal(t, []byte("not a jpeg"), strip(t, s, []byte("not a jpeg")))
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// testPNG is a PNG carrying the test EXIF in an eXIf chunk and an XMP packet.
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))))
+	encoded := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+
+	photo := append([]byte{}, encoded[:ihdrEnd]...)
+	photo = append(photo, pngChunk("eXIf", testTIFF())...)
+	photo = append(photo, pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta>GPS</x:xmpmeta>"))...)
+	return append(photo, encoded[ihdrEnd:]...)
+}
+
+// testWebP is an extended WebP with EXIF and XMP chunks after the image data,
+// where encoders put them.
+func testWebP() []byte {
+	chunk := func(fourcc string, data []byte) []byte {
+		c := append([]byte(fourcc), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{0x0C, 0, 0, 0, 3, 0, 0, 3, 0, 0})...)
+	body = append(body, chunk("VP8L", []byte("\x2f\x03\xc0\x00\x00\x00\x00"))...)
+	body = append(body, chunk("EXIF", append(append([]byte{}, exifHeader...), testTIFF()...))...)
+	body = append(body, chunk("XMP ", bytes.Repeat([]byte("<gps/>"), 30))...)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func TestExifService_StripLocation_PNGAndWebP(t *testing.T) {
+	s := NewExifService(logger.NewZapLogger("development"))
+
+	for name, photo := range map[string][]byte{"png": testPNG(t), "webp": testWebP()} {
+		t.Run(name, func(t *testing.T) {
+			m, err := s.Extract(bytes.NewReader(photo))
+			require.NoError(t, err)
+			require.NotNil(t, m)
+			require.True(t, m.HasLocation())
+			assert.Equal(t, "Canon", m.CameraMake)
+
+			stripped := strip(t, s, photo)
+			m, err = s.Extract(bytes.NewReader(stripped))
+			require.NoError(t, err)
+			require.NotNil(t, m)
+			assert.False(t, m.HasLocation())
+			assert.Equal(t, "Canon", m.CameraMake, "other fields are kept")
+			assert.NotContains(t, string(stripped), "gps")
+			assert.NotContains(t, string(stripped), "GPS")
+		})
+	}
+
+	stripped := strip(t, s, testPNG(t))
+	_, err := png.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err, "chunk CRCs are valid")
+
+	webp := testWebP()
+	assert.Len(t, strip(t, s, webp), len(webp), "the RIFF layout is kept")
+}
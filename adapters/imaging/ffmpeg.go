@@ -0,0 +1,171 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// posterOffset is how far into a video the poster frame is taken, skipping
+// the black frames many videos open with. Shorter videos use their midpoint.
+const posterOffset = time.Second
+
+// ffmpegProber runs the ffprobe and ffmpeg binaries. Either may be missing,
+// in which case Probe returns service.ErrProberUnavailable.
+type ffmpegProber struct {
+	ffprobe string
+	ffmpeg  string
+	logger  logger.Logger
+}
+
+type FFmpegSettings struct {
+	// FFprobePath and FFmpegPath default to the binaries found on PATH.
+	FFprobePath string
+	FFmpegPath  string
+}
+
+func NewFFmpegProber(s FFmpegSettings, log logger.Logger) service.AVProber {
+	p := &ffmpegProber{logger: log}
+	p.ffprobe = lookPath(s.FFprobePath, "ffprobe")
+	p.ffmpeg = lookPath(s.FFmpegPath, "ffmpeg")
+	if p.ffprobe == "" {
+		log.Warn("ffprobe not found, video and audio will be served without metadata or posters")
+	} else if p.ffmpeg == "" {
+		log.Warn("ffmpeg not found, videos will be served without posters")
+	}
+	return p
+}
+
+func lookPath(configured, name string) string {
+	if configured != "" {
+		name = configured
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+func (p *ffmpegProber) Probe(ctx context.Context, r io.Reader) (*service.AVInfo, error) {
+	if p.ffprobe == "" {
+		return nil, service.ErrProberUnavailable
+	}
+
+	// Both tools need to seek, e.g. to an MP4 index stored at the end, so
+	// the file is spooled to disk rather than piped.
+	f, err := os.CreateTemp("", "probe-*")
+	if err != nil {
+		return nil, apperror.NewInternal("failed to create temporary file", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, apperror.NewInternal("failed to spool media for probing", err)
+	}
+
+	out, err := exec.CommandContext(ctx, p.ffprobe, "-v", "error", "-print_format", "json", "-show_format", "-show_streams", f.Name()).Output()
+	if err != nil {
+		return nil, apperror.NewInvalidInput("media could not be read by ffprobe", commandError(err))
+	}
+	info, err := parseProbeOutput(out)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to parse ffprobe output", err)
+	}
+
+	if info.HasVideo() && p.ffmpeg != "" {
+		at := posterOffset
+		if info.Duration > 0 && info.Duration < 2*posterOffset {
+			at = info.Duration / 2
+		}
+		poster, err := p.posterFrame(ctx, f.Name(), at)
+		if err != nil {
+			// The metadata is still worth keeping without a poster.
+			p.logger.Warn("Failed to extract poster frame", zap.Error(err))
+		}
+		info.Poster = poster
+	}
+	return info, nil
+}
+
+func (p *ffmpegProber) posterFrame(ctx context.Context, path string, at time.Duration) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, p.ffmpeg,
+		"-v", "error",
+		"-ss", strconv.FormatFloat(at.Seconds(), 'f', 3, 64),
+		"-i", path,
+		"-frames:v", "1",
+		"-f", "image2", "-c:v", "png",
+		"pipe:1",
+	).Output()
+	if err != nil {
+		return nil, commandError(err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("ffmpeg returned no frame at %s", at)
+	}
+	return out, nil
+}
+
+// commandError includes the tool's stderr, which says why it failed.
+func commandError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	return err
+}
+
+type probeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// parseProbeOutput reads the first video and audio streams. Cover art
+// embedded in audio files is reported as a video stream by ffprobe and is
+// skipped.
+func parseProbeOutput(out []byte) (*service.AVInfo, error) {
+	var probe probeOutput
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, err
+	}
+
+	info := &service.AVInfo{}
+	if seconds, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+	}
+	for _, s := range probe.Streams {
+		switch {
+		case s.CodecType == "video" && s.Disposition.AttachedPic == 0 && info.VideoCodec == "":
+			info.VideoCodec = s.CodecName
+			info.Width = s.Width
+			info.Height = s.Height
+		case s.CodecType == "audio" && info.AudioCodec == "":
+			info.AudioCodec = s.CodecName
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,56 @@
+package imaging
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+func TestParseProbeOutput(t *testing.T) {
+	out := []byte(`{
+		"streams": [
+			{"codec_type": "audio", "codec_name": "aac"},
+			{"codec_type": "video", "codec_name": "mjpeg", "width": 300, "height": 300, "disposition": {"attached_pic": 1}},
+			{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080, "disposition": {"attached_pic": 0}}
+		],
+		"format": {"duration": "12.345678"}
+	}`)
+
+	info, err := parseProbeOutput(out)
+	require.NoError(t, err)
+	assert.Equal(t, 12346*time.Millisecond, info.Duration)
+	assert.Equal(t, "h264", info.VideoCodec, "cover art is not the video stream")
+	assert.Equal(t, "aac", info.AudioCodec)
+	assert.Equal(t, 1920, info.Width)
+	assert.Equal(t, 1080, info.Height)
+	assert.True(t, info.HasVideo())
+}
+
+func TestParseProbeOutput_AudioWithCoverArt(t *testing.T) {
+	out := []byte(`{
+		"streams": [
+			{"codec_type": "audio", "codec_name": "mp3"},
+			{"codec_type": "video", "codec_name": "png", "width": 500, "height": 500, "disposition": {"attached_pic": 1}}
+		],
+		"format": {"duration": "180.0"}
+	}`)
+
+	info, err := parseProbeOutput(out)
+	require.NoError(t, err)
+	assert.False(t, info.HasVideo())
+	assert.Equal(t, "mp3", info.AudioCodec)
+	assert.Zero(t, info.Width)
+}
+
+func TestFFmpegProber_Unavailable(t *testing.T) {
+	p := NewFFmpegProber(FFmpegSettings{FFprobePath: "/nonexistent/ffprobe", FFmpegPath: "/nonexistent/ffmpeg"}, logger.NewZapLogger("development"))
+	_, err := p.Probe(context.Background(), strings.NewReader("not a video"))
+	assert.ErrorIs(t, err, service.ErrProberUnavailable)
+}
@@ -0,0 +1,222 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/buckket/go-blurhash"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+const (
+	jpegQuality = 85
+	// maxPixels guards against decompression bombs; a 60 MP image already
+	// needs about 240 MB once decoded.
+	maxPixels = 60_000_000
+	// sampleSize is the edge of the small copy used for colour analysis.
+	sampleSize = 32
+	blurHashX  = 4
+	blurHashY  = 3
+)
+
+// processor renders variants with the standard library and pure-Go codecs,
+// so it needs neither cgo nor an external service. Opaque images are encoded
+// as JPEG; images with transparency as lossless WebP to keep the alpha.
+type processor struct {
+	logger logger.Logger
+}
+
+func NewProcessor(log logger.Logger) service.ImageProcessor {
+	return &processor{logger: log}
+}
+
+func (p *processor) Process(ctx context.Context, r io.Reader, variants []service.ImageVariant) (*service.ProcessedImage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to read image", err)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, service.ErrUnsupportedImage
+		}
+		return nil, apperror.NewInvalidInput("failed to read image header", err)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("image is too large (%dx%d)", cfg.Width, cfg.Height), nil)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperror.NewInvalidInput("failed to decode image", err)
+	}
+
+	sample := resize(src, sampleSize, sampleSize)
+	hash, err := blurhash.Encode(blurHashX, blurHashY, sample)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to compute blurhash", err)
+	}
+
+	bounds := src.Bounds()
+	out := &service.ProcessedImage{
+		Width:         bounds.Dx(),
+		Height:        bounds.Dy(),
+		DominantColor: dominantColor(sample),
+		BlurHash:      hash,
+		Variants:      make([]service.EncodedImage, 0, len(variants)),
+	}
+
+	opaque := isOpaque(src)
+	for _, v := range variants {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		encoded, err := render(src, v, opaque)
+		if err != nil {
+			return nil, apperror.NewInternal("failed to render "+v.Name+" variant", err)
+		}
+		out.Variants = append(out.Variants, *encoded)
+	}
+	return out, nil
+}
+
+func render(src image.Image, v service.ImageVariant, opaque bool) (*service.EncodedImage, error) {
+	var img image.Image
+	if v.Fit == service.FitFill {
+		img = fill(src, v.Width, v.Height)
+	} else {
+		img = limit(src, v.Width, v.Height)
+	}
+
+	var buf bytes.Buffer
+	encoded := &service.EncodedImage{
+		Name:   v.Name,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+	if opaque {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		encoded.ContentType, encoded.Extension = "image/jpeg", "jpg"
+	} else {
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, err
+		}
+		encoded.ContentType, encoded.Extension = "image/webp", "webp"
+	}
+	encoded.Data = buf.Bytes()
+	return encoded, nil
+}
+
+// limit scales src down to fit within width x height. It never upscales.
+func limit(src image.Image, width, height int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := 1.0
+	if width > 0 && w > width {
+		scale = float64(width) / float64(w)
+	}
+	if height > 0 && float64(h)*scale > float64(height) {
+		scale = float64(height) / float64(h)
+	}
+	if scale == 1.0 {
+		return src
+	}
+	return resize(src, max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5)))
+}
+
+// fill crops the centre of src to the target aspect ratio and scales it to
+// exactly width x height.
+func fill(src image.Image, width, height int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	crop := b
+	if w*height > h*width {
+		cw := h * width / height
+		crop.Min.X += (w - cw) / 2
+		crop.Max.X = crop.Min.X + cw
+	} else {
+		ch := w * height / width
+		crop.Min.Y += (h - ch) / 2
+		crop.Max.Y = crop.Min.Y + ch
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
+
+func resize(src image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dominantColor buckets pixels into a 4-bit-per-channel palette and returns
+// the average colour of the most common bucket. Mostly transparent pixels are
+// ignored.
+func dominantColor(img image.Image) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[int]*bucket)
+	var best *bucket
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += int(c.R)
+			bk.g += int(c.G)
+			bk.b += int(c.B)
+			if best == nil || bk.count > best.count {
+				best = bk
+			}
+		}
+	}
+	if best == nil {
+		return "#000000"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.count, best.g/best.count, best.b/best.count)
+}
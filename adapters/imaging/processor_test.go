@@ -0,0 +1,106 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+var testVariants = []service.ImageVariant{
+	{Name: "thumbnail", Width: 400, Height: 400, Fit: service.FitFill},
+	{Name: "medium", Width: 1200, Fit: service.FitLimit},
+	{Name: "small", Width: 4000, Fit: service.FitLimit},
+}
+
+// encodePNG draws a w x h image whose left three quarters are fg and the rest bg.
+func encodePNG(t *testing.T, w, h int, fg, bg color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w*3/4 {
+				img.Set(x, y, fg)
+			} else {
+				img.Set(x, y, bg)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// assertColorNear allows for pixels blended at edges while downsampling.
+func assertColorNear(t *testing.T, want, got string, msgAndArgs ...any) {
+	t.Helper()
+	var wr, wg, wb, gr, gg, gb int
+	_, err := fmt.Sscanf(want, "#%02x%02x%02x", &wr, &wg, &wb)
+	require.NoError(t, err)
+	_, err = fmt.Sscanf(got, "#%02x%02x%02x", &gr, &gg, &gb)
+	require.NoError(t, err, "colour %q", got)
+	assert.InDelta(t, wr, gr, 4, msgAndArgs...)
+	assert.InDelta(t, wg, gg, 4, msgAndArgs...)
+	assert.InDelta(t, wb, gb, 4, msgAndArgs...)
+}
+
+func TestProcessor_Process(t *testing.T) {
+	p := NewProcessor(logger.NewZapLogger("development"))
+	data := encodePNG(t, 1600, 900, color.NRGBA{R: 200, G: 30, B: 30, A: 255}, color.NRGBA{B: 255, A: 255})
+
+	out, err := p.Process(context.Background(), bytes.NewReader(data), testVariants)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1600, out.Width)
+	assert.Equal(t, 900, out.Height)
+	assertColorNear(t, "#c81e1e", out.DominantColor)
+	assert.NotEmpty(t, out.BlurHash)
+
+	require.Len(t, out.Variants, 3)
+	thumb, medium, small := out.Variants[0], out.Variants[1], out.Variants[2]
+	assert.Equal(t, [2]int{400, 400}, [2]int{thumb.Width, thumb.Height})
+	assert.Equal(t, [2]int{1200, 675}, [2]int{medium.Width, medium.Height})
+	assert.Equal(t, [2]int{1600, 900}, [2]int{small.Width, small.Height}, "limit never upscales")
+
+	for _, v := range out.Variants {
+		assert.Equal(t, "image/jpeg", v.ContentType)
+		assert.Equal(t, "jpg", v.Extension)
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(v.Data))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, v.Width, cfg.Width)
+		assert.Equal(t, v.Height, cfg.Height)
+	}
+}
+
+func TestProcessor_Process_KeepsTransparencyAsWebP(t *testing.T) {
+	p := NewProcessor(logger.NewZapLogger("development"))
+	data := encodePNG(t, 200, 100, color.NRGBA{G: 255, A: 255}, color.NRGBA{})
+
+	out, err := p.Process(context.Background(), bytes.NewReader(data), testVariants[:1])
+	require.NoError(t, err)
+
+	require.Len(t, out.Variants, 1)
+	assert.Equal(t, "image/webp", out.Variants[0].ContentType)
+	_, format, err := image.DecodeConfig(bytes.NewReader(out.Variants[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, "webp", format)
+	assertColorNear(t, "#00ff00", out.DominantColor, "transparent pixels are ignored")
+}
+
+func TestProcessor_Process_RejectsNonImages(t *testing.T) {
+	p := NewProcessor(logger.NewZapLogger("development"))
+	_, err := p.Process(context.Background(), strings.NewReader("%PDF-1.7"), testVariants)
+	assert.True(t, errors.Is(err, service.ErrUnsupportedImage))
+}
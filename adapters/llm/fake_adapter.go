@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+)
+
+// FakeLLM is a deterministic, offline LLMService for tests and local
+// evaluation. By default it answers with a digest of the prompt; queued
+// responses set with Respond are returned first, in order.
+type FakeLLM struct {
+	mu        sync.Mutex
+	responses []string
+	prompts   []service.ChatPrompt
+	err       error
+}
+
+func NewFakeLLM() *FakeLLM {
+	return &FakeLLM{}
+}
+
+var _ service.LLMService = (*FakeLLM)(nil)
+
+// Respond queues responses for the next calls.
+func (f *FakeLLM) Respond(responses ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, responses...)
+}
+
+// FailWith makes every following call return err. Pass nil to reset.
+func (f *FakeLLM) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Prompts returns every prompt received so far.
+func (f *FakeLLM) Prompts() []service.ChatPrompt {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]service.ChatPrompt(nil), f.prompts...)
+}
+
+func (f *FakeLLM) next(prompt service.ChatPrompt) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prompts = append(f.prompts, prompt)
+	if f.err != nil {
+		return "", f.err
+	}
+	if len(f.responses) > 0 {
+		r := f.responses[0]
+		f.responses = f.responses[1:]
+		return r, nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(prompt.System))
+	h.Write([]byte(prompt.User))
+	return fmt.Sprintf("fake response %08x", h.Sum32()), nil
+}
+
+func (f *FakeLLM) GenerateChatResponse(ctx context.Context, prompt service.ChatPrompt) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return f.next(prompt)
+}
+
+func (f *FakeLLM) StreamChatResponse(ctx context.Context, prompt service.ChatPrompt, onToken service.TokenHandler) (string, error) {
+	response, err := f.GenerateChatResponse(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	var full strings.Builder
+	for i, word := range strings.Split(response, " ") {
+		token := word
+		if i > 0 {
+			token = " " + word
+		}
+		full.WriteString(token)
+		if err := onToken(token); err != nil {
+			return full.String(), err
+		}
+	}
+	return full.String(), nil
+}
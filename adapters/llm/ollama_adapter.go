@@ -1,55 +1,173 @@
 package llm
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 
 	"github.com/khoahotran/personal-os/internal/application/service"
-	"github.com/khoahotran/personal-os/internal/config"
 	"github.com/khoahotran/personal-os/pkg/logger"
-	"github.com/sashabaranov/go-openai"
+	"github.com/khoahotran/personal-os/pkg/retry"
+	"go.uber.org/zap"
 )
 
-type ollamaLLMAdapter struct {
-	client *openai.Client
-	log    logger.Logger
+type ollamaNativeAdapter struct {
+	httpClient *http.Client
+	baseURL    string
+	settings   Settings
+	log        logger.Logger
 }
 
-// NewOllamaLLMAdapter là constructor
-func NewOllamaLLMAdapter(cfg config.Config, log logger.Logger) (service.LLMService, error) {
-	if cfg.Ollama.Host == "" {
+// NewOllamaNativeAdapter uses Ollama's own /api/chat endpoint, which exposes
+// options (such as num_predict) that the OpenAI-compatible layer does not.
+func NewOllamaNativeAdapter(s Settings, log logger.Logger) (service.LLMService, error) {
+	if s.BaseURL == "" {
 		return nil, fmt.Errorf("ollama Host is not configured")
 	}
 
-	config := openai.DefaultConfig("dummy-key")
-	config.BaseURL = cfg.Ollama.Host
+	baseURL := ollamaBaseURL(s.BaseURL)
+	log.Info("Ollama native LLM Adapter initialized", zap.String("base_url", baseURL), zap.String("model", s.Model))
+	return &ollamaNativeAdapter{httpClient: &http.Client{}, baseURL: baseURL, settings: s, log: log}, nil
+}
+
+// ollamaBaseURL strips the OpenAI-compatible "/v1" suffix so the same host
+// setting works for both the native and the compatible adapters.
+func ollamaBaseURL(host string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(strings.TrimRight(host, "/"), "/v1"), "/")
+}
 
-	client := openai.NewClientWithConfig(config)
+type ollamaChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []ollamaChatMessage `json:"messages"`
+	Stream   bool                `json:"stream"`
+	Options  map[string]any      `json:"options,omitempty"`
+}
 
-	log.Info("Ollama Chat (LLM) Adapter initialized")
-	return &ollamaLLMAdapter{client: client, log: log}, nil
+type ollamaChatResponse struct {
+	Message ollamaChatMessage `json:"message"`
+	Done    bool              `json:"done"`
+	Error   string            `json:"error"`
 }
 
-func (a *ollamaLLMAdapter) GenerateChatResponse(ctx context.Context, prompt string) (string, error) {
-	req := openai.ChatCompletionRequest{
-		Model: "phi3:mini",
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
-		Stream: false,
+func (a *ollamaNativeAdapter) post(ctx context.Context, prompt service.ChatPrompt, stream bool) (*http.Response, error) {
+	options := map[string]any{"temperature": a.settings.Temperature}
+	if a.settings.MaxTokens > 0 {
+		options["num_predict"] = a.settings.MaxTokens
+	}
+	messages := make([]ollamaChatMessage, 0, 2)
+	if prompt.System != "" {
+		messages = append(messages, ollamaChatMessage{Role: "system", Content: prompt.System})
+	}
+	messages = append(messages, ollamaChatMessage{Role: "user", Content: prompt.User})
+
+	body, err := json.Marshal(ollamaChatRequest{
+		Model:    a.settings.Model,
+		Messages: messages,
+		Stream:   stream,
+		Options:  options,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ollama chat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ollama chat request: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := a.client.CreateChatCompletion(ctx, req)
+	resp, err := a.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("ollama chat completion request failed: %w", err)
+		return nil, fmt.Errorf("ollama chat request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("ollama chat returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
 	}
+	return resp, nil
+}
+
+func (a *ollamaNativeAdapter) GenerateChatResponse(ctx context.Context, prompt service.ChatPrompt) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.settings.Timeout)
+	defer cancel()
+
+	var content string
+	err := retry.Do(ctx, a.settings.MaxRetries, func(ctx context.Context) error {
+		resp, err := a.post(ctx, prompt, false)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		var out ollamaChatResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return fmt.Errorf("failed to decode ollama chat response: %w", err)
+		}
+		if out.Error != "" {
+			return fmt.Errorf("ollama chat failed: %s", out.Error)
+		}
+		content = out.Message.Content
+		return nil
+	})
+	return content, err
+}
+
+func (a *ollamaNativeAdapter) StreamChatResponse(ctx context.Context, prompt service.ChatPrompt, onToken service.TokenHandler) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.settings.Timeout)
+	defer cancel()
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("ollama returned no chat choices")
+	// Only opening the stream is retried; once tokens have been handed to the
+	// caller a retry would duplicate them.
+	var resp *http.Response
+	err := retry.Do(ctx, a.settings.MaxRetries, func(ctx context.Context) error {
+		var err error
+		resp, err = a.post(ctx, prompt, true)
+		return err
+	})
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
-	return resp.Choices[0].Message.Content, nil
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return full.String(), fmt.Errorf("failed to decode ollama stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return full.String(), fmt.Errorf("ollama chat stream failed: %s", chunk.Error)
+		}
+		if token := chunk.Message.Content; token != "" {
+			full.WriteString(token)
+			if err := onToken(token); err != nil {
+				return full.String(), err
+			}
+		}
+		if chunk.Done {
+			return full.String(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return full.String(), fmt.Errorf("ollama chat stream receive failed: %w", err)
+	}
+	return full.String(), nil
 }
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/retry"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+type openAICompatibleAdapter struct {
+	client   *openai.Client
+	settings Settings
+	log      logger.Logger
+}
+
+// NewOpenAICompatibleAdapter talks to any server implementing the OpenAI chat
+// completions API, including Ollama's /v1 endpoint.
+func NewOpenAICompatibleAdapter(s Settings, log logger.Logger) (service.LLMService, error) {
+	if s.BaseURL == "" {
+		return nil, fmt.Errorf("llm base URL is not configured")
+	}
+
+	apiKey := s.APIKey
+	if apiKey == "" {
+		apiKey = "dummy-key"
+	}
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = s.BaseURL
+
+	client := openai.NewClientWithConfig(config)
+
+	log.Info("OpenAI-compatible LLM Adapter initialized", zap.String("base_url", s.BaseURL), zap.String("model", s.Model))
+	return &openAICompatibleAdapter{client: client, settings: s, log: log}, nil
+}
+
+func (a *openAICompatibleAdapter) request(prompt service.ChatPrompt, stream bool) openai.ChatCompletionRequest {
+	messages := make([]openai.ChatCompletionMessage, 0, 2)
+	if prompt.System != "" {
+		messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: prompt.System})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: prompt.User})
+
+	return openai.ChatCompletionRequest{
+		Model:       a.settings.Model,
+		Messages:    messages,
+		Temperature: a.settings.Temperature,
+		MaxTokens:   a.settings.MaxTokens,
+		Stream:      stream,
+	}
+}
+
+func (a *openAICompatibleAdapter) GenerateChatResponse(ctx context.Context, prompt service.ChatPrompt) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.settings.Timeout)
+	defer cancel()
+
+	var content string
+	err := retry.Do(ctx, a.settings.MaxRetries, func(ctx context.Context) error {
+		resp, err := a.client.CreateChatCompletion(ctx, a.request(prompt, false))
+		if err != nil {
+			return fmt.Errorf("chat completion request failed: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return fmt.Errorf("llm returned no chat choices")
+		}
+		content = resp.Choices[0].Message.Content
+		return nil
+	})
+	return content, err
+}
+
+func (a *openAICompatibleAdapter) StreamChatResponse(ctx context.Context, prompt service.ChatPrompt, onToken service.TokenHandler) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.settings.Timeout)
+	defer cancel()
+
+	// Only opening the stream is retried; once tokens have been handed to the
+	// caller a retry would duplicate them.
+	var stream *openai.ChatCompletionStream
+	err := retry.Do(ctx, a.settings.MaxRetries, func(ctx context.Context) error {
+		var err error
+		stream, err = a.client.CreateChatCompletionStream(ctx, a.request(prompt, true))
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("chat stream request failed: %w", err)
+	}
+	defer stream.Close()
+
+	var full strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return full.String(), nil
+		}
+		if err != nil {
+			return full.String(), fmt.Errorf("chat stream receive failed: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+
+		token := resp.Choices[0].Delta.Content
+		if token == "" {
+			continue
+		}
+		full.WriteString(token)
+		if err := onToken(token); err != nil {
+			return full.String(), err
+		}
+	}
+}
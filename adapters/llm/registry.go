@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/config"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+const (
+	defaultModel   = "phi3:mini"
+	defaultTimeout = 120 * time.Second
+)
+
+// Settings are the provider-independent options taken from the `llm` config section.
+type Settings struct {
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature float32
+	MaxTokens   int
+	Timeout     time.Duration
+	MaxRetries  int
+}
+
+type Factory func(s Settings, log logger.Logger) (service.LLMService, error)
+
+var providers = map[string]Factory{
+	ProviderOllama: NewOllamaNativeAdapter,
+	ProviderOpenAI: NewOpenAICompatibleAdapter,
+	ProviderFake: func(s Settings, log logger.Logger) (service.LLMService, error) {
+		return NewFakeLLM(), nil
+	},
+}
+
+// Register adds or replaces a provider. It is not safe for concurrent use and
+// should only be called during start-up.
+func Register(name string, f Factory) {
+	providers[name] = f
+}
+
+// NewLLMService builds the provider selected by cfg.LLM.Provider, defaulting to
+// the OpenAI-compatible API served by Ollama.
+func NewLLMService(cfg config.Config, log logger.Logger) (service.LLMService, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.LLM.Provider))
+	if name == "" {
+		name = ProviderOpenAI
+	}
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown llm provider %q (available: %s)", name, strings.Join(providerNames(), ", "))
+	}
+	return factory(settingsFromConfig(cfg), log)
+}
+
+func settingsFromConfig(cfg config.Config) Settings {
+	s := Settings{
+		BaseURL:     cfg.LLM.BaseURL,
+		APIKey:      cfg.LLM.APIKey,
+		Model:       cfg.LLM.Model,
+		Temperature: cfg.LLM.Temperature,
+		MaxTokens:   cfg.LLM.MaxTokens,
+		Timeout:     cfg.LLM.Timeout,
+		MaxRetries:  cfg.LLM.MaxRetries,
+	}
+	if s.BaseURL == "" {
+		s.BaseURL = cfg.Ollama.Host
+	}
+	if s.Model == "" {
+		s.Model = defaultModel
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = defaultTimeout
+	}
+	return s
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -4,63 +4,235 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/cloudinary/cloudinary-go/v2/asset"
 	"go.uber.org/zap"
 
 	"github.com/khoahotran/personal-os/internal/application/service"
-	"github.com/khoahotran/personal-os/internal/config"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
 )
 
+// cloudinaryAdapter stores objects in Cloudinary.
 type cloudinaryAdapter struct {
-	cld    *cloudinary.Cloudinary
-	logger logger.Logger
+	cld          *cloudinary.Cloudinary
+	signedURLTTL time.Duration
+	logger       logger.Logger
 }
 
-func NewCloudinaryAdapter(cfg config.Config, log logger.Logger) (service.Uploader, error) {
+type CloudinarySettings struct {
+	CloudName string
+	APIKey    string
+	APISecret string
+	// SignedURLTTL is how long private download URLs stay valid.
+	SignedURLTTL time.Duration
+}
+
+func NewCloudinaryAdapter(s CloudinarySettings, log logger.Logger) (service.Storage, error) {
 
-	if cfg.Cloudinary.CloudName == "" {
+	if s.CloudName == "" {
 		return nil, fmt.Errorf("cloudinary cloud_name has not config")
 	}
 
-	cld, err := cloudinary.NewFromParams(
-		cfg.Cloudinary.CloudName,
-		cfg.Cloudinary.ApiKey,
-		cfg.Cloudinary.ApiSecret,
-	)
+	cld, err := cloudinary.NewFromParams(s.CloudName, s.APIKey, s.APISecret)
 	if err != nil {
 		return nil, fmt.Errorf("cannot init cloudinary: %w", err)
 	}
 
 	log.Info("Connect Cloudinary successfully.")
-	return &cloudinaryAdapter{cld: cld, logger: log}, nil
+	return &cloudinaryAdapter{cld: cld, signedURLTTL: s.SignedURLTTL, logger: log}, nil
+}
+
+// cloudinaryResourceType maps a MIME type to the Cloudinary resource type,
+// which Cloudinary needs again to address the asset later.
+func cloudinaryResourceType(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return "image"
+	case strings.HasPrefix(contentType, "video/"), strings.HasPrefix(contentType, "audio/"), contentType == "application/ogg":
+		return "video"
+	case contentType == "":
+		return "auto"
+	}
+	return "raw"
 }
 
-func (a *cloudinaryAdapter) Upload(ctx context.Context, file io.Reader, folder string, publicID string) (string, error) {
+// cloudinaryDeliveryType stores private keys as authenticated assets, which
+// Cloudinary delivers only at signed URLs.
+func cloudinaryDeliveryType(key string) api.DeliveryType {
+	if service.IsPrivateKey(key) {
+		return api.Authenticated
+	}
+	return api.Upload
+}
+
+func (a *cloudinaryAdapter) Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	overwrite := true
 	uploadParams := uploader.UploadParams{
-		PublicID: publicID,
-		Folder:   folder,
+		PublicID:     key,
+		ResourceType: cloudinaryResourceType(contentType),
+		Type:         cloudinaryDeliveryType(key),
+		Overwrite:    &overwrite,
 	}
-	result, err := a.cld.Upload.Upload(ctx, file, uploadParams)
+	result, err := a.cld.Upload.Upload(ctx, body, uploadParams)
 	if err != nil {
 		return "", apperror.NewInternal("failed to upload to cloudinary", err)
 	}
+	if result.Error.Message != "" {
+		return "", apperror.NewInternal("failed to upload to cloudinary", fmt.Errorf("%s", result.Error.Message))
+	}
+	if service.IsPrivateKey(key) {
+		// The URL returned for an authenticated asset is signed for good, so
+		// the unsigned one is returned instead.
+		u, err := assetURL(a.assetOf(result.ResourceType), key, false)
+		if err != nil {
+			return "", apperror.NewInternal("failed to build cloudinary url", err)
+		}
+		return u, nil
+	}
 	return result.SecureURL, nil
 }
 
-func (a *cloudinaryAdapter) Delete(ctx context.Context, publicID string) error {
-	_, err := a.cld.Upload.Destroy(ctx, uploader.DestroyParams{
-		PublicID: publicID,
-	})
+// assetOf returns the constructor of assets of a Cloudinary resource type.
+func (a *cloudinaryAdapter) assetOf(resourceType string) func(string) (*asset.Asset, error) {
+	switch resourceType {
+	case "video":
+		return a.cld.Video
+	case "raw":
+		return a.cld.File
+	}
+	return a.cld.Image
+}
+
+// Open tries each resource type in turn, like Delete, because the key alone
+// does not say which one the asset was uploaded as. Private assets are
+// fetched at signed URLs.
+func (a *cloudinaryAdapter) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	for _, newAsset := range []func(string) (*asset.Asset, error){a.cld.Image, a.cld.Video, a.cld.File} {
+		u, err := assetURL(newAsset, key, service.IsPrivateKey(key))
+		if err != nil {
+			return nil, apperror.NewInternal("failed to build cloudinary url", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, apperror.NewInternal("failed to build cloudinary download request", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, apperror.NewInternal("failed to download from cloudinary", err)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, apperror.NewInternal("failed to download from cloudinary", fmt.Errorf("unexpected status %s", resp.Status))
+		}
+		return resp.Body, nil
+	}
+	return nil, apperror.NewNotFound("object", key)
+}
+
+func assetURL(newAsset func(string) (*asset.Asset, error), key string, signed bool) (string, error) {
+	a, err := newAsset(key)
 	if err != nil {
-		a.logger.Warn("Failed to delete asset from cloudinary", zap.String("public_id", publicID), zap.Error(err))
+		return "", err
+	}
+	a.DeliveryType = cloudinaryDeliveryType(key)
+	a.Config.URL.SignURL = signed
+	return a.String()
+}
+
+// Delete tries each resource type in turn because the key alone does not say
+// which one the asset was uploaded as.
+func (a *cloudinaryAdapter) Delete(ctx context.Context, key string) error {
+	for _, resourceType := range []string{"image", "video", "raw"} {
+		result, err := a.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+			PublicID:     key,
+			Type:         string(cloudinaryDeliveryType(key)),
+			ResourceType: resourceType,
+		})
+		if err != nil {
+			a.logger.Warn("Failed to delete asset from cloudinary", zap.String("public_id", key), zap.Error(err))
+			return nil
+		}
+		if result.Result != "not found" {
+			return nil
+		}
 	}
 	return nil
 }
 
-func (a *cloudinaryAdapter) GetClient() *cloudinary.Cloudinary {
-	return a.cld
+// List pages through the uploaded assets of every resource type, since each is
+// listed separately.
+func (a *cloudinaryAdapter) List(ctx context.Context, prefix string) ([]service.StoredObject, error) {
+	var objects []service.StoredObject
+	for _, assetType := range []api.AssetType{api.Image, api.Video, api.File} {
+		cursor := ""
+		for {
+			result, err := a.cld.Admin.Assets(ctx, admin.AssetsParams{
+				AssetType:    assetType,
+				DeliveryType: string(cloudinaryDeliveryType(prefix)),
+				Prefix:       prefix,
+				MaxResults:   500,
+				NextCursor:   cursor,
+			})
+			if err != nil {
+				return nil, apperror.NewInternal("failed to list cloudinary assets", err)
+			}
+			if result.Error.Message != "" {
+				return nil, apperror.NewInternal("failed to list cloudinary assets", fmt.Errorf("%s", result.Error.Message))
+			}
+			for _, asset := range result.Assets {
+				objects = append(objects, service.StoredObject{Key: asset.PublicID, Size: int64(asset.Bytes), ModifiedAt: asset.CreatedAt})
+			}
+			if result.NextCursor == "" {
+				break
+			}
+			cursor = result.NextCursor
+		}
+	}
+	return objects, nil
+}
+
+func (a *cloudinaryAdapter) URL(key, contentType string) string {
+	url, err := assetURL(a.assetOf(cloudinaryResourceType(contentType)), key, false)
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// SignedURL returns an expiring private download URL signed with the API
+// secret, for the resource type contentType was uploaded as.
+func (a *cloudinaryAdapter) SignedURL(ctx context.Context, key, contentType string) (string, error) {
+	resourceType := cloudinaryResourceType(contentType)
+	if resourceType == "auto" {
+		resourceType = "image"
+	}
+	expiresAt := time.Now().Add(a.signedURLTTL)
+	u, err := a.cld.Upload.PrivateDownloadURL(uploader.PrivateDownloadURLParams{
+		PublicID:     key,
+		Format:       strings.TrimPrefix(path.Ext(key), "."),
+		DeliveryType: string(cloudinaryDeliveryType(key)),
+		ExpiresAt:    &expiresAt,
+		ResourceType: api.AssetType(resourceType),
+	})
+	if err != nil {
+		return "", apperror.NewInternal("failed to sign cloudinary url", err)
+	}
+	return u, nil
+}
+
+func (a *cloudinaryAdapter) Driver() string {
+	return DriverCloudinary
 }
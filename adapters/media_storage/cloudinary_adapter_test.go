@@ -0,0 +1,26 @@
+package media_storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+func TestCloudinaryAdapter_URLsFollowContentType(t *testing.T) {
+	s, err := NewCloudinaryAdapter(CloudinarySettings{CloudName: "demo", APIKey: "key", APISecret: "secret", SignedURLTTL: time.Minute}, logger.NewZapLogger("development"))
+	require.NoError(t, err)
+
+	// Keys carry no extension, so only the content type tells a video apart.
+	assert.Contains(t, s.URL("users/o/media/originals/clip", "video/mp4"), "/video/upload/")
+	assert.Contains(t, s.URL("users/o/media/originals/song", "audio/mpeg"), "/video/upload/")
+	assert.Contains(t, s.URL("users/o/media/originals/photo", "image/png"), "/image/upload/")
+
+	signed, err := s.SignedURL(context.Background(), "private/users/o/media/originals/clip", "video/mp4")
+	require.NoError(t, err)
+	assert.Contains(t, signed, "/video/download")
+}
@@ -0,0 +1,231 @@
+package media_storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/signedurl"
+)
+
+// LocalStorage keeps objects as files under Root. The API serves Root at
+// BaseURL, so it needs no external service and suits development.
+type LocalStorage struct {
+	root          string
+	baseURL       string
+	signedBaseURL string
+	signer        *signedurl.Signer
+	logger        logger.Logger
+}
+
+type LocalSettings struct {
+	Root string
+	// BaseURL prefixes object URLs. It may be a path such as "/media" when the
+	// API serves the files itself.
+	BaseURL string
+	// SignedBaseURL prefixes signed URLs. The API verifies them there and
+	// streams the object, see SignedURL.
+	SignedBaseURL string
+	Signer        *signedurl.Signer
+}
+
+func NewLocalStorage(s LocalSettings, log logger.Logger) (*LocalStorage, error) {
+	if s.Root == "" {
+		return nil, fmt.Errorf("local storage root is not configured")
+	}
+	root, err := filepath.Abs(s.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local storage root: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create local storage root: %w", err)
+	}
+
+	log.Info("Local media storage initialized", zap.String("root", root), zap.String("base_url", s.BaseURL))
+	return &LocalStorage{
+		root:          root,
+		baseURL:       strings.TrimRight(s.BaseURL, "/"),
+		signedBaseURL: strings.TrimRight(s.SignedBaseURL, "/"),
+		signer:        s.Signer,
+		logger:        log,
+	}, nil
+}
+
+// Root is the directory to serve at ServePath.
+func (s *LocalStorage) Root() string {
+	return s.root
+}
+
+// ServePath is the URL path the API should serve Root at, taken from BaseURL.
+func (s *LocalStorage) ServePath() string {
+	u, err := url.Parse(s.baseURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// validateKey rejects keys that would escape the storage root.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return apperror.NewInvalidInput("invalid storage key", nil)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return apperror.NewInvalidInput("invalid storage key", nil)
+		}
+	}
+	return nil
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	dst := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", apperror.NewInternal("failed to create storage directory", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial object.
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return "", apperror.NewInternal("failed to create temporary file", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", apperror.NewInternal("failed to write object", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", apperror.NewInternal("failed to write object", err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", apperror.NewInternal("failed to store object", err)
+	}
+	return s.URL(key, contentType), nil
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, apperror.NewNotFound("object", key)
+	}
+	if err != nil {
+		return nil, apperror.NewInternal("failed to open object", err)
+	}
+	return f, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return apperror.NewInternal("failed to delete object", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]service.StoredObject, error) {
+	var objects []service.StoredObject
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			// Skip directories that cannot contain a matching key.
+			if key != "." && !strings.HasPrefix(key+"/", prefix) && !strings.HasPrefix(prefix, key+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, service.StoredObject{Key: key, Size: info.Size(), ModifiedAt: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, apperror.NewInternal("failed to list objects", err)
+	}
+	return objects, nil
+}
+
+func (s *LocalStorage) URL(key, contentType string) string {
+	return s.baseURL + "/" + escapeKey(key)
+}
+
+// SignedURL returns a URL under SignedBaseURL carrying an expiring signature
+// from Signer, which the API checks before serving the object.
+func (s *LocalStorage) SignedURL(ctx context.Context, key, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if s.signer == nil {
+		return "", apperror.NewInternal("signed urls are not configured for local storage", nil)
+	}
+	return s.signedBaseURL + "/" + escapeKey(key) + "?" + s.signer.Sign(key, time.Now()).Encode(), nil
+}
+
+// Signer checks the signed URLs this storage issues.
+func (s *LocalStorage) Signer() *signedurl.Signer {
+	return s.signer
+}
+
+// SignedServePath is the URL path the API should serve signed URLs at.
+func (s *LocalStorage) SignedServePath() string {
+	u, err := url.Parse(s.signedBaseURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return path.Join(segments...)
+}
+
+func (s *LocalStorage) Driver() string {
+	return DriverLocal
+}
+
+var _ service.Storage = (*LocalStorage)(nil)
@@ -0,0 +1,54 @@
+package media_storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+	s, err := NewLocalStorage(LocalSettings{Root: t.TempDir(), BaseURL: "/media/"}, logger.NewZapLogger("development"))
+	require.NoError(t, err)
+	return s
+}
+
+func TestLocalStorage_PutOpenDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	key := "users/abc/media/originals/photo 1"
+
+	url, err := s.Put(ctx, key, strings.NewReader("hello"), "text/plain")
+	require.NoError(t, err)
+	assert.Equal(t, "/media/users/abc/media/originals/photo%201", url)
+	assert.Equal(t, "/media", s.ServePath())
+
+	rc, err := s.Open(ctx, key)
+	require.NoError(t, err)
+	body, err := io.ReadAll(rc)
+	require.NoError(t, rc.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	require.NoError(t, s.Delete(ctx, key))
+	require.NoError(t, s.Delete(ctx, key), "deleting a missing object is not an error")
+
+	_, err = s.Open(ctx, key)
+	assert.True(t, errors.Is(err, apperror.ErrNotFound))
+}
+
+func TestLocalStorage_RejectsKeysOutsideRoot(t *testing.T) {
+	s := newTestLocalStorage(t)
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../b", "a//b", `a\b`} {
+		_, err := s.Put(context.Background(), key, strings.NewReader("x"), "")
+		assert.True(t, errors.Is(err, apperror.ErrInvalidInput), "key %q", key)
+	}
+}
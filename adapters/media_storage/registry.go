@@ -0,0 +1,81 @@
+package media_storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/config"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/signedurl"
+)
+
+const (
+	DriverCloudinary = "cloudinary"
+	DriverLocal      = "local"
+	DriverS3         = "s3"
+)
+
+const (
+	defaultLocalRoot          = "./data/media"
+	defaultLocalBaseURL       = "/media"
+	defaultLocalSignedBaseURL = "/api/media/signed"
+	defaultSignedURLTTL       = 15 * time.Minute
+)
+
+// NewStorage builds the driver selected by cfg.Storage.Driver, defaulting to
+// Cloudinary for existing deployments.
+func NewStorage(ctx context.Context, cfg config.Config, log logger.Logger) (service.Storage, error) {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Storage.Driver))
+	if driver == "" {
+		driver = DriverCloudinary
+	}
+
+	signedURLTTL := cfg.Storage.SignedURLs.TTL
+	if signedURLTTL <= 0 {
+		signedURLTTL = defaultSignedURLTTL
+	}
+
+	switch driver {
+	case DriverCloudinary:
+		return NewCloudinaryAdapter(CloudinarySettings{
+			CloudName:    cfg.Cloudinary.CloudName,
+			APIKey:       cfg.Cloudinary.ApiKey,
+			APISecret:    cfg.Cloudinary.ApiSecret,
+			SignedURLTTL: signedURLTTL,
+		}, log)
+	case DriverLocal:
+		// A secret of its own keeps media links from being forged with, or
+		// leaking, the one that signs sessions.
+		if cfg.Storage.SignedURLs.Secret == "" {
+			return nil, fmt.Errorf("storage.signed_urls.secret is required by the %s storage driver", DriverLocal)
+		}
+		return NewLocalStorage(LocalSettings{
+			Root:          valueOr(cfg.Storage.Local.Root, defaultLocalRoot),
+			BaseURL:       valueOr(cfg.Storage.Local.BaseURL, defaultLocalBaseURL),
+			SignedBaseURL: defaultLocalSignedBaseURL,
+			Signer:        signedurl.New(cfg.Storage.SignedURLs.Secret, signedURLTTL),
+		}, log)
+	case DriverS3:
+		return NewS3Storage(ctx, S3Settings{
+			Endpoint:      cfg.Storage.S3.Endpoint,
+			Region:        cfg.Storage.S3.Region,
+			Bucket:        cfg.Storage.S3.Bucket,
+			AccessKey:     cfg.Storage.S3.AccessKey,
+			SecretKey:     cfg.Storage.S3.SecretKey,
+			UseSSL:        cfg.Storage.S3.UseSSL,
+			PublicBaseURL: cfg.Storage.S3.PublicBaseURL,
+			SignedURLTTL:  signedURLTTL,
+		}, log)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q (available: %s, %s, %s)", driver, DriverCloudinary, DriverLocal, DriverS3)
+}
+
+func valueOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
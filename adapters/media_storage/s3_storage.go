@@ -0,0 +1,158 @@
+package media_storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// s3Storage talks to any S3-compatible object store, including AWS S3 and a
+// local MinIO. Object URLs are only served when the bucket, or the CDN in
+// front of it, allows anonymous reads; that must stop short of private keys,
+// as the policy set on buckets this driver creates does.
+type s3Storage struct {
+	client       *minio.Client
+	bucket       string
+	baseURL      string
+	signedURLTTL time.Duration
+	logger       logger.Logger
+}
+
+type S3Settings struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// PublicBaseURL prefixes object URLs, e.g. a CDN in front of the bucket.
+	// It defaults to the path-style bucket URL on Endpoint.
+	PublicBaseURL string
+	// SignedURLTTL is how long presigned URLs stay valid.
+	SignedURLTTL time.Duration
+}
+
+func NewS3Storage(ctx context.Context, s S3Settings, log logger.Logger) (service.Storage, error) {
+	if s.Endpoint == "" || s.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket must be configured")
+	}
+
+	client, err := minio.New(s.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(s.AccessKey, s.SecretKey, ""),
+		Secure: s.UseSSL,
+		Region: s.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot init s3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, s.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach s3 bucket %q: %w", s.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, s.Bucket, minio.MakeBucketOptions{Region: s.Region}); err != nil {
+			return nil, fmt.Errorf("cannot create s3 bucket %q: %w", s.Bucket, err)
+		}
+		if err := client.SetBucketPolicy(ctx, s.Bucket, publicReadPolicy(s.Bucket)); err != nil {
+			return nil, fmt.Errorf("cannot set policy of s3 bucket %q: %w", s.Bucket, err)
+		}
+		log.Info("Created s3 bucket", zap.String("bucket", s.Bucket))
+	}
+
+	baseURL := s.PublicBaseURL
+	if baseURL == "" {
+		scheme := "http"
+		if s.UseSSL {
+			scheme = "https"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, s.Endpoint, s.Bucket)
+	}
+
+	log.Info("S3 media storage initialized", zap.String("endpoint", s.Endpoint), zap.String("bucket", s.Bucket))
+	return &s3Storage{
+		client:       client,
+		bucket:       s.Bucket,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		signedURLTTL: s.SignedURLTTL,
+		logger:       log,
+	}, nil
+}
+
+// publicReadPolicy lets anyone read the objects users own, but not those
+// under service.PrivatePrefix, which are served presigned only.
+func publicReadPolicy(bucket string) string {
+	return fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::%s/users/*"]}]}`, bucket)
+}
+
+func (a *s3Storage) Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	_, err := a.client.PutObject(ctx, a.bucket, key, body, -1, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", apperror.NewInternal("failed to upload to s3", err)
+	}
+	return a.URL(key, contentType), nil
+}
+
+func (a *s3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := a.client.GetObject(ctx, a.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, apperror.NewInternal("failed to download from s3", err)
+	}
+	// GetObject is lazy; Stat surfaces a missing key before the caller reads.
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, apperror.NewNotFound("object", key)
+		}
+		return nil, apperror.NewInternal("failed to download from s3", err)
+	}
+	return obj, nil
+}
+
+func (a *s3Storage) Delete(ctx context.Context, key string) error {
+	if err := a.client.RemoveObject(ctx, a.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return apperror.NewInternal("failed to delete from s3", err)
+	}
+	return nil
+}
+
+func (a *s3Storage) List(ctx context.Context, prefix string) ([]service.StoredObject, error) {
+	var objects []service.StoredObject
+	for obj := range a.client.ListObjects(ctx, a.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, apperror.NewInternal("failed to list s3 objects", obj.Err)
+		}
+		objects = append(objects, service.StoredObject{Key: obj.Key, Size: obj.Size, ModifiedAt: obj.LastModified})
+	}
+	return objects, nil
+}
+
+func (a *s3Storage) URL(key, contentType string) string {
+	return a.baseURL + "/" + key
+}
+
+// SignedURL presigns a GET on the bucket endpoint, bypassing PublicBaseURL.
+func (a *s3Storage) SignedURL(ctx context.Context, key, contentType string) (string, error) {
+	u, err := a.client.PresignedGetObject(ctx, a.bucket, key, a.signedURLTTL, nil)
+	if err != nil {
+		return "", apperror.NewInternal("failed to presign s3 url", err)
+	}
+	return u.String(), nil
+}
+
+func (a *s3Storage) Driver() string {
+	return DriverS3
+}
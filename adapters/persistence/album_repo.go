@@ -0,0 +1,233 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/khoahotran/personal-os/internal/domain/album"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type postgresAlbumRepo struct {
+	db     *pgxpool.Pool
+	logger logger.Logger
+}
+
+func NewPostgresAlbumRepo(db *pgxpool.Pool, logger logger.Logger) album.Repository {
+	return &postgresAlbumRepo{db: db, logger: logger}
+}
+
+var psqlAlbum = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+const albumColumns = `a.id, a.owner_id, a.slug, a.title, a.description, a.cover_media_id, a.is_public, a.created_at, a.updated_at,
+	ARRAY(SELECT am.media_id FROM album_media am WHERE am.album_id = a.id ORDER BY am.position) AS media_ids,
+	c.url, c.thumbnail_url`
+
+// selectAlbums joins the cover media. Public reads only show a cover that is
+// itself public and processed.
+func selectAlbums(publicOnly bool) sq.SelectBuilder {
+	join := "media c ON c.id = a.cover_media_id"
+	if publicOnly {
+		join += " AND c.is_public = true AND c.status = 'ready'"
+	}
+	return psqlAlbum.Select(albumColumns).From("albums a").LeftJoin(join)
+}
+
+func scanAlbum(row pgx.Row) (*album.Album, error) {
+	a := &album.Album{}
+	var coverURL, coverThumbURL sql.NullString
+
+	err := row.Scan(
+		&a.ID, &a.OwnerID, &a.Slug, &a.Title, &a.Description, &a.CoverMediaID,
+		&a.IsPublic, &a.CreatedAt, &a.UpdatedAt, &a.MediaIDs, &coverURL, &coverThumbURL,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NewNotFound("album", "")
+		}
+		return nil, apperror.NewInternal("failed to scan album row", err)
+	}
+
+	a.CoverURL = coverURL.String
+	if coverThumbURL.Valid {
+		a.CoverThumbnailURL = &coverThumbURL.String
+	}
+	return a, nil
+}
+
+func (r *postgresAlbumRepo) queryAlbums(ctx context.Context, builder sq.SelectBuilder) ([]*album.Album, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build album query", err)
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query albums", err)
+	}
+	defer rows.Close()
+
+	albums := make([]*album.Album, 0)
+	for rows.Next() {
+		a, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating album rows", err)
+	}
+	return albums, nil
+}
+
+func (r *postgresAlbumRepo) findOne(ctx context.Context, builder sq.SelectBuilder) (*album.Album, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build album query", err)
+	}
+	return scanAlbum(r.db.QueryRow(ctx, query, args...))
+}
+
+func (r *postgresAlbumRepo) Save(ctx context.Context, a *album.Album) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `
+			INSERT INTO albums (id, owner_id, slug, title, description, cover_media_id, is_public, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`
+		_, err := tx.Exec(ctx, query, a.ID, a.OwnerID, a.Slug, a.Title, a.Description, a.CoverMediaID, a.IsPublic, a.CreatedAt, a.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return setAlbumMedia(ctx, tx, a)
+	})
+	return albumWriteError(err, a, "failed to save album")
+}
+
+func (r *postgresAlbumRepo) Update(ctx context.Context, a *album.Album) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		query := `
+			UPDATE albums SET
+				slug = $2, title = $3, description = $4, cover_media_id = $5, is_public = $6, updated_at = NOW()
+			WHERE id = $1 AND owner_id = $7
+		`
+		cmdTag, err := tx.Exec(ctx, query, a.ID, a.Slug, a.Title, a.Description, a.CoverMediaID, a.IsPublic, a.OwnerID)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return apperror.NewNotFound("album", a.ID.String())
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM album_media WHERE album_id = $1`, a.ID); err != nil {
+			return err
+		}
+		return setAlbumMedia(ctx, tx, a)
+	})
+	return albumWriteError(err, a, "failed to update album")
+}
+
+// setAlbumMedia inserts the album's media in order, skipping any the owner
+// does not have, and reports ErrUnknownMedia if that dropped any.
+func setAlbumMedia(ctx context.Context, tx pgx.Tx, a *album.Album) error {
+	if len(a.MediaIDs) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO album_media (album_id, media_id, position)
+		SELECT $1, m.id, t.position
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS t(media_id, position)
+		JOIN media m ON m.id = t.media_id AND m.owner_id = $3
+	`
+	cmdTag, err := tx.Exec(ctx, query, a.ID, a.MediaIDs, a.OwnerID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() != int64(len(a.MediaIDs)) {
+		return album.ErrUnknownMedia
+	}
+	return nil
+}
+
+func albumWriteError(err error, a *album.Album, msg string) error {
+	var pgErr *pgconn.PgError
+	var appErr *apperror.AppError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, album.ErrUnknownMedia):
+		return apperror.NewInvalidInput(err.Error(), err)
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return apperror.NewConflict("album", "slug", a.Slug)
+	default:
+		return apperror.NewInternal(msg, err)
+	}
+}
+
+func (r *postgresAlbumRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error {
+	query := `DELETE FROM albums WHERE id = $1 AND owner_id = $2`
+	cmdTag, err := r.db.Exec(ctx, query, id, ownerID)
+	if err != nil {
+		return apperror.NewInternal("failed to delete album", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return apperror.NewNotFound("album", id.String())
+	}
+	return nil
+}
+
+func (r *postgresAlbumRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*album.Album, error) {
+	return r.findOne(ctx, selectAlbums(false).Where(sq.Eq{"a.id": id, "a.owner_id": ownerID}))
+}
+
+func (r *postgresAlbumRepo) FindPublicBySlug(ctx context.Context, slug string) (*album.Album, error) {
+	return r.findOne(ctx, selectAlbums(true).Where(sq.Eq{"a.slug": slug, "a.is_public": true}))
+}
+
+func (r *postgresAlbumRepo) ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*album.Album, error) {
+	builder := selectAlbums(false).
+		Where(sq.Eq{"a.owner_id": ownerID}).
+		OrderBy("a.created_at DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	return r.queryAlbums(ctx, builder)
+}
+
+func (r *postgresAlbumRepo) ListPublic(ctx context.Context, limit, offset int) ([]*album.Album, error) {
+	builder := selectAlbums(true).
+		Where(sq.Eq{"a.is_public": true}).
+		OrderBy("a.created_at DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	return r.queryAlbums(ctx, builder)
+}
+
+func (r *postgresAlbumRepo) ListMedia(ctx context.Context, albumID uuid.UUID, publicOnly bool) ([]*media.Media, error) {
+	builder := psqlMedia.Select(prefixedMediaColumns("m")).
+		From("album_media am").
+		Join("media m ON m.id = am.media_id").
+		Where(sq.Eq{"am.album_id": albumID}).
+		OrderBy("am.position")
+	if publicOnly {
+		builder = builder.Where(sq.Eq{"m.is_public": true, "m.status": media.StatusReady})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build album media query", err)
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query album media", err)
+	}
+	return scanMedias(rows, r.logger)
+}
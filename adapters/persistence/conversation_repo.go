@@ -0,0 +1,181 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/domain/conversation"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type postgresConversationRepo struct {
+	db     *pgxpool.Pool
+	logger logger.Logger
+}
+
+func NewPostgresConversationRepo(db *pgxpool.Pool, logger logger.Logger) conversation.Repository {
+	return &postgresConversationRepo{db: db, logger: logger}
+}
+
+var psqlConversation = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+func scanConversation(row pgx.Row) (*conversation.Conversation, error) {
+	c := &conversation.Conversation{}
+	err := row.Scan(&c.ID, &c.OwnerID, &c.Title, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, conversation.ErrConversationNotFound
+		}
+		return nil, apperror.NewInternal("failed to scan conversation row", err)
+	}
+	return c, nil
+}
+
+func (r *postgresConversationRepo) Save(ctx context.Context, c *conversation.Conversation) error {
+	query := `
+		INSERT INTO chat_conversations (id, owner_id, title, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.db.Exec(ctx, query, c.ID, c.OwnerID, c.Title, c.CreatedAt, c.UpdatedAt)
+	if err != nil {
+		return apperror.NewInternal("failed to save conversation", err)
+	}
+	return nil
+}
+
+func (r *postgresConversationRepo) UpdateTitle(ctx context.Context, id uuid.UUID, ownerID uuid.UUID, title string) error {
+	query := `UPDATE chat_conversations SET title = $3 WHERE id = $1 AND owner_id = $2`
+	cmdTag, err := r.db.Exec(ctx, query, id, ownerID, title)
+	if err != nil {
+		return apperror.NewInternal("failed to update conversation title", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return apperror.NewNotFound("conversation", id.String())
+	}
+	return nil
+}
+
+func (r *postgresConversationRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error {
+	query := `DELETE FROM chat_conversations WHERE id = $1 AND owner_id = $2`
+	cmdTag, err := r.db.Exec(ctx, query, id, ownerID)
+	if err != nil {
+		return apperror.NewInternal("failed to delete conversation", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return apperror.NewNotFound("conversation", id.String())
+	}
+	return nil
+}
+
+func (r *postgresConversationRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*conversation.Conversation, error) {
+	query := `SELECT id, owner_id, title, created_at, updated_at FROM chat_conversations WHERE id = $1 AND owner_id = $2`
+	c, err := scanConversation(r.db.QueryRow(ctx, query, id, ownerID))
+	if errors.Is(err, conversation.ErrConversationNotFound) {
+		return nil, apperror.NewNotFound("conversation", id.String())
+	}
+	return c, err
+}
+
+func (r *postgresConversationRepo) ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*conversation.Conversation, error) {
+	builder := psqlConversation.Select("id, owner_id, title, created_at, updated_at").
+		From("chat_conversations").
+		Where(sq.Eq{"owner_id": ownerID}).
+		OrderBy("updated_at DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build list conversations query", err)
+	}
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query conversations by owner", err)
+	}
+	defer rows.Close()
+
+	conversations := make([]*conversation.Conversation, 0)
+	for rows.Next() {
+		c, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating conversation rows", err)
+	}
+	return conversations, nil
+}
+
+func (r *postgresConversationRepo) AddMessage(ctx context.Context, m *conversation.Message) error {
+	sourcesBytes, err := json.Marshal(m.Sources)
+	if err != nil {
+		return apperror.NewInternal("failed to marshal message sources", err)
+	}
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO chat_messages (id, conversation_id, role, content, sources, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING conversation_id
+		)
+		UPDATE chat_conversations SET updated_at = NOW()
+		WHERE id = (SELECT conversation_id FROM inserted)
+	`
+	_, err = r.db.Exec(ctx, query, m.ID, m.ConversationID, m.Role, m.Content, sourcesBytes, m.CreatedAt)
+	if err != nil {
+		return apperror.NewInternal("failed to save chat message", err)
+	}
+	return nil
+}
+
+func (r *postgresConversationRepo) ListMessages(ctx context.Context, conversationID uuid.UUID, limit int) ([]*conversation.Message, error) {
+	var limitArg any
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	query := `
+		SELECT id, conversation_id, role, content, sources, created_at
+		FROM (
+			SELECT id, conversation_id, role, content, sources, created_at
+			FROM chat_messages
+			WHERE conversation_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		) recent
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, conversationID, limitArg)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query chat messages", err)
+	}
+	defer rows.Close()
+
+	messages := make([]*conversation.Message, 0)
+	for rows.Next() {
+		m := &conversation.Message{}
+		var sourcesBytes []byte
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Role, &m.Content, &sourcesBytes, &m.CreatedAt); err != nil {
+			return nil, apperror.NewInternal("failed to scan chat message", err)
+		}
+		if err := json.Unmarshal(sourcesBytes, &m.Sources); err != nil {
+			r.logger.Warn("Failed to unmarshal chat message sources", zap.String("message_id", m.ID.String()), zap.Error(err))
+			m.Sources = []conversation.SourceRef{}
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating chat messages", err)
+	}
+	return messages, nil
+}
@@ -26,6 +26,8 @@ func NewPostgresHobbyRepo(db *pgxpool.Pool, logger logger.Logger) hobby.Reposito
 
 var psqlHobby = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
+const hobbyItemColumns = "id, owner_id, category, title, status, rating, notes, metadata, is_public, created_at, updated_at"
+
 func scanHobbyItem(row pgx.Row, l logger.Logger) (*hobby.HobbyItem, error) {
 	hi := &hobby.HobbyItem{}
 	var metadataBytes []byte
@@ -122,13 +124,13 @@ func (r *postgresHobbyRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uu
 }
 
 func (r *postgresHobbyRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*hobby.HobbyItem, error) {
-	query := `SELECT * FROM hobby_items WHERE id = $1 AND owner_id = $2`
+	query := `SELECT ` + hobbyItemColumns + ` FROM hobby_items WHERE id = $1 AND owner_id = $2`
 	row := r.db.QueryRow(ctx, query, id, ownerID)
 	return scanHobbyItem(row, r.logger)
 }
 
 func (r *postgresHobbyRepo) ListByOwnerAndCategory(ctx context.Context, ownerID uuid.UUID, category string, limit, offset int) ([]*hobby.HobbyItem, error) {
-	builder := psqlHobby.Select("*").
+	builder := psqlHobby.Select(hobbyItemColumns).
 		From("hobby_items").
 		Where(sq.Eq{"owner_id": ownerID, "category": category}).
 		OrderBy("created_at DESC").
@@ -147,7 +149,7 @@ func (r *postgresHobbyRepo) ListByOwnerAndCategory(ctx context.Context, ownerID
 }
 
 func (r *postgresHobbyRepo) ListPublicByCategory(ctx context.Context, category string, limit, offset int) ([]*hobby.HobbyItem, error) {
-	builder := psqlHobby.Select("*").
+	builder := psqlHobby.Select(hobbyItemColumns).
 		From("hobby_items").
 		Where(sq.Eq{"is_public": true, "category": category}).
 		OrderBy("created_at DESC").
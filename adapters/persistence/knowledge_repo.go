@@ -0,0 +1,164 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type postgresKnowledgeRepo struct {
+	db     *pgxpool.Pool
+	logger logger.Logger
+}
+
+func NewPostgresKnowledgeRepo(db *pgxpool.Pool, logger logger.Logger) knowledge.Repository {
+	return &postgresKnowledgeRepo{db: db, logger: logger}
+}
+
+func (r *postgresKnowledgeRepo) SearchByEmbedding(ctx context.Context, embedding pgvector.Vector, ownerID uuid.UUID, limit int) ([]*knowledge.Source, error) {
+	query := `
+	(SELECT
+		id, 'post' AS resource_type, slug, title,
+		COALESCE(content_markdown, '') AS content,
+		(status = 'public') AS is_public,
+		updated_at,
+		embedding <=> $2 AS distance
+	FROM posts
+	WHERE owner_id = $1 AND status != 'pending' AND embedding IS NOT NULL)
+
+	UNION ALL
+
+	(SELECT
+		id, 'project' AS resource_type, slug, title,
+		concat_ws(E'\n', description, 'Stack: ' || array_to_string(stack, ', ')) AS content,
+		is_public,
+		updated_at,
+		embedding <=> $2 AS distance
+	FROM projects
+	WHERE owner_id = $1 AND embedding IS NOT NULL)
+
+	UNION ALL
+
+	(SELECT
+		id, 'hobby' AS resource_type, id::text AS slug, title,
+		concat_ws(E'\n',
+			category || ' - ' || COALESCE(status, ''),
+			'Rating: ' || rating || '/10',
+			notes
+		) AS content,
+		is_public,
+		updated_at,
+		embedding <=> $2 AS distance
+	FROM hobby_items
+	WHERE owner_id = $1 AND embedding IS NOT NULL)
+
+	UNION ALL
+
+	(SELECT
+		owner_id AS id, 'profile' AS resource_type, $3::text AS slug, 'Profile' AS title,
+		concat_ws(E'\n', bio, (
+			SELECT string_agg(concat_ws(' - ', m->>'date', m->>'title', m->>'description'), E'\n')
+			FROM jsonb_array_elements(career_timeline) AS m
+		)) AS content,
+		false AS is_public,
+		updated_at,
+		embedding <=> $2 AS distance
+	FROM profiles
+	WHERE owner_id = $1 AND embedding IS NOT NULL)
+
+	ORDER BY distance ASC
+	LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, ownerID, embedding, knowledge.ProfileSlug, limit)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query knowledge by embedding", err)
+	}
+	return scanKnowledgeSources(rows)
+}
+
+func (r *postgresKnowledgeRepo) SearchPublicByEmbedding(ctx context.Context, embedding pgvector.Vector, ownerID uuid.UUID, limit int) ([]*knowledge.Source, error) {
+	query := `
+	(SELECT
+		id, 'post' AS resource_type, slug, title,
+		COALESCE(content_markdown, '') AS content,
+		true AS is_public,
+		updated_at,
+		embedding <=> $2 AS distance
+	FROM posts
+	WHERE owner_id = $1 AND status = 'public' AND embedding IS NOT NULL)
+
+	UNION ALL
+
+	(SELECT
+		id, 'project' AS resource_type, slug, title,
+		concat_ws(E'\n', description, 'Stack: ' || array_to_string(stack, ', ')) AS content,
+		true AS is_public,
+		updated_at,
+		embedding <=> $2 AS distance
+	FROM projects
+	WHERE owner_id = $1 AND is_public = true AND embedding IS NOT NULL)
+
+	ORDER BY distance ASC
+	LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, ownerID, embedding, limit)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query public knowledge by embedding", err)
+	}
+	return scanKnowledgeSources(rows)
+}
+
+func scanKnowledgeSources(rows pgx.Rows) ([]*knowledge.Source, error) {
+	defer rows.Close()
+
+	sources := make([]*knowledge.Source, 0)
+	for rows.Next() {
+		s := &knowledge.Source{}
+		if err := rows.Scan(
+			&s.ResourceID, &s.ResourceType, &s.Slug, &s.Title,
+			&s.Content, &s.IsPublic, &s.UpdatedAt, &s.Distance,
+		); err != nil {
+			return nil, apperror.NewInternal("failed to scan knowledge source", err)
+		}
+		sources = append(sources, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating knowledge sources", err)
+	}
+	return sources, nil
+}
+
+func (r *postgresKnowledgeRepo) UpdateEmbedding(ctx context.Context, resourceType knowledge.ResourceType, resourceID uuid.UUID, ownerID uuid.UUID, embedding pgvector.Vector) error {
+	var query string
+	switch resourceType {
+	case knowledge.ResourcePost:
+		query = `UPDATE posts SET embedding = $3 WHERE id = $1 AND owner_id = $2`
+	case knowledge.ResourceProject:
+		query = `UPDATE projects SET embedding = $3 WHERE id = $1 AND owner_id = $2`
+	case knowledge.ResourceHobby:
+		query = `UPDATE hobby_items SET embedding = $3 WHERE id = $1 AND owner_id = $2`
+	case knowledge.ResourceProfile:
+		query = `UPDATE profiles SET embedding = $3 WHERE owner_id = $1 AND owner_id = $2`
+	default:
+		return apperror.NewInvalidInput(fmt.Sprintf("unsupported resource type '%s'", resourceType), nil)
+	}
+
+	cmdTag, err := r.db.Exec(ctx, query, resourceID, ownerID, embedding)
+	if err != nil {
+		return apperror.NewInternal("failed to update embedding", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return apperror.NewNotFound(string(resourceType), resourceID.String())
+	}
+	return nil
+}
@@ -5,10 +5,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/khoahotran/personal-os/internal/domain/media"
 	"github.com/khoahotran/personal-os/pkg/apperror"
@@ -26,15 +29,32 @@ func NewPostgresMediaRepo(db *pgxpool.Pool, logger logger.Logger) media.Reposito
 
 var psqlMedia = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
+const mediaColumns = "id, owner_id, provider, url, thumbnail_url, status, metadata, is_public, kind, blob_id, width, height, dominant_color, blurhash, variants, duration_ms, video_codec, audio_codec, taken_at, processing_attempts, processing_error, last_attempt_at, processed_at, created_at, updated_at"
+
+// prefixedMediaColumns qualifies mediaColumns with a table alias, for queries
+// that join media to other tables.
+func prefixedMediaColumns(alias string) string {
+	cols := strings.Split(mediaColumns, ", ")
+	for i, c := range cols {
+		cols[i] = alias + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
 func scanMedia(row pgx.Row, l logger.Logger) (*media.Media, error) {
 	m := &media.Media{}
-	var metadataBytes []byte
-	var thumbURL sql.NullString
+	var metadataBytes, variantsBytes []byte
+	var thumbURL, dominantColor, blurHash, processingError, videoCodec, audioCodec sql.NullString
+	var width, height sql.NullInt32
+	var durationMS sql.NullInt64
 
 	err := row.Scan(
 		&m.ID, &m.OwnerID, &m.Provider, &m.URL,
 		&thumbURL, &m.Status, &metadataBytes,
-		&m.IsPublic, &m.CreatedAt, &m.UpdatedAt,
+		&m.IsPublic, &m.Kind, &m.BlobID, &width, &height, &dominantColor, &blurHash, &variantsBytes,
+		&durationMS, &videoCodec, &audioCodec,
+		&m.TakenAt, &m.ProcessingAttempts, &processingError, &m.LastAttemptAt, &m.ProcessedAt,
+		&m.CreatedAt, &m.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -46,12 +66,44 @@ func scanMedia(row pgx.Row, l logger.Logger) (*media.Media, error) {
 	if thumbURL.Valid {
 		m.ThumbnailURL = &thumbURL.String
 	}
+	m.Width = int(width.Int32)
+	m.Height = int(height.Int32)
+	m.DominantColor = dominantColor.String
+	m.BlurHash = blurHash.String
+	m.ProcessingError = processingError.String
+	m.Duration = time.Duration(durationMS.Int64) * time.Millisecond
+	m.VideoCodec = videoCodec.String
+	m.AudioCodec = audioCodec.String
 	if err := json.Unmarshal(metadataBytes, &m.Metadata); err != nil {
 		m.Metadata = map[string]any{}
 	}
+	if err := json.Unmarshal(variantsBytes, &m.Variants); err != nil {
+		m.Variants = []media.Variant{}
+	}
 	return m, nil
 }
 
+const blobColumns = "id, owner_id, sha256, is_private, storage_key, content_type, size_bytes, ref_count, created_at"
+
+func scanBlob(row pgx.Row) (*media.Blob, error) {
+	b := &media.Blob{}
+	if err := row.Scan(&b.ID, &b.OwnerID, &b.SHA256, &b.IsPrivate, &b.StorageKey, &b.ContentType, &b.SizeBytes, &b.RefCount, &b.CreatedAt); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func marshalVariants(variants []media.Variant) ([]byte, error) {
+	if variants == nil {
+		variants = []media.Variant{}
+	}
+	b, err := json.Marshal(variants)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to marshal media variants", err)
+	}
+	return b, nil
+}
+
 func scanMedias(rows pgx.Rows, l logger.Logger) ([]*media.Media, error) {
 	defer rows.Close()
 	medias := make([]*media.Media, 0)
@@ -68,38 +120,113 @@ func scanMedias(rows pgx.Rows, l logger.Logger) ([]*media.Media, error) {
 	return medias, nil
 }
 
-func (r *postgresMediaRepo) Save(ctx context.Context, m *media.Media) error {
+func (r *postgresMediaRepo) Save(ctx context.Context, m *media.Media, blob *media.Blob) error {
 	metadataBytes, err := json.Marshal(m.Metadata)
 	if err != nil {
 		return apperror.NewInternal("failed to marshal media metadata", err)
 	}
+	variantsBytes, err := marshalVariants(m.Variants)
+	if err != nil {
+		return err
+	}
 
-	query := `
-		INSERT INTO media (id, owner_id, provider, url, thumbnail_url, status, metadata, is_public, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`
-	_, err = r.db.Exec(ctx, query,
-		m.ID, m.OwnerID, m.Provider, m.URL, m.ThumbnailURL, m.Status,
-		metadataBytes, m.IsPublic, m.CreatedAt, m.UpdatedAt,
-	)
-	return err
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if blob != nil {
+			if err := takeBlobRef(ctx, tx, m, blob); err != nil {
+				return err
+			}
+		}
+
+		query := `
+			INSERT INTO media (id, owner_id, provider, url, thumbnail_url, status, metadata, is_public, blob_id,
+				width, height, dominant_color, blurhash, variants, taken_at, processed_at, created_at, updated_at,
+				kind, duration_ms, video_codec, audio_codec)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0), NULLIF($11, 0), NULLIF($12, ''), NULLIF($13, ''), $14, $15, $16, $17, $18,
+				$19, NULLIF($20, 0), NULLIF($21, ''), NULLIF($22, ''))
+		`
+		_, err := tx.Exec(ctx, query,
+			m.ID, m.OwnerID, m.Provider, m.URL, m.ThumbnailURL, m.Status,
+			metadataBytes, m.IsPublic, m.BlobID, m.Width, m.Height, m.DominantColor, m.BlurHash, variantsBytes,
+			m.TakenAt, m.ProcessedAt, m.CreatedAt, m.UpdatedAt,
+			m.Kind, m.Duration.Milliseconds(), m.VideoCodec, m.AudioCodec,
+		)
+		return err
+	})
+	if errors.Is(err, media.ErrBlobChanged) {
+		return err
+	}
+	if err != nil {
+		return apperror.NewInternal("failed to save media", err)
+	}
+	return nil
+}
+
+// takeBlobRef references an existing blob, or creates a new one. The update
+// locks the blob row, so a concurrent Delete either sees the new reference or
+// has already removed the row, and a lost race with a concurrent upload of the
+// same bytes shows up as a conflict; both return media.ErrBlobChanged rather
+// than pointing media at files that are being deleted or were never stored.
+func takeBlobRef(ctx context.Context, tx pgx.Tx, m *media.Media, blob *media.Blob) error {
+	var err error
+	if blob.ID != uuid.Nil {
+		err = tx.QueryRow(ctx,
+			`UPDATE media_blobs SET ref_count = ref_count + 1, updated_at = NOW() WHERE id = $1 AND owner_id = $2 RETURNING storage_key, ref_count, created_at`,
+			blob.ID, m.OwnerID,
+		).Scan(&blob.StorageKey, &blob.RefCount, &blob.CreatedAt)
+	} else {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO media_blobs (owner_id, sha256, is_private, storage_key, content_type, size_bytes, ref_count)
+			VALUES ($1, $2, $3, $4, $5, $6, 1)
+			ON CONFLICT (owner_id, sha256, is_private) DO NOTHING
+			RETURNING id, ref_count, created_at
+		`, m.OwnerID, blob.SHA256, blob.IsPrivate, blob.StorageKey, blob.ContentType, blob.SizeBytes).Scan(&blob.ID, &blob.RefCount, &blob.CreatedAt)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return media.ErrBlobChanged
+	}
+	if err != nil {
+		return err
+	}
+	blob.OwnerID = m.OwnerID
+	m.BlobID = &blob.ID
+	return nil
 }
 
 func (r *postgresMediaRepo) Update(ctx context.Context, m *media.Media) error {
+	return updateMedia(ctx, r.db, m)
+}
+
+// execer runs updateMedia on the pool or in a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func updateMedia(ctx context.Context, db execer, m *media.Media) error {
 	metadataBytes, err := json.Marshal(m.Metadata)
 	if err != nil {
 		return apperror.NewInternal("failed to marshal media metadata", err)
 	}
+	variantsBytes, err := marshalVariants(m.Variants)
+	if err != nil {
+		return err
+	}
 
 	query := `
 		UPDATE media SET
 			provider = $2, url = $3, thumbnail_url = $4, status = $5, 
-			metadata = $6, is_public = $7, updated_at = NOW()
+			metadata = $6, is_public = $7, width = NULLIF($9, 0), height = NULLIF($10, 0),
+			dominant_color = NULLIF($11, ''), blurhash = NULLIF($12, ''), variants = $13, taken_at = $14,
+			processing_attempts = $15, processing_error = NULLIF($16, ''), last_attempt_at = $17, processed_at = $18,
+			kind = $19, duration_ms = NULLIF($20, 0), video_codec = NULLIF($21, ''), audio_codec = NULLIF($22, ''),
+			updated_at = NOW()
 		WHERE id = $1 AND owner_id = $8
 	`
-	cmdTag, err := r.db.Exec(ctx, query,
+	cmdTag, err := db.Exec(ctx, query,
 		m.ID, m.Provider, m.URL, m.ThumbnailURL, m.Status,
 		metadataBytes, m.IsPublic, m.OwnerID,
+		m.Width, m.Height, m.DominantColor, m.BlurHash, variantsBytes, m.TakenAt,
+		m.ProcessingAttempts, m.ProcessingError, m.LastAttemptAt, m.ProcessedAt,
+		m.Kind, m.Duration.Milliseconds(), m.VideoCodec, m.AudioCodec,
 	)
 	if err != nil {
 		return apperror.NewInternal("failed to update media", err)
@@ -110,29 +237,188 @@ func (r *postgresMediaRepo) Update(ctx context.Context, m *media.Media) error {
 	return nil
 }
 
-func (r *postgresMediaRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error {
-	query := `DELETE FROM media WHERE id = $1 AND owner_id = $2`
-	cmdTag, err := r.db.Exec(ctx, query, id, ownerID)
+func (r *postgresMediaRepo) UpdateProcessing(ctx context.Context, m *media.Media, originalKey string) error {
+	variantsBytes, err := marshalVariants(m.Variants)
 	if err != nil {
-		return apperror.NewInternal("failed to delete media", err)
+		return err
+	}
+
+	query := `
+		UPDATE media SET
+			url = $3, thumbnail_url = $4, status = $5, kind = $6,
+			width = NULLIF($7, 0), height = NULLIF($8, 0), dominant_color = NULLIF($9, ''), blurhash = NULLIF($10, ''), variants = $11,
+			duration_ms = NULLIF($12, 0), video_codec = NULLIF($13, ''), audio_codec = NULLIF($14, ''),
+			processing_attempts = $15, processing_error = NULLIF($16, ''), last_attempt_at = $17, processed_at = $18,
+			updated_at = NOW()
+		WHERE id = $1 AND owner_id = $2 AND COALESCE(metadata->>'original_public_id', '') = $19
+	`
+	cmdTag, err := r.db.Exec(ctx, query,
+		m.ID, m.OwnerID, m.URL, m.ThumbnailURL, m.Status, m.Kind,
+		m.Width, m.Height, m.DominantColor, m.BlurHash, variantsBytes,
+		m.Duration.Milliseconds(), m.VideoCodec, m.AudioCodec,
+		m.ProcessingAttempts, m.ProcessingError, m.LastAttemptAt, m.ProcessedAt,
+		originalKey,
+	)
+	if err != nil {
+		return apperror.NewInternal("failed to update media processing", err)
 	}
 	if cmdTag.RowsAffected() == 0 {
-		return apperror.NewNotFound("media", id.String())
+		return media.ErrOriginalMoved
 	}
 	return nil
 }
 
+func (r *postgresMediaRepo) MoveToBlob(ctx context.Context, m *media.Media, blob *media.Blob) (*media.Blob, error) {
+	var orphaned *media.Blob
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var oldBlobID *uuid.UUID
+		err := tx.QueryRow(ctx, `SELECT blob_id FROM media WHERE id = $1 AND owner_id = $2 FOR UPDATE`, m.ID, m.OwnerID).Scan(&oldBlobID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.NewNotFound("media", m.ID.String())
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := takeBlobRef(ctx, tx, m, blob); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE media SET blob_id = $2 WHERE id = $1`, m.ID, blob.ID); err != nil {
+			return err
+		}
+		if err := updateMedia(ctx, tx, m); err != nil {
+			return err
+		}
+		if oldBlobID != nil {
+			orphaned, err = dropBlobRef(ctx, tx, *oldBlobID)
+		}
+		return err
+	})
+	var appErr *apperror.AppError
+	if errors.Is(err, media.ErrBlobChanged) || errors.As(err, &appErr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, apperror.NewInternal("failed to move media to blob", err)
+	}
+	return orphaned, nil
+}
+
+func (r *postgresMediaRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*media.Blob, error) {
+	var found bool
+	var orphaned *media.Blob
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var blobID *uuid.UUID
+		err := tx.QueryRow(ctx, `DELETE FROM media WHERE id = $1 AND owner_id = $2 RETURNING blob_id`, id, ownerID).Scan(&blobID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		if blobID == nil {
+			return nil
+		}
+		orphaned, err = dropBlobRef(ctx, tx, *blobID)
+		return err
+	})
+	if err != nil {
+		return nil, apperror.NewInternal("failed to delete media", err)
+	}
+	if !found {
+		return nil, apperror.NewNotFound("media", id.String())
+	}
+	return orphaned, nil
+}
+
+// dropBlobRef releases a reference to the blob, deleting it and returning it
+// when that was the last one.
+func dropBlobRef(ctx context.Context, tx pgx.Tx, blobID uuid.UUID) (*media.Blob, error) {
+	var refCount int
+	if err := tx.QueryRow(ctx, `UPDATE media_blobs SET ref_count = ref_count - 1, updated_at = NOW() WHERE id = $1 RETURNING ref_count`, blobID).Scan(&refCount); err != nil {
+		return nil, err
+	}
+	if refCount > 0 {
+		return nil, nil
+	}
+	return scanBlob(tx.QueryRow(ctx, `DELETE FROM media_blobs WHERE id = $1 RETURNING `+blobColumns, blobID))
+}
+
 func (r *postgresMediaRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*media.Media, error) {
-	query := `SELECT * FROM media WHERE id = $1 AND owner_id = $2`
+	query := `SELECT ` + mediaColumns + ` FROM media WHERE id = $1 AND owner_id = $2`
 	row := r.db.QueryRow(ctx, query, id, ownerID)
 	return scanMedia(row, r.logger)
 }
 
-func (r *postgresMediaRepo) ListPublic(ctx context.Context, limit, offset int) ([]*media.Media, error) {
-	builder := psqlMedia.Select("*").
+func (r *postgresMediaRepo) FindBlob(ctx context.Context, ownerID uuid.UUID, sha256 string, private bool) (*media.Blob, error) {
+	query := `SELECT ` + blobColumns + ` FROM media_blobs WHERE owner_id = $1 AND sha256 = $2 AND is_private = $3`
+	b, err := scanBlob(r.db.QueryRow(ctx, query, ownerID, sha256, private))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NewNotFound("media blob", sha256)
+	}
+	if err != nil {
+		return nil, apperror.NewInternal("failed to find media blob", err)
+	}
+	return b, nil
+}
+
+func (r *postgresMediaRepo) StorageKeys(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT storage_key FROM media_blobs
+		UNION
+		SELECT metadata->>'original_public_id' FROM media WHERE metadata ? 'original_public_id'
+		UNION
+		SELECT v->>'key' FROM media, jsonb_array_elements(variants) AS v WHERE v ? 'key'
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query media storage keys", err)
+	}
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, apperror.NewInternal("failed to scan media storage key", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating media storage keys", err)
+	}
+	return keys, nil
+}
+
+func (r *postgresMediaRepo) FindReadyByBlob(ctx context.Context, blobID uuid.UUID) (*media.Media, error) {
+	query := `SELECT ` + mediaColumns + ` FROM media WHERE blob_id = $1 AND status = $2 ORDER BY created_at LIMIT 1`
+	return scanMedia(r.db.QueryRow(ctx, query, blobID, media.StatusReady), r.logger)
+}
+
+func (r *postgresMediaRepo) ListPrivateOutside(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]*media.Media, error) {
+	query := `
+		SELECT ` + mediaColumns + ` FROM media
+		WHERE NOT is_public AND metadata ? 'original_public_id'
+		AND NOT starts_with(metadata->>'original_public_id', $1) AND id > $2
+		ORDER BY id LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, prefix, after, limit)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query private media", err)
+	}
+	return scanMedias(rows, r.logger)
+}
+
+func (r *postgresMediaRepo) ListPublic(ctx context.Context, sort media.Sort, limit, offset int) ([]*media.Media, error) {
+	orderBy := []string{"created_at DESC", "id"}
+	if sort == media.SortTakenAt {
+		orderBy = []string{"taken_at DESC NULLS LAST", "created_at DESC", "id"}
+	}
+	builder := psqlMedia.Select(mediaColumns).
 		From("media").
 		Where(sq.Eq{"is_public": true, "status": media.StatusReady}).
-		OrderBy("created_at DESC").
+		OrderBy(orderBy...).
 		Limit(uint64(limit)).
 		Offset(uint64(offset))
 
@@ -147,14 +433,54 @@ func (r *postgresMediaRepo) ListPublic(ctx context.Context, limit, offset int) (
 	return scanMedias(rows, r.logger)
 }
 
-func (r *postgresMediaRepo) ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*media.Media, error) {
-	builder := psqlMedia.Select("*").
+func (r *postgresMediaRepo) ListByOwner(ctx context.Context, ownerID uuid.UUID, f media.ListFilter, limit, offset int) ([]*media.Media, error) {
+	dateColumn := "created_at"
+	if f.Sort == media.SortTakenAt {
+		dateColumn = "taken_at"
+	}
+	direction := "DESC"
+	if f.Ascending {
+		direction = "ASC"
+	}
+
+	builder := psqlMedia.Select(mediaColumns).
 		From("media").
 		Where(sq.Eq{"owner_id": ownerID}).
-		OrderBy("created_at DESC").
+		OrderBy(dateColumn+" "+direction+" NULLS LAST", "created_at "+direction, "id").
 		Limit(uint64(limit)).
 		Offset(uint64(offset))
 
+	if f.Status != "" {
+		builder = builder.Where(sq.Eq{"status": f.Status})
+	}
+	if f.IsPublic != nil {
+		builder = builder.Where(sq.Eq{"is_public": *f.IsPublic})
+	}
+	if f.ContentType != "" {
+		switch kind := media.Kind(f.ContentType); {
+		case strings.Contains(f.ContentType, "/"):
+			builder = builder.Where(sq.Expr("metadata->>'content_type' = ?", f.ContentType))
+		case kind == media.KindImage, kind == media.KindVideo, kind == media.KindAudio:
+			builder = builder.Where(sq.Eq{"kind": kind})
+		default:
+			builder = builder.Where(sq.Expr("metadata->>'content_type' LIKE ?", f.ContentType+"/%"))
+		}
+	}
+	if f.From != nil {
+		builder = builder.Where(sq.GtOrEq{dateColumn: *f.From})
+	}
+	if f.To != nil {
+		builder = builder.Where(sq.LtOrEq{dateColumn: *f.To})
+	}
+	if f.AlbumID != nil {
+		builder = builder.Where(sq.Expr("EXISTS (SELECT 1 FROM album_media am WHERE am.media_id = media.id AND am.album_id = ?)", *f.AlbumID))
+	}
+	if f.TagSlug != "" {
+		builder = builder.Where(sq.Expr(`EXISTS (
+			SELECT 1 FROM tag_relations tr JOIN tags t ON t.id = tr.tag_id
+			WHERE tr.resource_id = media.id AND tr.resource_type = ? AND t.slug = ?)`, media.ResourceType, f.TagSlug))
+	}
+
 	sql, args, err := builder.ToSql()
 	if err != nil {
 		return nil, apperror.NewInternal("failed to build list media by owner query", err)
@@ -31,6 +31,9 @@ func NewPostgresPostRepo(db *pgxpool.Pool, logger logger.Logger) post.Repository
 
 var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
+// postColumns matches the scan order of scanPost.
+const postColumns = "id, owner_id, slug, title, content_markdown, status, language, og_image_url, thumbnail_url, metadata, version_history, embedding, published_at, created_at, updated_at"
+
 func scanPost(row pgx.Row, l logger.Logger) (*post.Post, error) {
 	p := &post.Post{}
 	var historyBytes, metadataBytes []byte
@@ -45,6 +48,7 @@ func scanPost(row pgx.Row, l logger.Logger) (*post.Post, error) {
 		&p.Title,
 		&p.ContentMarkdown,
 		&p.Status,
+		&p.Language,
 		&ogImageURL,
 		&thumbnailURL,
 		&metadataBytes,
@@ -120,11 +124,11 @@ func (r *postgresPostRepo) Save(ctx context.Context, p *post.Post) error {
 	}
 
 	query := `
-		INSERT INTO posts (id, owner_id, slug, title, content_markdown, status, metadata, version_history, embedding, published_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO posts (id, owner_id, slug, title, content_markdown, status, language, metadata, version_history, embedding, published_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 	_, err = r.db.Exec(ctx, query,
-		p.ID, p.OwnerID, p.Slug, p.Title, p.ContentMarkdown, p.Status,
+		p.ID, p.OwnerID, p.Slug, p.Title, p.ContentMarkdown, p.Status, p.Language,
 		metadataBytes, historyBytes, p.Embedding, p.PublishedAt, p.CreatedAt, p.UpdatedAt,
 	)
 	if err != nil {
@@ -155,13 +159,13 @@ func (r *postgresPostRepo) Update(ctx context.Context, p *post.Post) error {
 		UPDATE posts SET
 			slug = $2, title = $3, content_markdown = $4, status = $5, 
 			version_history = $6, metadata = $7, published_at = $8, og_image_url = $9, thumbnail_url = $10, 
-			embedding = $11,
+			embedding = $11, language = $13,
 			updated_at = NOW()
 		WHERE id = $1 AND owner_id = $12
 	`
 	cmdTag, err := r.db.Exec(ctx, query,
 		p.ID, p.Slug, p.Title, p.ContentMarkdown, p.Status,
-		historyBytes, metadataBytes, p.PublishedAt, p.OgImageURL, p.ThumbnailURL, p.Embedding, p.OwnerID,
+		historyBytes, metadataBytes, p.PublishedAt, p.OgImageURL, p.ThumbnailURL, p.Embedding, p.OwnerID, p.Language,
 	)
 	if err != nil {
 		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
@@ -175,6 +179,25 @@ func (r *postgresPostRepo) Update(ctx context.Context, p *post.Post) error {
 	return nil
 }
 
+func (r *postgresPostRepo) UpdateSuggestions(ctx context.Context, id uuid.UUID, ownerID uuid.UUID, s post.Suggestions) (bool, error) {
+	suggestionsBytes, err := json.Marshal(s)
+	if err != nil {
+		return false, apperror.NewInternal("failed to marshal post suggestions", err)
+	}
+
+	query := `
+		UPDATE posts SET
+			metadata = jsonb_set(COALESCE(metadata, '{}'::jsonb), ARRAY[$3::text], $4::jsonb),
+			updated_at = NOW()
+		WHERE id = $1 AND owner_id = $2 AND status <> $5
+	`
+	cmdTag, err := r.db.Exec(ctx, query, id, ownerID, post.MetaKeySuggestions, suggestionsBytes, post.StatusPending)
+	if err != nil {
+		return false, apperror.NewInternal("failed to update post suggestions", err)
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
 func (r *postgresPostRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error {
 	query := `DELETE FROM posts WHERE id = $1 AND owner_id = $2`
 	cmdTag, err := r.db.Exec(ctx, query, id, ownerID)
@@ -188,25 +211,25 @@ func (r *postgresPostRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uui
 }
 
 func (r *postgresPostRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*post.Post, error) {
-	query := `SELECT id, owner_id, slug, title, content_markdown, status, og_image_url, thumbnail_url, metadata, version_history, embedding, published_at, created_at, updated_at FROM posts WHERE id = $1 AND owner_id = $2`
+	query := `SELECT ` + postColumns + ` FROM posts WHERE id = $1 AND owner_id = $2`
 	row := r.db.QueryRow(ctx, query, id, ownerID)
 	return scanPost(row, r.logger)
 }
 
 func (r *postgresPostRepo) FindBySlug(ctx context.Context, slug string) (*post.Post, error) {
-	query := `SELECT id, owner_id, slug, title, content_markdown, status, og_image_url, thumbnail_url, metadata, version_history, embedding, published_at, created_at, updated_at FROM posts WHERE slug = $1`
+	query := `SELECT ` + postColumns + ` FROM posts WHERE slug = $1`
 	row := r.db.QueryRow(ctx, query, slug)
 	return scanPost(row, r.logger)
 }
 
 func (r *postgresPostRepo) FindPublicBySlug(ctx context.Context, slug string) (*post.Post, error) {
-	query := `SELECT id, owner_id, slug, title, content_markdown, status, og_image_url, thumbnail_url, metadata, version_history, embedding, published_at, created_at, updated_at FROM posts WHERE slug = $1 AND status = $2`
+	query := `SELECT ` + postColumns + ` FROM posts WHERE slug = $1 AND status = $2`
 	row := r.db.QueryRow(ctx, query, slug, post.StatusPublic)
 	return scanPost(row, r.logger)
 }
 
 func (r *postgresPostRepo) ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*post.Post, error) {
-	builder := psql.Select("*").
+	builder := psql.Select(postColumns).
 		From("posts").
 		Where(sq.Eq{"owner_id": ownerID}).
 		OrderBy("created_at DESC").
@@ -225,7 +248,7 @@ func (r *postgresPostRepo) ListByOwner(ctx context.Context, ownerID uuid.UUID, l
 }
 
 func (r *postgresPostRepo) ListPublic(ctx context.Context, limit, offset int) ([]*post.Post, error) {
-	builder := psql.Select("*").
+	builder := psql.Select(postColumns).
 		From("posts").
 		Where(sq.Eq{"status": post.StatusPublic}).
 		OrderBy("published_at DESC").
@@ -245,10 +268,7 @@ func (r *postgresPostRepo) ListPublic(ctx context.Context, limit, offset int) ([
 
 func (r *postgresPostRepo) SearchByEmbedding(ctx context.Context, embedding pgvector.Vector, ownerID uuid.UUID, limit int) ([]*post.Post, error) {
 	query := `
-		SELECT 
-			id, owner_id, slug, title, content_markdown, status, 
-			og_image_url, thumbnail_url, version_history, metadata, embedding, 
-			published_at, created_at, updated_at
+		SELECT ` + postColumns + `
 		FROM posts
 		WHERE owner_id = $1 AND status != $2
 		ORDER BY embedding <=> $3
@@ -267,3 +287,25 @@ func (r *postgresPostRepo) SearchByEmbedding(ctx context.Context, embedding pgve
 
 	return scanPosts(rows, r.logger)
 }
+
+func (r *postgresPostRepo) ListAssetRefs(ctx context.Context) ([]post.AssetRef, error) {
+	query := `SELECT id, owner_id, COALESCE(metadata->>'original_public_id', '') FROM posts`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query post asset refs", err)
+	}
+	defer rows.Close()
+
+	refs := make([]post.AssetRef, 0)
+	for rows.Next() {
+		var ref post.AssetRef
+		if err := rows.Scan(&ref.PostID, &ref.OwnerID, &ref.OriginalKey); err != nil {
+			return nil, apperror.NewInternal("failed to scan post asset ref", err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating post asset refs", err)
+	}
+	return refs, nil
+}
@@ -116,6 +116,7 @@ func (s *PostRepoIntegrationTestSuite) Test_Save_And_FindByID() {
 		Title:           "My First Post",
 		ContentMarkdown: "Hello world",
 		Status:          post.StatusDraft,
+		Language:        post.LanguageEnglish,
 		CreatedAt:       time.Now().UTC(),
 		UpdatedAt:       time.Now().UTC(),
 	}
@@ -137,12 +138,12 @@ func (s *PostRepoIntegrationTestSuite) Test_ListPublic() {
 	publishedAtTime := time.Now()
 	publicPost := &post.Post{
 		ID: uuid.New(), OwnerID: s.testOwner.ID, Slug: "public-post", Title: "Public",
-		Status: post.StatusPublic, PublishedAt: &publishedAtTime,
+		Status: post.StatusPublic, Language: post.LanguageEnglish, PublishedAt: &publishedAtTime,
 		CreatedAt: time.Now(), UpdatedAt: time.Now(),
 	}
 	privatePost := &post.Post{
 		ID: uuid.New(), OwnerID: s.testOwner.ID, Slug: "private-post", Title: "Private",
-		Status:    post.StatusPrivate,
+		Status: post.StatusPrivate, Language: post.LanguageVietnamese,
 		CreatedAt: time.Now(), UpdatedAt: time.Now(),
 	}
 
@@ -155,3 +156,36 @@ func (s *PostRepoIntegrationTestSuite) Test_ListPublic() {
 	s.Len(publicPosts, 1)
 	s.Equal(publicPost.ID, publicPosts[0].ID)
 }
+
+func (s *PostRepoIntegrationTestSuite) Test_UpdateSuggestions() {
+	ctx := context.Background()
+
+	published := &post.Post{
+		ID: uuid.New(), OwnerID: s.testOwner.ID, Slug: "suggested-post", Title: "Suggested",
+		Status: post.StatusPublic, Language: post.LanguageEnglish, Metadata: map[string]any{"requested_status": "public"},
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	pending := &post.Post{
+		ID: uuid.New(), OwnerID: s.testOwner.ID, Slug: "edited-post", Title: "Edited",
+		Status: post.StatusPending, Language: post.LanguageEnglish,
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	s.NoError(s.postRepo.Save(ctx, published))
+	s.NoError(s.postRepo.Save(ctx, pending))
+
+	stored, err := s.postRepo.UpdateSuggestions(ctx, published.ID, s.testOwner.ID, post.Suggestions{Summary: "A summary.", Tags: []string{"Go"}})
+	s.NoError(err)
+	s.True(stored)
+
+	found, err := s.postRepo.FindByID(ctx, published.ID, s.testOwner.ID)
+	s.NoError(err)
+	suggestions, ok := found.Suggestions()
+	s.True(ok)
+	s.Equal("A summary.", suggestions.Summary)
+	s.Equal("public", found.Metadata["requested_status"], "the rest of the metadata is kept")
+	s.Equal("Suggested", found.Title)
+
+	stored, err = s.postRepo.UpdateSuggestions(ctx, pending.ID, s.testOwner.ID, post.Suggestions{Summary: "Stale."})
+	s.NoError(err)
+	s.False(stored, "a post pending again gets new suggestions from its event")
+}
@@ -26,7 +26,7 @@ func NewPostgresProfileRepo(db *pgxpool.Pool, logger logger.Logger) profile.Repo
 
 func (r *postgresProfileRepo) GetByUserID(ctx context.Context, ownerID uuid.UUID) (*profile.Profile, error) {
 	query := `
-		SELECT owner_id, bio, career_timeline, theme_settings, updated_at
+		SELECT owner_id, bio, career_timeline, theme_settings, chat_persona, updated_at
 		FROM profiles
 		WHERE owner_id = $1
 	`
@@ -38,6 +38,7 @@ func (r *postgresProfileRepo) GetByUserID(ctx context.Context, ownerID uuid.UUID
 		&p.Bio,
 		&careerTimelineBytes,
 		&themeSettingsBytes,
+		&p.ChatPersona,
 		&p.UpdatedAt,
 	)
 
@@ -77,11 +78,12 @@ func (r *postgresProfileRepo) Upsert(ctx context.Context, p *profile.Profile) er
 	}
 
 	query := `
-		INSERT INTO profiles (owner_id, bio, career_timeline, theme_settings, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO profiles (owner_id, bio, career_timeline, theme_settings, chat_persona, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (owner_id) DO UPDATE SET
 			bio = EXCLUDED.bio,
 			career_timeline = EXCLUDED.career_timeline,
+			chat_persona = EXCLUDED.chat_persona,
 			updated_at = NOW()
 	`
 	_, err = r.db.Exec(ctx, query,
@@ -89,6 +91,7 @@ func (r *postgresProfileRepo) Upsert(ctx context.Context, p *profile.Profile) er
 		p.Bio,
 		careerTimelineBytes,
 		themeSettingsBytes,
+		p.ChatPersona,
 		p.UpdatedAt,
 	)
 
@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type postgresPromptRepo struct {
+	db     *pgxpool.Pool
+	logger logger.Logger
+}
+
+func NewPostgresPromptRepo(db *pgxpool.Pool, logger logger.Logger) prompt.Repository {
+	return &postgresPromptRepo{db: db, logger: logger}
+}
+
+const promptTemplateColumns = "id, name, version, system_template, user_template, is_active, created_at"
+
+func scanPromptTemplate(row pgx.Row) (*prompt.Template, error) {
+	t := &prompt.Template{}
+	err := row.Scan(&t.ID, &t.Name, &t.Version, &t.System, &t.User, &t.IsActive, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, prompt.ErrTemplateNotFound
+		}
+		return nil, apperror.NewInternal("failed to scan prompt template row", err)
+	}
+	return t, nil
+}
+
+func (r *postgresPromptRepo) FindActive(ctx context.Context, name string) (*prompt.Template, error) {
+	query := `SELECT ` + promptTemplateColumns + ` FROM prompt_templates WHERE name = $1 AND is_active`
+	return scanPromptTemplate(r.db.QueryRow(ctx, query, name))
+}
+
+func (r *postgresPromptRepo) ListVersions(ctx context.Context, name string) ([]*prompt.Template, error) {
+	query := `SELECT ` + promptTemplateColumns + ` FROM prompt_templates WHERE name = $1 ORDER BY version DESC`
+	rows, err := r.db.Query(ctx, query, name)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query prompt template versions", err)
+	}
+	defer rows.Close()
+
+	templates := make([]*prompt.Template, 0)
+	for rows.Next() {
+		t, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating prompt template rows", err)
+	}
+	return templates, nil
+}
+
+func (r *postgresPromptRepo) CreateVersion(ctx context.Context, t *prompt.Template) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// Serialise concurrent saves of the same template so versions stay gapless.
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('prompt_templates:' || $1))`, t.Name); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) + 1 FROM prompt_templates WHERE name = $1`, t.Name).Scan(&t.Version); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE prompt_templates SET is_active = false WHERE name = $1 AND is_active`, t.Name); err != nil {
+			return err
+		}
+		query := `
+			INSERT INTO prompt_templates (id, name, version, system_template, user_template, is_active, created_at)
+			VALUES ($1, $2, $3, $4, $5, true, $6)
+		`
+		_, err := tx.Exec(ctx, query, t.ID, t.Name, t.Version, t.System, t.User, t.CreatedAt)
+		return err
+	})
+	if err != nil {
+		return apperror.NewInternal("failed to save prompt template version", err)
+	}
+	t.IsActive = true
+	return nil
+}
+
+func (r *postgresPromptRepo) Activate(ctx context.Context, name string, version int) error {
+	var found bool
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM prompt_templates WHERE name = $1 AND version = $2)`, name, version).Scan(&found); err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+		if _, err := tx.Exec(ctx, `UPDATE prompt_templates SET is_active = false WHERE name = $1 AND is_active`, name); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `UPDATE prompt_templates SET is_active = true WHERE name = $1 AND version = $2`, name, version)
+		return err
+	})
+	if err != nil {
+		return apperror.NewInternal("failed to activate prompt template version", err)
+	}
+	if !found {
+		return apperror.NewNotFound("prompt template", fmt.Sprintf("%s@%d", name, version))
+	}
+	return nil
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 
 	sq "github.com/Masterminds/squirrel"
-	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/khoahotran/personal-os/internal/domain/search"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/pgvector/pgvector-go"
 )
 
 type postgresSearchRepo struct {
@@ -22,119 +23,366 @@ func NewPostgresSearchRepo(db *pgxpool.Pool, logger logger.Logger) search.Reposi
 
 var psqlSearch = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-func (r *postgresSearchRepo) searchBase(ctx context.Context, builder sq.SelectBuilder) ([]search.SearchResult, error) {
+// maxTagFacets caps the tag facet list; types are few enough to return all.
+const maxTagFacets = 20
+
+const searchResultColumns = "id, resource_type, title, slug, snippet, rank, is_public, tags, updated_at"
+
+// textQueryCTE turns user input into a tsquery that cannot fail to parse: the
+// websearch part and the optional prefix of the last word are ANDed, and an
+// empty side drops out of the conjunction. The query is parsed once per text
+// search configuration (see migration 000010): q.query for unaccented simple
+// matching and q.query_en for English posts, which are stemmed.
+const textQueryCTE = `
+	q AS (
+		SELECT websearch_to_tsquery('simple_unaccent', ?)
+			&& CASE WHEN ?::text = '' THEN ''::tsquery ELSE to_tsquery('simple_unaccent', ?::text || ':*') END AS query,
+			websearch_to_tsquery('english_unaccent', ?)
+			&& CASE WHEN ?::text = '' THEN ''::tsquery ELSE to_tsquery('english_unaccent', ?::text || ':*') END AS query_en
+	)
+`
+
+func textQueryArgs(text string) []interface{} {
+	tq := search.ParseTextQuery(text)
+	return []interface{}{tq.Websearch, tq.Prefix, tq.Prefix, tq.Websearch, tq.Prefix, tq.Prefix}
+}
+
+// postTSQuerySQL picks the parsed query matching a post's language.
+const postTSQuerySQL = `(CASE WHEN posts.language = 'en' THEN q.query_en ELSE q.query END)`
+
+// tagUsageCTE lists every tagged resource with its owner and visibility, so
+// tag matches can be scoped like the resources they label.
+const tagUsageCTE = `
+	tag_usage AS (
+		SELECT tr.tag_id, p.owner_id, (p.status = 'public') AS is_public, p.updated_at
+		FROM tag_relations tr JOIN posts p ON tr.resource_type = 'post' AND p.id = tr.resource_id
+		UNION ALL
+		SELECT tr.tag_id, pr.owner_id, pr.is_public, pr.updated_at
+		FROM tag_relations tr JOIN projects pr ON tr.resource_type = 'project' AND pr.id = tr.resource_id
+	)
+`
+
+const headlineOptions = `'StartSel=*,StopSel=*,MaxFragments=1,MaxWords=10,MinWords=5'`
+
+// mediaTitleSQL picks a display title for media, which has no title column.
+const mediaTitleSQL = `COALESCE(NULLIF(metadata->>'caption', ''), NULLIF(metadata->>'original_filename', ''), 'Untitled media')`
+
+const visibilityStatusSQL = `CASE WHEN is_public THEN 'public' ELSE 'private' END`
+
+func resourceTagsSQL(resourceType, table string) string {
+	return `ARRAY(SELECT t.slug::text FROM tag_relations tr JOIN tags t ON t.id = tr.tag_id
+		WHERE tr.resource_type = '` + resourceType + `' AND tr.resource_id = ` + table + `.id)`
+}
+
+// scopeFilter restricts one UNION branch to what the caller may see. Private
+// scopes bind the owner ID once per branch.
+type scopeFilter struct {
+	sql  string
+	args []interface{}
+}
+
+func scopeFor(q search.Query, ownerColumn, publicCondition string) scopeFilter {
+	if q.Public {
+		return scopeFilter{sql: publicCondition}
+	}
+	return scopeFilter{sql: ownerColumn + " = ?", args: []interface{}{q.OwnerID}}
+}
+
+// keywordDocs builds the CTEs whose final "docs" relation holds every
+// full-text match visible to the query, before filters are applied.
+func keywordDocs(q search.Query) (string, []interface{}) {
+	args := textQueryArgs(q.Text)
+
+	posts := scopeFor(q, "owner_id", "status = 'public'")
+	projects := scopeFor(q, "owner_id", "is_public = true")
+	hobbies := scopeFor(q, "owner_id", "is_public = true")
+	media := scopeFor(q, "owner_id", "is_public = true AND status = 'ready'")
+	tags := scopeFor(q, "u.owner_id", "u.is_public")
+
+	sql := `WITH ` + textQueryCTE + `,` + tagUsageCTE + `,
+	docs AS (
+		(SELECT
+			id, 'post' AS resource_type, title, slug,
+			ts_headline(search_config(language), COALESCE(content_markdown, ''), ` + postTSQuerySQL + `, ` + headlineOptions + `) AS snippet,
+			ts_rank_cd(ts, ` + postTSQuerySQL + `) AS rank,
+			(status = 'public') AS is_public,
+			status::text AS status,
+			` + resourceTagsSQL("post", "posts") + ` AS tags,
+			updated_at
+		FROM posts, q
+		WHERE ` + posts.sql + ` AND ts @@ ` + postTSQuerySQL + `)
+
+		UNION ALL
+
+		(SELECT
+			id, 'project' AS resource_type, title, slug,
+			ts_headline('simple_unaccent', COALESCE(description, ''), q.query, ` + headlineOptions + `) AS snippet,
+			ts_rank_cd(ts, q.query) AS rank,
+			is_public,
+			` + visibilityStatusSQL + ` AS status,
+			` + resourceTagsSQL("project", "projects") + ` AS tags,
+			updated_at
+		FROM projects, q
+		WHERE ` + projects.sql + ` AND ts @@ q.query)
+
+		UNION ALL
+
+		(SELECT
+			id, 'hobby' AS resource_type, title, id::text AS slug,
+			ts_headline('simple_unaccent', COALESCE(notes, ''), q.query, ` + headlineOptions + `) AS snippet,
+			ts_rank_cd(ts, q.query) AS rank,
+			is_public,
+			` + visibilityStatusSQL + ` AS status,
+			ARRAY[]::text[] AS tags,
+			updated_at
+		FROM hobby_items, q
+		WHERE ` + hobbies.sql + ` AND ts @@ q.query)
+
+		UNION ALL
+
+		(SELECT
+			id, 'media' AS resource_type, ` + mediaTitleSQL + ` AS title, id::text AS slug,
+			ts_headline('simple_unaccent', concat_ws(' ', metadata->>'caption', metadata->>'alt'), q.query, ` + headlineOptions + `) AS snippet,
+			ts_rank_cd(ts, q.query) AS rank,
+			is_public,
+			` + visibilityStatusSQL + ` AS status,
+			ARRAY[]::text[] AS tags,
+			updated_at
+		FROM media, q
+		WHERE ` + media.sql + ` AND ts @@ q.query)
+
+		UNION ALL
+
+		(SELECT
+			t.id, 'tag' AS resource_type, t.name AS title, t.slug,
+			t.name AS snippet,
+			ts_rank_cd(to_tsvector('simple_unaccent', t.name), q.query) AS rank,
+			bool_or(u.is_public) AS is_public,
+			CASE WHEN bool_or(u.is_public) THEN 'public' ELSE 'private' END AS status,
+			ARRAY[t.slug::text] AS tags,
+			max(u.updated_at) AS updated_at
+		FROM tags t JOIN tag_usage u ON u.tag_id = t.id, q
+		WHERE ` + tags.sql + ` AND to_tsvector('simple_unaccent', t.name) @@ q.query
+		GROUP BY t.id, t.name, t.slug, q.query)
+	)
+	`
+	for _, s := range []scopeFilter{posts, projects, hobbies, media, tags} {
+		args = append(args, s.args...)
+	}
+	return sql, args
+}
+
+// semanticDocs builds a "docs" CTE over embedded content in the same shape as
+// keywordDocs, ranked by similarity to the query embedding.
+func semanticDocs(q search.Query, embedding pgvector.Vector) (string, []interface{}) {
+	posts := scopeFor(q, "owner_id", "status = 'public'")
+	projects := scopeFor(q, "owner_id", "is_public = true")
+	hobbies := scopeFor(q, "owner_id", "is_public = true")
+
+	sql := `WITH
+	query_embedding AS (SELECT ?::vector AS v),
+	docs AS (
+		(SELECT
+			id, 'post' AS resource_type, title, slug,
+			left(COALESCE(content_markdown, ''), 200) AS snippet,
+			(1 - (embedding <=> e.v))::real AS rank,
+			(status = 'public') AS is_public,
+			status::text AS status,
+			` + resourceTagsSQL("post", "posts") + ` AS tags,
+			updated_at
+		FROM posts, query_embedding e
+		WHERE ` + posts.sql + ` AND embedding IS NOT NULL)
+
+		UNION ALL
+
+		(SELECT
+			id, 'project' AS resource_type, title, slug,
+			left(COALESCE(description, ''), 200) AS snippet,
+			(1 - (embedding <=> e.v))::real AS rank,
+			is_public,
+			` + visibilityStatusSQL + ` AS status,
+			` + resourceTagsSQL("project", "projects") + ` AS tags,
+			updated_at
+		FROM projects, query_embedding e
+		WHERE ` + projects.sql + ` AND embedding IS NOT NULL)
+
+		UNION ALL
+
+		(SELECT
+			id, 'hobby' AS resource_type, title, id::text AS slug,
+			left(COALESCE(notes, ''), 200) AS snippet,
+			(1 - (embedding <=> e.v))::real AS rank,
+			is_public,
+			` + visibilityStatusSQL + ` AS status,
+			ARRAY[]::text[] AS tags,
+			updated_at
+		FROM hobby_items, query_embedding e
+		WHERE ` + hobbies.sql + ` AND embedding IS NOT NULL)
+	)
+	`
+	args := []interface{}{embedding}
+	for _, s := range []scopeFilter{posts, projects, hobbies} {
+		args = append(args, s.args...)
+	}
+	return sql, args
+}
+
+// filterOptions lets facet queries drop the filter of the facet being counted.
+type filterOptions struct {
+	skipTypes bool
+	skipTags  bool
+}
+
+func applySearchFilters(b sq.SelectBuilder, f search.Filters, opts filterOptions) sq.SelectBuilder {
+	if len(f.Types) > 0 && !opts.skipTypes {
+		b = b.Where(sq.Eq{"resource_type": f.Types})
+	}
+	if len(f.Tags) > 0 && !opts.skipTags {
+		b = b.Where("tags && ?::text[]", f.Tags)
+	}
+	if f.From != nil {
+		b = b.Where(sq.GtOrEq{"updated_at": *f.From})
+	}
+	if f.To != nil {
+		b = b.Where(sq.Lt{"updated_at": *f.To})
+	}
+	if len(f.Statuses) > 0 {
+		b = b.Where(sq.Eq{"status": f.Statuses})
+	}
+	return b
+}
+
+func (r *postgresSearchRepo) rankedQuery(ctx context.Context, docsSQL string, docsArgs []interface{}, q search.Query) ([]search.SearchResult, error) {
+	builder := psqlSearch.Select(searchResultColumns).
+		Prefix(docsSQL, docsArgs...).
+		From("docs").
+		OrderBy("rank DESC", "resource_type ASC", "id ASC").
+		Limit(uint64(q.Limit))
+	builder = applySearchFilters(builder, q.Filters, filterOptions{})
+	if q.After != nil {
+		builder = builder.Where("(rank < ? OR (rank = ? AND (resource_type, id) > (?::text, ?::uuid)))",
+			q.After.Rank, q.After.Rank, q.After.ResourceType, q.After.ID)
+	}
+
 	sql, args, err := builder.ToSql()
 	if err != nil {
 		return nil, apperror.NewInternal("failed to build search query", err)
 	}
-
 	rows, err := r.db.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, apperror.NewInternal("failed to execute search query", err)
 	}
+	return scanSearchResults(rows)
+}
+
+func (r *postgresSearchRepo) Search(ctx context.Context, q search.Query) ([]search.SearchResult, error) {
+	docsSQL, docsArgs := keywordDocs(q)
+	return r.rankedQuery(ctx, docsSQL, docsArgs, q)
+}
+
+func (r *postgresSearchRepo) SemanticSearch(ctx context.Context, q search.Query, embedding pgvector.Vector) ([]search.SearchResult, error) {
+	docsSQL, docsArgs := semanticDocs(q, embedding)
+	return r.rankedQuery(ctx, docsSQL, docsArgs, q)
+}
+
+func (r *postgresSearchRepo) Facets(ctx context.Context, q search.Query) (*search.Facets, error) {
+	docsSQL, docsArgs := keywordDocs(q)
+
+	types := applySearchFilters(
+		psqlSearch.Select("resource_type", "count(*)").Prefix(docsSQL, docsArgs...).From("docs").
+			GroupBy("resource_type").OrderBy("count(*) DESC", "resource_type ASC"),
+		q.Filters, filterOptions{skipTypes: true},
+	)
+	typeCounts, err := r.facetQuery(ctx, types)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := applySearchFilters(
+		psqlSearch.Select("tag", "count(*)").Prefix(docsSQL, docsArgs...).From("docs, unnest(tags) AS tag").
+			GroupBy("tag").OrderBy("count(*) DESC", "tag ASC").Limit(maxTagFacets),
+		q.Filters, filterOptions{skipTags: true},
+	)
+	tagCounts, err := r.facetQuery(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &search.Facets{Types: typeCounts, Tags: tagCounts}, nil
+}
+
+func (r *postgresSearchRepo) facetQuery(ctx context.Context, builder sq.SelectBuilder) ([]search.FacetCount, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build search facet query", err)
+	}
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to execute search facet query", err)
+	}
 	defer rows.Close()
 
-	results := make([]search.SearchResult, 0)
+	counts := make([]search.FacetCount, 0)
 	for rows.Next() {
-		var res search.SearchResult
-		if err := rows.Scan(
-			&res.ID, &res.ResourceType, &res.Title, &res.Slug,
-			&res.Snippet, &res.Rank, &res.IsPublic, &res.UpdatedAt,
-		); err != nil {
-			return nil, apperror.NewInternal("failed to scan search result", err)
+		var fc search.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, apperror.NewInternal("failed to scan search facet", err)
 		}
-		results = append(results, res)
+		counts = append(counts, fc)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, apperror.NewInternal("error iterating search results", err)
+		return nil, apperror.NewInternal("error iterating search facets", err)
 	}
-	return results, nil
+	return counts, nil
 }
 
-func (r *postgresSearchRepo) SearchPrivate(ctx context.Context, query string, ownerID uuid.UUID, limit int) ([]search.SearchResult, error) {
-	finalSql := `
-	(SELECT 
-		id, 'post' AS resource_type, title, slug,
-		ts_headline('simple', content_markdown, to_tsquery('simple', $1), 'StartSel=*,StopSel=*,MaxFragments=1,MaxWords=10,MinWords=5') AS snippet,
-		ts_rank_cd(ts, to_tsquery('simple', $1)) AS rank,
-		(status = 'public') AS is_public,
-		updated_at
-	FROM posts
-	WHERE owner_id = $2 AND ts @@ to_tsquery('simple', $1))
-	
-	UNION ALL
-	
-	(SELECT 
-		id, 'project' AS resource_type, title, slug,
-		ts_headline('simple', description, to_tsquery('simple', $1), 'StartSel=*,StopSel=*,MaxFragments=1,MaxWords=10,MinWords=5') AS snippet,
-		ts_rank_cd(ts, to_tsquery('simple', $1)) AS rank,
-		is_public,
-		updated_at
-	FROM projects
-	WHERE owner_id = $2 AND ts @@ to_tsquery('simple', $1))
-
-	-- (Sau này có thể UNION ALL với bảng hobby_items ở đây)
-	
-	ORDER BY rank DESC
-	LIMIT $3
-	`
+// SuggestPublic completes titles of public posts and projects. Every word must
+// match and the last one may be incomplete, so it works while the user types.
+func (r *postgresSearchRepo) SuggestPublic(ctx context.Context, query string, limit int) ([]search.Suggestion, error) {
+	finalSql := `WITH ` + textQueryCTE + `
+	SELECT resource_type, title, slug FROM (
+		(SELECT 'post' AS resource_type, title, slug,
+			ts_rank(to_tsvector('simple_unaccent', title), q.query) AS rank
+		FROM posts, q
+		WHERE status = 'public' AND to_tsvector('simple_unaccent', title) @@ q.query)
+
+		UNION ALL
 
-	finalArgs := []interface{}{query, ownerID, limit}
+		(SELECT 'project' AS resource_type, title, slug,
+			ts_rank(to_tsvector('simple_unaccent', title), q.query) AS rank
+		FROM projects, q
+		WHERE is_public = true AND to_tsvector('simple_unaccent', title) @@ q.query)
+	) AS matches
+	ORDER BY rank DESC, length(title) ASC, title ASC
+	LIMIT ?
+	`
+	finalSql, err := sq.Dollar.ReplacePlaceholders(finalSql)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build search suggestions query", err)
+	}
+	args := append(textQueryArgs(query), limit)
 
-	rows, err := r.db.Query(ctx, finalSql, finalArgs...)
+	rows, err := r.db.Query(ctx, finalSql, args...)
 	if err != nil {
-		return nil, apperror.NewInternal("failed to execute private search", err)
+		return nil, apperror.NewInternal("failed to execute search suggestions", err)
 	}
 	defer rows.Close()
 
-	results := make([]search.SearchResult, 0)
+	suggestions := make([]search.Suggestion, 0)
 	for rows.Next() {
-		var res search.SearchResult
-		if err := rows.Scan(
-			&res.ID, &res.ResourceType, &res.Title, &res.Slug,
-			&res.Snippet, &res.Rank, &res.IsPublic, &res.UpdatedAt,
-		); err != nil {
-			return nil, apperror.NewInternal("failed to scan search result", err)
+		var s search.Suggestion
+		if err := rows.Scan(&s.ResourceType, &s.Title, &s.Slug); err != nil {
+			return nil, apperror.NewInternal("failed to scan search suggestion", err)
 		}
-		results = append(results, res)
+		suggestions = append(suggestions, s)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, apperror.NewInternal("error iterating search results", err)
+		return nil, apperror.NewInternal("error iterating search suggestions", err)
 	}
-	return results, nil
+	return suggestions, nil
 }
 
-func (r *postgresSearchRepo) SearchPublic(ctx context.Context, query string, limit int) ([]search.SearchResult, error) {
-	finalSql := `
-	(SELECT 
-		id, 'post' AS resource_type, title, slug,
-		ts_headline('simple', content_markdown, to_tsquery('simple', $1), 'StartSel=*,StopSel=*,MaxFragments=1,MaxWords=10,MinWords=5') AS snippet,
-		ts_rank_cd(ts, to_tsquery('simple', $1)) AS rank,
-		true AS is_public,
-		updated_at
-	FROM posts
-	WHERE status = 'public' AND ts @@ to_tsquery('simple', $1))
-	
-	UNION ALL
-	
-	(SELECT 
-		id, 'project' AS resource_type, title, slug,
-		ts_headline('simple', description, to_tsquery('simple', $1), 'StartSel=*,StopSel=*,MaxFragments=1,MaxWords=10,MinWords=5') AS snippet,
-		ts_rank_cd(ts, to_tsquery('simple', $1)) AS rank,
-		is_public,
-		updated_at
-	FROM projects
-	WHERE is_public = true AND ts @@ to_tsquery('simple', $1))
-	
-	ORDER BY rank DESC
-	LIMIT $2
-	`
-	finalArgs := []interface{}{query, limit}
-
-	rows, err := r.db.Query(ctx, finalSql, finalArgs...)
-	if err != nil {
-		return nil, apperror.NewInternal("failed to execute public search", err)
-	}
+func scanSearchResults(rows pgx.Rows) ([]search.SearchResult, error) {
 	defer rows.Close()
 
 	results := make([]search.SearchResult, 0)
@@ -142,7 +390,7 @@ func (r *postgresSearchRepo) SearchPublic(ctx context.Context, query string, lim
 		var res search.SearchResult
 		if err := rows.Scan(
 			&res.ID, &res.ResourceType, &res.Title, &res.Slug,
-			&res.Snippet, &res.Rank, &res.IsPublic, &res.UpdatedAt,
+			&res.Snippet, &res.Rank, &res.IsPublic, &res.Tags, &res.UpdatedAt,
 		); err != nil {
 			return nil, apperror.NewInternal("failed to scan search result", err)
 		}
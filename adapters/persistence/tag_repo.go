@@ -130,3 +130,25 @@ func (r *postgresTagRepo) GetTagsForResource(ctx context.Context, resourceID uui
 	}
 	return tags, nil
 }
+
+func (r *postgresTagRepo) ListAll(ctx context.Context) ([]tag.Tag, error) {
+	query := `SELECT id, name, slug FROM tags ORDER BY name`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query tags", err)
+	}
+	defer rows.Close()
+
+	tags := make([]tag.Tag, 0)
+	for rows.Next() {
+		var t tag.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Slug); err != nil {
+			return nil, apperror.NewInternal("failed to scan tag", err)
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating tags", err)
+	}
+	return tags, nil
+}
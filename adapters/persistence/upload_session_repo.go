@@ -0,0 +1,150 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/khoahotran/personal-os/internal/domain/uploadsession"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type postgresUploadSessionRepo struct {
+	db     *pgxpool.Pool
+	logger logger.Logger
+}
+
+func NewPostgresUploadSessionRepo(db *pgxpool.Pool, logger logger.Logger) uploadsession.Repository {
+	return &postgresUploadSessionRepo{db: db, logger: logger}
+}
+
+var psqlUploadSession = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+const uploadSessionColumns = `s.id, s.owner_id, s.file_name, s.size_bytes, s.part_size, s.sha256, s.metadata,
+	s.is_public, s.keep_location, s.expires_at, s.created_at, s.updated_at,
+	ARRAY(SELECT p.part_number FROM upload_session_parts p WHERE p.session_id = s.id ORDER BY p.part_number) AS parts`
+
+func scanUploadSession(row pgx.Row) (*uploadsession.Session, error) {
+	s := &uploadsession.Session{}
+	var metadataBytes []byte
+
+	err := row.Scan(
+		&s.ID, &s.OwnerID, &s.FileName, &s.Size, &s.PartSize, &s.SHA256, &metadataBytes,
+		&s.IsPublic, &s.KeepLocation, &s.ExpiresAt, &s.CreatedAt, &s.UpdatedAt, &s.Parts,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NewNotFound("upload session", "")
+		}
+		return nil, apperror.NewInternal("failed to scan upload session row", err)
+	}
+	if err := json.Unmarshal(metadataBytes, &s.Metadata); err != nil {
+		return nil, apperror.NewInternal("failed to unmarshal upload session metadata", err)
+	}
+	return s, nil
+}
+
+func (r *postgresUploadSessionRepo) Save(ctx context.Context, s *uploadsession.Session) error {
+	metadataBytes, err := json.Marshal(s.Metadata)
+	if err != nil {
+		return apperror.NewInternal("failed to marshal upload session metadata", err)
+	}
+	query := `
+		INSERT INTO upload_sessions (id, owner_id, file_name, size_bytes, part_size, sha256, metadata,
+			is_public, keep_location, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err = r.db.Exec(ctx, query, s.ID, s.OwnerID, s.FileName, s.Size, s.PartSize, s.SHA256, metadataBytes,
+		s.IsPublic, s.KeepLocation, s.ExpiresAt, s.CreatedAt, s.UpdatedAt)
+	if err != nil {
+		return apperror.NewInternal("failed to save upload session", err)
+	}
+	return nil
+}
+
+func (r *postgresUploadSessionRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*uploadsession.Session, error) {
+	query, args, err := psqlUploadSession.Select(uploadSessionColumns).
+		From("upload_sessions s").
+		Where(sq.Eq{"s.id": id, "s.owner_id": ownerID}).
+		Where("s.expires_at > NOW()").
+		ToSql()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build upload session query", err)
+	}
+	s, err := scanUploadSession(r.db.QueryRow(ctx, query, args...))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, apperror.NewNotFound("upload session", id.String())
+	}
+	return s, err
+}
+
+func (r *postgresUploadSessionRepo) AddPart(ctx context.Context, id uuid.UUID, n int, expiresAt time.Time) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx, `UPDATE upload_sessions SET expires_at = $2 WHERE id = $1 AND expires_at > NOW()`, id, expiresAt)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return apperror.NewNotFound("upload session", id.String())
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO upload_session_parts (session_id, part_number) VALUES ($1, $2) ON CONFLICT DO NOTHING`, id, n)
+		return err
+	})
+	var appErr *apperror.AppError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &appErr):
+		return appErr
+	default:
+		return apperror.NewInternal("failed to record upload part", err)
+	}
+}
+
+func (r *postgresUploadSessionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	cmdTag, err := r.db.Exec(ctx, `DELETE FROM upload_sessions WHERE id = $1`, id)
+	if err != nil {
+		return apperror.NewInternal("failed to delete upload session", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return apperror.NewNotFound("upload session", id.String())
+	}
+	return nil
+}
+
+func (r *postgresUploadSessionRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]*uploadsession.Session, error) {
+	query, args, err := psqlUploadSession.Select(uploadSessionColumns).
+		From("upload_sessions s").
+		Where(sq.Lt{"s.expires_at": before}).
+		OrderBy("s.expires_at").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, apperror.NewInternal("failed to build upload session query", err)
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to query upload sessions", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*uploadsession.Session, 0)
+	for rows.Next() {
+		s, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.NewInternal("error iterating upload session rows", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Question is one evaluation case. ExpectedSlugs are the slugs of the sources
+// that should be retrieved; the order does not matter.
+type Question struct {
+	ID            string   `json:"id" yaml:"id"`
+	Question      string   `json:"question" yaml:"question"`
+	ExpectedSlugs []string `json:"expected_slugs" yaml:"expected_slugs"`
+}
+
+// loadDataset reads a .yaml/.yml file holding a list of questions (or a
+// `questions:` key), or a .jsonl file with one question per line.
+func loadDataset(path string) ([]Question, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	var questions []Question
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		questions, err = parseYAMLDataset(data)
+	case ".jsonl":
+		questions, err = parseJSONLDataset(data)
+	default:
+		return nil, fmt.Errorf("unsupported dataset extension %q (want .yaml, .yml or .jsonl)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(questions))
+	for i := range questions {
+		q := &questions[i]
+		if strings.TrimSpace(q.Question) == "" {
+			return nil, fmt.Errorf("question #%d has no text", i+1)
+		}
+		if q.ID == "" {
+			q.ID = fmt.Sprintf("q%03d", i+1)
+		}
+		if seen[q.ID] {
+			return nil, fmt.Errorf("duplicate question id %q", q.ID)
+		}
+		seen[q.ID] = true
+	}
+	return questions, nil
+}
+
+func parseYAMLDataset(data []byte) ([]Question, error) {
+	var list []Question
+	if err := yaml.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	var doc struct {
+		Questions []Question `yaml:"questions"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML dataset: %w", err)
+	}
+	return doc.Questions, nil
+}
+
+func parseJSONLDataset(data []byte) ([]Question, error) {
+	var questions []Question
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		var q Question
+		if err := json.Unmarshal(text, &q); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		questions = append(questions, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSONL dataset: %w", err)
+	}
+	return questions, nil
+}
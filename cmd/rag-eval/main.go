@@ -0,0 +1,181 @@
+// Command rag-eval measures retrieval quality of the chat pipeline against a
+// set of questions with known relevant sources.
+//
+//	go run ./cmd/rag-eval -dataset eval/questions.yaml -k 5 -out eval/baseline.json
+//
+// The JSON report lists questions in id order with a fixed field order, so two
+// runs can be compared with a plain diff. Pass -latency=false to leave timing
+// out of the report when diffing. Use -llm-provider fake (and optionally
+// -embedding-provider fake) to run without a model server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/khoahotran/personal-os/adapters/embedding"
+	"github.com/khoahotran/personal-os/adapters/llm"
+	"github.com/khoahotran/personal-os/adapters/persistence"
+	chatUC "github.com/khoahotran/personal-os/internal/application/usecase/chat"
+	"github.com/khoahotran/personal-os/internal/config"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+func main() {
+	configDir := flag.String("config", ".", "directory containing config.yaml and .env")
+	datasetPath := flag.String("dataset", "", "questions file (.yaml, .yml or .jsonl)")
+	k := flag.Int("k", 5, "number of sources to retrieve per question")
+	ownerFlag := flag.String("owner", "", "owner UUID whose knowledge is searched (defaults to public_chat.owner_id)")
+	generate := flag.Bool("generate", false, "also generate an answer for every question")
+	withLatency := flag.Bool("latency", true, "include latency figures in the report")
+	llmProvider := flag.String("llm-provider", "", "override llm.provider (e.g. fake)")
+	embeddingProvider := flag.String("embedding-provider", "", "override embedding.provider (e.g. fake)")
+	outPath := flag.String("out", "", "write the JSON report to this file instead of stdout")
+	flag.Parse()
+
+	if *datasetPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *k <= 0 {
+		log.Fatalf("-k must be positive")
+	}
+
+	cfg, err := config.LoadConfig(*configDir)
+	if err != nil {
+		log.Fatalf("cannot load config: %v", err)
+	}
+	if *llmProvider != "" {
+		cfg.LLM.Provider = *llmProvider
+	}
+	if *embeddingProvider != "" {
+		cfg.Embedding.Provider = *embeddingProvider
+	}
+
+	ownerStr := *ownerFlag
+	if ownerStr == "" {
+		ownerStr = cfg.PublicChat.OwnerID
+	}
+	ownerID, err := uuid.Parse(ownerStr)
+	if err != nil {
+		log.Fatalf("a valid owner UUID is required (-owner or public_chat.owner_id): %v", err)
+	}
+
+	questions, err := loadDataset(*datasetPath)
+	if err != nil {
+		log.Fatalf("cannot load dataset: %v", err)
+	}
+
+	appLogger := logger.NewZapLogger("production")
+
+	dbPool, err := persistence.NewPostgresPool(cfg, appLogger)
+	if err != nil {
+		log.Fatalf("cannot connect Postgres: %v", err)
+	}
+	defer dbPool.Close()
+
+	embedder, err := embedding.NewEmbeddingService(cfg, appLogger)
+	if err != nil {
+		log.Fatalf("cannot initialize embedding provider: %v", err)
+	}
+	llmService, err := llm.NewLLMService(cfg, appLogger)
+	if err != nil {
+		log.Fatalf("cannot initialize LLM provider: %v", err)
+	}
+
+	chatUseCase := chatUC.NewChatUseCase(
+		embedder,
+		llmService,
+		persistence.NewPostgresKnowledgeRepo(dbPool, appLogger),
+		persistence.NewPostgresPromptRepo(dbPool, appLogger),
+		chatUC.ChatConfig{ContextTokenBudget: cfg.Chat.ContextTokenBudget},
+		appLogger,
+	)
+
+	report := run(context.Background(), chatUseCase, questions, ownerID, *k, *generate, *withLatency)
+
+	out := os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("cannot create report file: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatalf("cannot write report: %v", err)
+	}
+
+	s := report.Summary
+	fmt.Fprintf(os.Stderr, "questions=%d errors=%d recall@%d=%.4f mrr=%.4f\n", s.Questions, s.Errors, s.K, s.MeanRecall, s.MRR)
+}
+
+func run(ctx context.Context, uc *chatUC.ChatUseCase, questions []Question, ownerID uuid.UUID, k int, generate, withLatency bool) Report {
+	sorted := append([]Question(nil), questions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	results := make([]QuestionResult, 0, len(sorted))
+	var retrievalLatencies, generationLatencies []time.Duration
+
+	for _, q := range sorted {
+		expected := append([]string{}, q.ExpectedSlugs...)
+		sort.Strings(expected)
+		r := QuestionResult{ID: q.ID, Question: q.Question, ExpectedSlugs: expected, RetrievedSlugs: []string{}}
+		input := chatUC.ChatInput{Query: q.Question, OwnerID: ownerID, Limit: k}
+
+		start := time.Now()
+		sources, err := uc.Retrieve(ctx, input)
+		elapsed := time.Since(start)
+		if err != nil {
+			r.Error = err.Error()
+			results = append(results, r)
+			continue
+		}
+		retrievalLatencies = append(retrievalLatencies, elapsed)
+		if withLatency {
+			ms := elapsed.Milliseconds()
+			r.RetrievalMillis = &ms
+		}
+		for _, s := range sources {
+			r.RetrievedSlugs = append(r.RetrievedSlugs, s.Slug)
+		}
+		r.Recall, r.ReciprocalRank, r.FirstHitRank = scoreRetrieval(q.ExpectedSlugs, r.RetrievedSlugs, k)
+		r.Recall, r.ReciprocalRank = round4(r.Recall), round4(r.ReciprocalRank)
+
+		if generate {
+			start = time.Now()
+			output, err := uc.Execute(ctx, input)
+			elapsed = time.Since(start)
+			if err != nil {
+				r.Error = err.Error()
+			} else {
+				generationLatencies = append(generationLatencies, elapsed)
+				if withLatency {
+					ms := elapsed.Milliseconds()
+					r.GenerationMillis = &ms
+				}
+				r.Answer = output.Response
+				for _, c := range output.Citations {
+					r.CitedSlugs = append(r.CitedSlugs, c.Source.Slug)
+				}
+			}
+		}
+		results = append(results, r)
+	}
+
+	return Report{
+		Summary: summarize(results, k, retrievalLatencies, generationLatencies, withLatency),
+		Results: results,
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// QuestionResult is the outcome for one question. Field order is fixed so
+// that JSON reports from two runs can be diffed line by line.
+type QuestionResult struct {
+	ID               string   `json:"id"`
+	Question         string   `json:"question"`
+	ExpectedSlugs    []string `json:"expected_slugs"`
+	RetrievedSlugs   []string `json:"retrieved_slugs"`
+	FirstHitRank     int      `json:"first_hit_rank"`
+	Recall           float64  `json:"recall_at_k"`
+	ReciprocalRank   float64  `json:"reciprocal_rank"`
+	RetrievalMillis  *int64   `json:"retrieval_ms,omitempty"`
+	Answer           string   `json:"answer,omitempty"`
+	CitedSlugs       []string `json:"cited_slugs,omitempty"`
+	GenerationMillis *int64   `json:"generation_ms,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+type Summary struct {
+	Questions     int     `json:"questions"`
+	Errors        int     `json:"errors"`
+	K             int     `json:"k"`
+	MeanRecall    float64 `json:"mean_recall_at_k"`
+	MRR           float64 `json:"mrr"`
+	RetrievalP50  *int64  `json:"retrieval_p50_ms,omitempty"`
+	RetrievalP95  *int64  `json:"retrieval_p95_ms,omitempty"`
+	GenerationP50 *int64  `json:"generation_p50_ms,omitempty"`
+	GenerationP95 *int64  `json:"generation_p95_ms,omitempty"`
+}
+
+type Report struct {
+	Summary Summary          `json:"summary"`
+	Results []QuestionResult `json:"results"`
+}
+
+// scoreRetrieval computes recall@k and the reciprocal rank of the first
+// relevant slug within the top k retrieved slugs. A question without
+// expected slugs scores 1 when nothing relevant is expected.
+func scoreRetrieval(expected, retrieved []string, k int) (recall, reciprocalRank float64, firstHitRank int) {
+	if len(retrieved) > k {
+		retrieved = retrieved[:k]
+	}
+	if len(expected) == 0 {
+		return 1, 1, 0
+	}
+
+	want := make(map[string]bool, len(expected))
+	for _, s := range expected {
+		want[s] = true
+	}
+	found := make(map[string]bool, len(expected))
+	for i, s := range retrieved {
+		if !want[s] || found[s] {
+			continue
+		}
+		found[s] = true
+		if firstHitRank == 0 {
+			firstHitRank = i + 1
+		}
+	}
+
+	recall = float64(len(found)) / float64(len(want))
+	if firstHitRank > 0 {
+		reciprocalRank = 1 / float64(firstHitRank)
+	}
+	return recall, reciprocalRank, firstHitRank
+}
+
+func summarize(results []QuestionResult, k int, retrievalLatencies, generationLatencies []time.Duration, withLatency bool) Summary {
+	s := Summary{Questions: len(results), K: k}
+	scored := 0
+	for _, r := range results {
+		if r.Error != "" {
+			s.Errors++
+			continue
+		}
+		scored++
+		s.MeanRecall += r.Recall
+		s.MRR += r.ReciprocalRank
+	}
+	if scored > 0 {
+		s.MeanRecall = round4(s.MeanRecall / float64(scored))
+		s.MRR = round4(s.MRR / float64(scored))
+	}
+	if withLatency {
+		s.RetrievalP50, s.RetrievalP95 = percentile(retrievalLatencies, 50), percentile(retrievalLatencies, 95)
+		s.GenerationP50, s.GenerationP95 = percentile(generationLatencies, 50), percentile(generationLatencies, 95)
+	}
+	return s
+}
+
+// percentile uses the nearest-rank method and returns nil for no samples.
+func percentile(samples []time.Duration, p int) *int64 {
+	if len(samples) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	ms := sorted[rank-1].Milliseconds()
+	return &ms
+}
+
+func round4(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreRetrieval(t *testing.T) {
+	recall, rr, rank := scoreRetrieval([]string{"a", "b"}, []string{"x", "b", "a", "y"}, 5)
+	assert.Equal(t, 1.0, recall)
+	assert.Equal(t, 0.5, rr)
+	assert.Equal(t, 2, rank)
+
+	recall, rr, rank = scoreRetrieval([]string{"a", "b"}, []string{"x", "y", "a"}, 2)
+	assert.Equal(t, 0.0, recall, "hits beyond k do not count")
+	assert.Equal(t, 0.0, rr)
+	assert.Equal(t, 0, rank)
+
+	recall, _, _ = scoreRetrieval([]string{"a", "b"}, []string{"a", "a", "x"}, 3)
+	assert.Equal(t, 0.5, recall, "duplicate hits count once")
+}
+
+func TestSummarize(t *testing.T) {
+	results := []QuestionResult{
+		{ID: "q1", Recall: 1, ReciprocalRank: 1},
+		{ID: "q2", Recall: 0.5, ReciprocalRank: 0.25},
+		{ID: "q3", Error: "boom"},
+	}
+	latencies := []time.Duration{10 * time.Millisecond, 30 * time.Millisecond}
+
+	s := summarize(results, 5, latencies, nil, true)
+	assert.Equal(t, 3, s.Questions)
+	assert.Equal(t, 1, s.Errors)
+	assert.Equal(t, 0.75, s.MeanRecall)
+	assert.Equal(t, 0.625, s.MRR)
+	require.NotNil(t, s.RetrievalP50)
+	assert.Equal(t, int64(10), *s.RetrievalP50)
+	assert.Equal(t, int64(30), *s.RetrievalP95)
+	assert.Nil(t, s.GenerationP50)
+
+	s = summarize(results, 5, latencies, nil, false)
+	assert.Nil(t, s.RetrievalP50)
+}
+
+func TestLoadDataset(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "q.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+questions:
+  - id: generics
+    question: When did Go get generics?
+    expected_slugs: [go-generics]
+  - question: What is my stack?
+`), 0o644))
+	questions, err := loadDataset(yamlPath)
+	require.NoError(t, err)
+	require.Len(t, questions, 2)
+	assert.Equal(t, []string{"go-generics"}, questions[0].ExpectedSlugs)
+	assert.Equal(t, "q002", questions[1].ID)
+
+	jsonlPath := filepath.Join(dir, "q.jsonl")
+	require.NoError(t, os.WriteFile(jsonlPath, []byte(`{"id":"a","question":"one","expected_slugs":["x"]}
+# comment
+
+{"id":"a","question":"two"}
+`), 0o644))
+	_, err = loadDataset(jsonlPath)
+	assert.ErrorContains(t, err, "duplicate question id")
+}
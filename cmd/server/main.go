@@ -9,13 +9,16 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/khoahotran/personal-os/adapters/embedding"
 	"github.com/khoahotran/personal-os/adapters/event"
 	httpAdapter "github.com/khoahotran/personal-os/adapters/http"
+	"github.com/khoahotran/personal-os/adapters/imaging"
 	"github.com/khoahotran/personal-os/adapters/llm"
 	"github.com/khoahotran/personal-os/adapters/media_storage"
 	"github.com/khoahotran/personal-os/adapters/persistence"
+	albumUC "github.com/khoahotran/personal-os/internal/application/usecase/album"
 	authUC "github.com/khoahotran/personal-os/internal/application/usecase/auth"
 	chatUC "github.com/khoahotran/personal-os/internal/application/usecase/chat"
 	hobbyUC "github.com/khoahotran/personal-os/internal/application/usecase/hobby"
@@ -23,11 +26,13 @@ import (
 	postUC "github.com/khoahotran/personal-os/internal/application/usecase/post"
 	profileUC "github.com/khoahotran/personal-os/internal/application/usecase/profile"
 	projectUC "github.com/khoahotran/personal-os/internal/application/usecase/project"
+	promptUC "github.com/khoahotran/personal-os/internal/application/usecase/prompt"
 	searchUC "github.com/khoahotran/personal-os/internal/application/usecase/search"
 	"github.com/khoahotran/personal-os/internal/config"
 	"github.com/khoahotran/personal-os/pkg/auth"
 	"github.com/khoahotran/personal-os/pkg/logger"
 	"github.com/khoahotran/personal-os/pkg/tracing"
+	"github.com/khoahotran/personal-os/pkg/upload"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
@@ -83,62 +88,121 @@ func main() {
 	tagRepo := persistence.NewPostgresTagRepo(dbPool, appLogger)
 	projectRepo := persistence.NewPostgresProjectRepo(dbPool, appLogger)
 	mediaRepo := persistence.NewPostgresMediaRepo(dbPool, appLogger)
+	albumRepo := persistence.NewPostgresAlbumRepo(dbPool, appLogger)
+	uploadSessionRepo := persistence.NewPostgresUploadSessionRepo(dbPool, appLogger)
 	hobbyRepo := persistence.NewPostgresHobbyRepo(dbPool, appLogger)
 	searchRepo := persistence.NewPostgresSearchRepo(dbPool, appLogger)
+	knowledgeRepo := persistence.NewPostgresKnowledgeRepo(dbPool, appLogger)
+	conversationRepo := persistence.NewPostgresConversationRepo(dbPool, appLogger)
+	promptRepo := persistence.NewPostgresPromptRepo(dbPool, appLogger)
 
 	// Services
 	jwtSvc := auth.NewJWTService(cfg.Auth.JWTSecret, cfg.Auth.TokenLifespan)
-	uploader, err := media_storage.NewCloudinaryAdapter(cfg, appLogger)
+	storage, err := media_storage.NewStorage(context.Background(), cfg, appLogger)
 	if err != nil {
-		appLogger.Fatal("FATAL: Failed to initialize uploader", err)
+		appLogger.Fatal("FATAL: Failed to initialize media storage", err)
 	}
-	embedder, err := embedding.NewOllamaAdapter(cfg, appLogger)
+	exifService := imaging.NewExifService(appLogger)
+	embedder, err := embedding.NewEmbeddingService(cfg, appLogger)
 	if err != nil {
-		appLogger.Fatal("FATAL: Failed to initialize Ollama adapter", err)
+		appLogger.Fatal("FATAL: Failed to initialize embedding provider", err)
 	}
-	llmService, err := llm.NewOllamaLLMAdapter(cfg, appLogger)
+	llmService, err := llm.NewLLMService(cfg, appLogger)
 	if err != nil {
-		appLogger.Fatal("FATAL: Failed to initialize Ollama LLM adapter", err)
+		appLogger.Fatal("FATAL: Failed to initialize LLM provider", err)
 	}
 
 	// Use Cases
 	loginUseCase := authUC.NewLoginUseCase(userRepo, jwtSvc, appLogger)
-	profileUseCase := profileUC.NewProfileUseCase(profileRepo, appLogger)
+	profileUseCase := profileUC.NewProfileUseCase(profileRepo, kafkaClient, appLogger)
 
-	createPostUseCase := postUC.NewCreatePostUseCase(postRepo, tagRepo, kafkaClient, uploader, appLogger)
+	createPostUseCase := postUC.NewCreatePostUseCase(postRepo, tagRepo, kafkaClient, storage, exifService, appLogger)
 	listPostsUseCase := postUC.NewListPostsUseCase(postRepo, tagRepo, appLogger)
 	listPublicPostsUseCase := postUC.NewListPublicPostsUseCase(postRepo, tagRepo, appLogger)
 	updatePostUseCase := postUC.NewUpdatePostUseCase(postRepo, tagRepo, kafkaClient, appLogger)
 	deletePostUseCase := postUC.NewDeletePostUseCase(postRepo, tagRepo, kafkaClient, appLogger)
 	getPostUseCase := postUC.NewGetPostUseCase(postRepo, tagRepo, appLogger)
-	getPublicPostUseCase := postUC.NewGetPublicPostUseCase(postRepo, tagRepo, appLogger)
+	getPublicPostUseCase := postUC.NewGetPublicPostUseCase(postRepo, tagRepo, albumRepo, appLogger)
+	acceptSuggestionsUseCase := postUC.NewAcceptSuggestionsUseCase(postRepo, tagRepo, appLogger)
+	reprocessPostUseCase := postUC.NewReprocessPostUseCase(postRepo, kafkaClient, appLogger)
 
-	createProjectUseCase := projectUC.NewCreateProjectUseCase(projectRepo, tagRepo, appLogger)
+	createProjectUseCase := projectUC.NewCreateProjectUseCase(projectRepo, tagRepo, kafkaClient, appLogger)
 	listProjectsUseCase := projectUC.NewListProjectsUseCase(projectRepo, appLogger)
 	listPublicProjectsUseCase := projectUC.NewListPublicProjectsUseCase(projectRepo, appLogger)
 	getProjectUseCase := projectUC.NewGetProjectUseCase(projectRepo, tagRepo, appLogger)
 	getPublicProjectUseCase := projectUC.NewGetPublicProjectUseCase(projectRepo, tagRepo, appLogger)
-	updateProjectUseCase := projectUC.NewUpdateProjectUseCase(projectRepo, tagRepo, appLogger)
+	updateProjectUseCase := projectUC.NewUpdateProjectUseCase(projectRepo, tagRepo, kafkaClient, appLogger)
 	deleteProjectUseCase := projectUC.NewDeleteProjectUseCase(projectRepo, tagRepo, appLogger)
 
-	uploadMediaUseCase := mediaUC.NewUploadMediaUseCase(mediaRepo, uploader, kafkaClient, appLogger)
+	uploadMediaUseCase := mediaUC.NewUploadMediaUseCase(mediaRepo, storage, exifService, kafkaClient, appLogger)
 	listPublicMediaUseCase := mediaUC.NewListPublicMediaUseCase(mediaRepo, appLogger)
-	updateMediaUseCase := mediaUC.NewUpdateMediaUseCase(mediaRepo, appLogger)
-	deleteMediaUseCase := mediaUC.NewDeleteMediaUseCase(mediaRepo, uploader, appLogger)
-
-	hobbyUseCase := hobbyUC.NewHobbyUseCase(hobbyRepo, appLogger)
+	updateMediaUseCase := mediaUC.NewUpdateMediaUseCase(mediaRepo, storage, appLogger)
+	deleteMediaUseCase := mediaUC.NewDeleteMediaUseCase(mediaRepo, tagRepo, storage, appLogger)
+	listMediaUseCase := mediaUC.NewListMediaUseCase(mediaRepo, tagRepo, storage, appLogger)
+	bulkMediaUseCase := mediaUC.NewBulkMediaUseCase(mediaRepo, tagRepo, storage, deleteMediaUseCase, appLogger)
+	reprocessMediaUseCase := mediaUC.NewReprocessMediaUseCase(mediaRepo, storage, kafkaClient, appLogger)
+	albumUseCase := albumUC.NewAlbumUseCase(albumRepo, storage, appLogger)
+
+	hobbyUseCase := hobbyUC.NewHobbyUseCase(hobbyRepo, kafkaClient, appLogger)
 	chatUseCase := chatUC.NewChatUseCase(
 		embedder,
 		llmService,
-		postRepo,
+		knowledgeRepo,
+		promptRepo,
+		chatUC.ChatConfig{ContextTokenBudget: cfg.Chat.ContextTokenBudget},
+		appLogger,
+	)
+	publicChatOwnerID, err := uuid.Parse(cfg.PublicChat.OwnerID)
+	if err != nil {
+		appLogger.Warn("public_chat.owner_id is not a valid UUID, public chat will find no sources")
+	}
+	publicChatUseCase := chatUC.NewPublicChatUseCase(
+		embedder,
+		llmService,
+		knowledgeRepo,
+		profileRepo,
+		promptRepo,
+		chatUC.PublicChatConfig{
+			OwnerID:            publicChatOwnerID,
+			MaxQueryLength:     cfg.PublicChat.MaxQueryLength,
+			MaxDistance:        cfg.PublicChat.MaxDistance,
+			MaxSources:         cfg.PublicChat.MaxSources,
+			ContextTokenBudget: cfg.Chat.ContextTokenBudget,
+		},
 		appLogger,
 	)
-	searchUseCase := searchUC.NewSearchUseCase(searchRepo, appLogger)
+	conversationUseCase := chatUC.NewConversationUseCase(conversationRepo, chatUseCase, appLogger)
+	promptUseCase := promptUC.NewPromptUseCase(promptRepo, appLogger)
+	searchUseCase := searchUC.NewSearchUseCase(searchRepo, embedder, appLogger)
 	rssUseCase := postUC.NewRSSUseCase(postRepo, appLogger)
 
 	// HTTP Handlers
 	authHandler := httpAdapter.NewAuthHandler(loginUseCase, appLogger)
 	profileHandler := httpAdapter.NewProfileHandler(profileUseCase, appLogger)
+	maxCoverMB, maxMediaMB := cfg.Upload.MaxCoverSizeMB, cfg.Upload.MaxMediaSizeMB
+	if maxCoverMB <= 0 {
+		maxCoverMB = 10
+	}
+	if maxMediaMB <= 0 {
+		maxMediaMB = 100
+	}
+	coverPolicy := upload.Policy{MaxSize: maxCoverMB << 20, Allowed: upload.ImageTypes}
+	mediaPolicy := upload.Policy{MaxSize: maxMediaMB << 20, Allowed: upload.MediaTypes}
+
+	maxSessionMB, partSizeMB, sessionTTL := cfg.Upload.MaxSessionSizeMB, cfg.Upload.PartSizeMB, cfg.Upload.SessionTTL
+	if maxSessionMB <= 0 {
+		maxSessionMB = 2048
+	}
+	if partSizeMB <= 0 {
+		partSizeMB = 8
+	}
+	if sessionTTL <= 0 {
+		sessionTTL = 24 * time.Hour
+	}
+	sessionPolicy := upload.Policy{MaxSize: maxSessionMB << 20, Allowed: upload.MediaTypes}
+	resumableUploadUseCase := mediaUC.NewResumableUploadUseCase(uploadSessionRepo, storage, uploadMediaUseCase, sessionPolicy,
+		mediaUC.ResumableUploadSettings{PartSize: partSizeMB << 20, TTL: sessionTTL}, appLogger)
+
 	postHandler := httpAdapter.NewPostHandler(
 		createPostUseCase,
 		listPostsUseCase,
@@ -147,6 +211,9 @@ func main() {
 		deletePostUseCase,
 		getPostUseCase,
 		getPublicPostUseCase,
+		acceptSuggestionsUseCase,
+		reprocessPostUseCase,
+		coverPolicy,
 		appLogger,
 	)
 	hobbyHandler := httpAdapter.NewHobbyHandler(hobbyUseCase, appLogger)
@@ -158,28 +225,54 @@ func main() {
 
 	mediaHandler := httpAdapter.NewMediaHandler(
 		uploadMediaUseCase,
+		listMediaUseCase,
 		listPublicMediaUseCase,
 		updateMediaUseCase,
 		deleteMediaUseCase,
+		bulkMediaUseCase,
+		reprocessMediaUseCase,
+		mediaPolicy,
 		appLogger,
 	)
 
+	uploadSessionHandler := httpAdapter.NewUploadSessionHandler(resumableUploadUseCase, appLogger)
+
+	albumHandler := httpAdapter.NewAlbumHandler(albumUseCase, appLogger)
+
 	chatHandler := httpAdapter.NewChatHandler(
 		chatUseCase,
+		publicChatUseCase,
 		appLogger,
 	)
 
+	conversationHandler := httpAdapter.NewConversationHandler(conversationUseCase, appLogger)
+
+	promptHandler := httpAdapter.NewPromptHandler(promptUseCase, appLogger)
+
 	searchHandler := httpAdapter.NewSearchHandler(searchUseCase, appLogger)
 
 	rssHandler := httpAdapter.NewRSSHandler(rssUseCase, appLogger)
 
 	// Middleware
 	authMiddleware := httpAdapter.AuthMiddleware(jwtSvc, appLogger)
+	publicChatLimit, publicChatWindow := cfg.PublicChat.RateLimit, cfg.PublicChat.RateWindow
+	if publicChatLimit <= 0 {
+		publicChatLimit = 10
+	}
+	if publicChatWindow < time.Second {
+		publicChatWindow = time.Minute
+	}
+	publicChatRateLimit := httpAdapter.RateLimitMiddleware(redisClient, "public-chat", publicChatLimit, publicChatWindow, appLogger)
 
 	// Setup Gin router
 	router := gin.Default()
 	router.Use(httpAdapter.ErrorMiddleware(appLogger))
 	router.Use(otelgin.Middleware("personal-os-api"))
+	if local, ok := storage.(*media_storage.LocalStorage); ok {
+		router.StaticFS(local.ServePath(), httpAdapter.PublicMediaFS(local.Root()))
+		signedMediaHandler := httpAdapter.NewSignedMediaHandler(local, local.Signer(), appLogger)
+		router.GET(local.SignedServePath()+"/*key", signedMediaHandler.ServeSigned)
+	}
 	api := router.Group("/api")
 	{
 
@@ -207,6 +300,24 @@ func main() {
 					})
 				})
 				adminPrivate.POST("/chat", chatHandler.Chat)
+				adminPrivate.POST("/chat/stream", chatHandler.ChatStream)
+
+				conversations := adminPrivate.Group("/chat/conversations")
+				{
+					conversations.POST("", conversationHandler.CreateConversation)
+					conversations.GET("", conversationHandler.ListConversations)
+					conversations.GET("/:id", conversationHandler.GetConversation)
+					conversations.DELETE("/:id", conversationHandler.DeleteConversation)
+					conversations.POST("/:id/messages", conversationHandler.SendMessage)
+				}
+
+				prompts := adminPrivate.Group("/prompts")
+				{
+					prompts.GET("", promptHandler.ListPrompts)
+					prompts.GET("/:name", promptHandler.GetPrompt)
+					prompts.POST("/:name", promptHandler.SavePrompt)
+					prompts.POST("/:name/versions/:version/activate", promptHandler.ActivatePrompt)
+				}
 
 				adminPrivate.GET("/profile", profileHandler.GetProfile)
 				adminPrivate.PUT("/profile", profileHandler.UpdateProfile)
@@ -218,6 +329,8 @@ func main() {
 					posts.PUT("/:id", postHandler.UpdatePost)
 					posts.DELETE("/:id", postHandler.DeletePost)
 					posts.GET("/:id", postHandler.GetPost)
+					posts.POST("/:id/suggestions/accept", postHandler.AcceptSuggestions)
+					posts.POST("/:id/reprocess", postHandler.ReprocessPost)
 				}
 
 				projects := adminPrivate.Group("/projects")
@@ -231,9 +344,27 @@ func main() {
 
 				media := adminPrivate.Group("/media")
 				{
+					media.GET("", mediaHandler.ListMedia)
 					media.POST("/upload", mediaHandler.UploadMedia)
+					media.POST("/bulk", mediaHandler.BulkMedia)
 					media.PUT("/:id", mediaHandler.UpdateMedia)
 					media.DELETE("/:id", mediaHandler.DeleteMedia)
+					media.POST("/:id/reprocess", mediaHandler.ReprocessMedia)
+
+					media.POST("/uploads", uploadSessionHandler.StartUpload)
+					media.GET("/uploads/:id", uploadSessionHandler.GetUpload)
+					media.PUT("/uploads/:id/parts/:part", uploadSessionHandler.UploadPart)
+					media.POST("/uploads/:id/complete", uploadSessionHandler.CompleteUpload)
+					media.DELETE("/uploads/:id", uploadSessionHandler.AbortUpload)
+				}
+
+				albums := adminPrivate.Group("/albums")
+				{
+					albums.POST("", albumHandler.CreateAlbum)
+					albums.GET("", albumHandler.ListAlbums)
+					albums.GET("/:id", albumHandler.GetAlbum)
+					albums.PUT("/:id", albumHandler.UpdateAlbum)
+					albums.DELETE("/:id", albumHandler.DeleteAlbum)
 				}
 
 				hobbies := adminPrivate.Group("/hobbies")
@@ -260,10 +391,15 @@ func main() {
 			public.GET("/projects/:slug", projectHandler.GetPublicProject)
 
 			public.GET("/media", mediaHandler.ListPublicMedia)
+			public.GET("/albums", albumHandler.ListPublicAlbums)
+			public.GET("/albums/:slug", albumHandler.GetPublicAlbum)
 
 			public.GET("/hobbies", hobbyHandler.ListPublicHobbyItems) // ?category=...
 
 			public.GET("/search", searchHandler.SearchPublic)
+			public.GET("/search/suggest", searchHandler.SuggestPublic)
+
+			public.POST("/chat", publicChatRateLimit, chatHandler.PublicChat)
 
 			public.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
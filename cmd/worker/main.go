@@ -10,12 +10,17 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/khoahotran/personal-os/adapters/embedding"
 	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/adapters/imaging"
+	"github.com/khoahotran/personal-os/adapters/llm"
 	"github.com/khoahotran/personal-os/adapters/media_storage"
 	"github.com/khoahotran/personal-os/adapters/persistence"
 	"github.com/khoahotran/personal-os/internal/application/usecase/backup"
+	"github.com/khoahotran/personal-os/internal/application/usecase/cleanup"
+	knowledgeUC "github.com/khoahotran/personal-os/internal/application/usecase/knowledge"
 	mediaUC "github.com/khoahotran/personal-os/internal/application/usecase/media"
 	postUC "github.com/khoahotran/personal-os/internal/application/usecase/post"
 	"github.com/khoahotran/personal-os/internal/config"
@@ -39,9 +44,15 @@ func main() {
 	appLogger.Info("Worker Logger initialized")
 
 	// Embedding Service
-	embedder, err := embedding.NewOllamaAdapter(cfg, appLogger)
+	embedder, err := embedding.NewEmbeddingService(cfg, appLogger)
 	if err != nil {
-		appLogger.Fatal("FATAL: Failed to initialize Ollama adapter", err)
+		appLogger.Fatal("FATAL: Failed to initialize embedding provider", err)
+	}
+
+	// LLM Service
+	llmService, err := llm.NewLLMService(cfg, appLogger)
+	if err != nil {
+		appLogger.Fatal("FATAL: Failed to initialize LLM provider", err)
 	}
 
 	// Database
@@ -51,20 +62,36 @@ func main() {
 	}
 	defer dbPool.Close()
 
-	// Cloudinary Uploader
-	uploader, err := media_storage.NewCloudinaryAdapter(cfg, appLogger)
+	// Media Storage
+	storage, err := media_storage.NewStorage(context.Background(), cfg, appLogger)
 	if err != nil {
-		appLogger.Fatal("FATAL: Failed to initialize uploader", err)
+		appLogger.Fatal("FATAL: Failed to initialize media storage", err)
 	}
+	imageProcessor := imaging.NewProcessor(appLogger)
+	avProber := imaging.NewFFmpegProber(imaging.FFmpegSettings{
+		FFmpegPath:  cfg.FFmpeg.FFmpegPath,
+		FFprobePath: cfg.FFmpeg.FFprobePath,
+	}, appLogger)
 
 	// Repositories
 	postRepo := persistence.NewPostgresPostRepo(dbPool, appLogger)
+	tagRepo := persistence.NewPostgresTagRepo(dbPool, appLogger)
+	promptRepo := persistence.NewPostgresPromptRepo(dbPool, appLogger)
 	mediaRepo := persistence.NewPostgresMediaRepo(dbPool, appLogger)
+	uploadSessionRepo := persistence.NewPostgresUploadSessionRepo(dbPool, appLogger)
+	projectRepo := persistence.NewPostgresProjectRepo(dbPool, appLogger)
+	hobbyRepo := persistence.NewPostgresHobbyRepo(dbPool, appLogger)
+	profileRepo := persistence.NewPostgresProfileRepo(dbPool, appLogger)
+	knowledgeRepo := persistence.NewPostgresKnowledgeRepo(dbPool, appLogger)
 
 	// Worker Use Case
-	processPostEventUC := postUC.NewProcessPostEventUseCase(postRepo, uploader, embedder, appLogger)
-	processMediaEventUC := mediaUC.NewProcessMediaUseCase(mediaRepo, uploader, appLogger)
-	backupUseCase := backup.NewBackupUseCase(cfg, uploader, appLogger)
+	processPostEventUC := postUC.NewProcessPostEventUseCase(postRepo, tagRepo, promptRepo, storage, imageProcessor, embedder, llmService, appLogger)
+	processMediaEventUC := mediaUC.NewProcessMediaUseCase(mediaRepo, storage, imageProcessor, avProber, appLogger)
+	backupUseCase := backup.NewBackupUseCase(cfg, storage, appLogger)
+	orphanedAssetsUC := cleanup.NewOrphanedAssetsUseCase(mediaRepo, postRepo, storage, appLogger)
+	abandonedUploadsUC := cleanup.NewAbandonedUploadsUseCase(uploadSessionRepo, storage, appLogger)
+	backfillPrivateMediaUC := mediaUC.NewBackfillPrivateMediaUseCase(mediaRepo, storage, appLogger)
+	indexKnowledgeUC := knowledgeUC.NewIndexKnowledgeUseCase(knowledgeRepo, projectRepo, hobbyRepo, profileRepo, embedder, appLogger)
 
 	// Kafka Consumer
 	postConsumer := kafka.NewReader(kafka.ReaderConfig{
@@ -85,6 +112,15 @@ func main() {
 	})
 	defer mediaConsumer.Close()
 
+	knowledgeConsumer := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  cfg.Kafka.Brokers,
+		Topic:    event.TopicKnowledgeEvents,
+		GroupID:  "knowledge-indexer-group",
+		MinBytes: 10e3,
+		MaxBytes: 10e6,
+	})
+	defer knowledgeConsumer.Close()
+
 	c := cron.New()
 	// 2AM every day
 	_, err = c.AddFunc("0 2 * * *", func() {
@@ -94,8 +130,69 @@ func main() {
 	if err != nil {
 		appLogger.Fatal("Failed to add cron job", err)
 	}
+
+	gcSchedule := cfg.Storage.GC.Schedule
+	if gcSchedule == "" {
+		gcSchedule = "0 3 * * *"
+	}
+	gcInput := cleanup.OrphanedAssetsInput{GracePeriod: cfg.Storage.GC.GracePeriod, DryRun: cfg.Storage.GC.DryRun}
+	if gcInput.GracePeriod <= 0 {
+		gcInput.GracePeriod = 24 * time.Hour
+	}
+	_, err = c.AddFunc(gcSchedule, func() {
+		appLogger.Info("Cron job triggered: Collecting orphaned storage objects...", zap.Bool("dry_run", gcInput.DryRun))
+		report, err := orphanedAssetsUC.Execute(context.Background(), gcInput)
+		if err != nil {
+			appLogger.Error("Orphaned storage collection failed", err)
+			return
+		}
+		appLogger.Info("Orphaned storage collection finished",
+			zap.Bool("dry_run", report.DryRun),
+			zap.Int("scanned", report.Scanned),
+			zap.Int("orphans", len(report.Orphans)),
+			zap.Int("in_grace_period", report.InGracePeriod),
+			zap.Int("dangling", len(report.Dangling)),
+			zap.Int("deleted", report.Deleted),
+			zap.Int("failed", report.Failed),
+		)
+	})
+	if err != nil {
+		appLogger.Fatal("Failed to add cron job", err)
+	}
+
+	// Every hour
+	_, err = c.AddFunc("0 * * * *", func() {
+		report, err := abandonedUploadsUC.Execute(context.Background())
+		if err != nil {
+			appLogger.Error("Abandoned upload cleanup failed", err)
+			return
+		}
+		if report.Sessions > 0 || report.Failed > 0 {
+			appLogger.Info("Abandoned upload cleanup finished",
+				zap.Int("sessions", report.Sessions),
+				zap.Int("deleted_parts", report.DeletedParts),
+				zap.Int("failed", report.Failed),
+			)
+		}
+	})
+	if err != nil {
+		appLogger.Fatal("Failed to add cron job", err)
+	}
 	c.Start()
-	appLogger.Info("Cron job scheduler started. Backup scheduled for 2 AM.")
+	appLogger.Info("Cron job scheduler started. Backup scheduled for 2 AM.", zap.String("storage_gc_schedule", gcSchedule))
+
+	// Private media stored before private media was kept apart are moved
+	// under the private prefix; once done, later starts find none.
+	go func() {
+		report, err := backfillPrivateMediaUC.Execute(context.Background())
+		if err != nil {
+			appLogger.Error("Private media backfill failed", err)
+			return
+		}
+		if report.Moved > 0 || report.Failed > 0 {
+			appLogger.Info("Private media backfill finished", zap.Int("moved", report.Moved), zap.Int("failed", report.Failed))
+		}
+	}()
 
 	// Context and run
 
@@ -103,7 +200,7 @@ func main() {
 	defer cancel()
 
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(4)
 
 	go func() {
 		defer wg.Done()
@@ -219,6 +316,43 @@ func main() {
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		appLogger.Info("Worker listening on topic", zap.String("topic", event.TopicKnowledgeEvents))
+		for {
+			select {
+			case <-ctx.Done():
+				appLogger.Info("Stopping Knowledge consumer...")
+				return
+			default:
+				msg, err := knowledgeConsumer.FetchMessage(ctx)
+				if err != nil {
+					if errors.Is(err, context.Canceled) {
+						return
+					}
+					appLogger.Error("Failed to read message", err, zap.String("topic", event.TopicKnowledgeEvents))
+					continue
+				}
+
+				appLogger.Info("Received message", zap.String("topic", msg.Topic), zap.String("key", string(msg.Key)))
+				var payload event.KnowledgeEventPayload
+				if err := json.Unmarshal(msg.Value, &payload); err != nil {
+					appLogger.Error("Failed to unmarshal knowledge event", err, zap.ByteString("value", msg.Value))
+					commitMessage(knowledgeConsumer, msg, appLogger)
+					continue
+				}
+
+				l := appLogger.With(zap.String("resource_id", payload.ResourceID.String()), zap.String("resource_type", payload.ResourceType))
+				l.Info("Processing event")
+				if err := indexKnowledgeUC.Execute(ctx, payload); err != nil {
+					l.Error("Failed to process knowledge event", err)
+					continue
+				}
+				commitMessage(knowledgeConsumer, msg, appLogger)
+			}
+		}
+	}()
+
 	// Ctrl+C
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
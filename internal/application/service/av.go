@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrProberUnavailable is returned by an AVProber that has no tools to run,
+// such as when ffmpeg is not installed. Media is then served without its
+// metadata or poster.
+var ErrProberUnavailable = errors.New("audio/video prober is unavailable")
+
+// AVInfo describes a video or audio file. Fields the file does not carry are
+// left empty.
+type AVInfo struct {
+	Duration time.Duration
+	// Width and Height are the video's dimensions; zero for audio.
+	Width      int
+	Height     int
+	VideoCodec string
+	AudioCodec string
+	// Poster is a PNG frame from early in the video; nil for audio.
+	Poster []byte
+}
+
+// HasVideo reports whether the file has a video stream.
+func (i *AVInfo) HasVideo() bool {
+	return i.VideoCodec != ""
+}
+
+// AVProber reads the streams of video and audio files and renders posters.
+type AVProber interface {
+	Probe(ctx context.Context, r io.Reader) (*AVInfo, error)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrUnsupportedImage is returned by ImageProcessor for input it cannot
+// decode, such as documents or video.
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// ImageFit describes how an image is resized into a variant's box.
+type ImageFit string
+
+const (
+	// FitLimit scales down to fit within the box, keeping the aspect ratio.
+	// A zero Height leaves the height unconstrained.
+	FitLimit ImageFit = "limit"
+	// FitFill scales and crops to cover the box exactly.
+	FitFill ImageFit = "fill"
+)
+
+type ImageVariant struct {
+	Name   string
+	Width  int
+	Height int
+	Fit    ImageFit
+}
+
+// EncodedImage is a rendered variant ready to be stored.
+type EncodedImage struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	// Extension is the file extension without a dot, e.g. "jpg".
+	Extension string
+	Data      []byte
+}
+
+type ProcessedImage struct {
+	Width  int
+	Height int
+	// DominantColor is a "#rrggbb" hex colour.
+	DominantColor string
+	// BlurHash is a compact placeholder, see https://blurha.sh.
+	BlurHash string
+	Variants []EncodedImage
+}
+
+// ImageProcessor decodes an image and renders the requested variants, so
+// every storage driver serves the same renditions.
+type ImageProcessor interface {
+	Process(ctx context.Context, r io.Reader, variants []ImageVariant) (*ProcessedImage, error)
+}
+
+// PhotoMetadata holds the EXIF fields worth keeping from a photo. Fields the
+// file does not carry are left empty.
+type PhotoMetadata struct {
+	CameraMake   string     `json:"camera_make,omitempty"`
+	CameraModel  string     `json:"camera_model,omitempty"`
+	Lens         string     `json:"lens,omitempty"`
+	ExposureTime string     `json:"exposure_time,omitempty"`
+	FNumber      float64    `json:"f_number,omitempty"`
+	ISO          int        `json:"iso,omitempty"`
+	FocalLength  float64    `json:"focal_length,omitempty"`
+	TakenAt      *time.Time `json:"taken_at,omitempty"`
+	Latitude     *float64   `json:"latitude,omitempty"`
+	Longitude    *float64   `json:"longitude,omitempty"`
+}
+
+// HasLocation reports whether the photo carries GPS coordinates.
+func (m *PhotoMetadata) HasLocation() bool {
+	return m.Latitude != nil && m.Longitude != nil
+}
+
+// ExifService reads and scrubs photo metadata on upload.
+type ExifService interface {
+	// Extract returns nil when the file carries no EXIF. It reads only as
+	// far as the metadata, without buffering image data.
+	Extract(r io.Reader) (*PhotoMetadata, error)
+	// StripLocation copies src to dst without embedded GPS coordinates. Files
+	// it cannot rewrite are copied unchanged.
+	StripLocation(dst io.Writer, src io.Reader) error
+}
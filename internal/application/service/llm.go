@@ -4,6 +4,16 @@ import (
 	"context"
 )
 
+// TokenHandler receives streamed tokens in order. Returning an error stops the stream.
+type TokenHandler func(token string) error
+
+// ChatPrompt is a rendered prompt. System may be empty.
+type ChatPrompt struct {
+	System string
+	User   string
+}
+
 type LLMService interface {
-	GenerateChatResponse(ctx context.Context, prompt string) (string, error)
+	GenerateChatResponse(ctx context.Context, prompt ChatPrompt) (string, error)
+	StreamChatResponse(ctx context.Context, prompt ChatPrompt, onToken TokenHandler) (string, error)
 }
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+// Storage is a blob store addressed by slash-separated keys such as
+// "users/<owner>/media/originals/<id>". Keys are chosen by use cases and are
+// what gets persisted; URLs are derived from them by the driver.
+//
+// Keys under PrivatePrefix are private: drivers store them so that nothing is
+// served at their URL, and SignedURL is the only way to reach them.
+type Storage interface {
+	// Put stores body under key, replacing any existing object, and returns
+	// the URL the object is served from.
+	Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error)
+	// Open returns the object's content. A missing object is an
+	// apperror.ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]StoredObject, error)
+	// URL returns the URL the object is served from. contentType is the one
+	// it was stored with, which some drivers need to address it.
+	URL(key, contentType string) string
+	// SignedURL returns a URL that serves the object only for a limited
+	// time, for objects that must not be reachable at their permanent URL.
+	// contentType is as for URL.
+	SignedURL(ctx context.Context, key, contentType string) (string, error)
+	// Driver names the backend, recorded as the provider of stored media.
+	Driver() string
+}
+
+// PrivatePrefix starts the keys of private objects.
+const PrivatePrefix = "private/"
+
+// IsPrivateKey reports whether key names a private object.
+func IsPrivateKey(key string) bool {
+	return strings.HasPrefix(key, PrivatePrefix)
+}
+
+// KeyWithVisibility returns key under PrivatePrefix when private is set, and
+// outside it otherwise.
+func KeyWithVisibility(key string, private bool) string {
+	key = strings.TrimPrefix(key, PrivatePrefix)
+	if private {
+		return PrivatePrefix + key
+	}
+	return key
+}
+
+// StoredObject describes an object returned by Storage.List.
+type StoredObject struct {
+	Key        string
+	Size       int64
+	ModifiedAt time.Time
+}
@@ -0,0 +1,149 @@
+package album
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	mediaUC "github.com/khoahotran/personal-os/internal/application/usecase/media"
+	"github.com/khoahotran/personal-os/internal/domain/album"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type AlbumUseCase struct {
+	repo    album.Repository
+	storage service.Storage
+	logger  logger.Logger
+}
+
+func NewAlbumUseCase(r album.Repository, s service.Storage, log logger.Logger) *AlbumUseCase {
+	return &AlbumUseCase{repo: r, storage: s, logger: log}
+}
+
+type CreateAlbumInput struct {
+	OwnerID      uuid.UUID
+	Title        string
+	Slug         string
+	Description  string
+	CoverMediaID *uuid.UUID
+	MediaIDs     []uuid.UUID
+	IsPublic     bool
+}
+
+func (uc *AlbumUseCase) CreateAlbum(ctx context.Context, in CreateAlbumInput) (*album.Album, error) {
+	if in.Slug == "" {
+		in.Slug = strings.ToLower(strings.ReplaceAll(in.Title, " ", "-"))
+	}
+	now := time.Now().UTC()
+	a := &album.Album{
+		ID:           uuid.New(),
+		OwnerID:      in.OwnerID,
+		Slug:         in.Slug,
+		Title:        in.Title,
+		Description:  in.Description,
+		CoverMediaID: in.CoverMediaID,
+		MediaIDs:     in.MediaIDs,
+		IsPublic:     in.IsPublic,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := a.Validate(); err != nil {
+		return nil, apperror.NewInvalidInput("album validation failed", err)
+	}
+	if err := uc.repo.Save(ctx, a); err != nil {
+		return nil, err
+	}
+	return uc.repo.FindByID(ctx, a.ID, a.OwnerID)
+}
+
+type UpdateAlbumInput struct {
+	AlbumID      uuid.UUID
+	OwnerID      uuid.UUID
+	Title        string
+	Slug         string
+	Description  string
+	CoverMediaID *uuid.UUID
+	MediaIDs     []uuid.UUID
+	IsPublic     bool
+}
+
+func (uc *AlbumUseCase) UpdateAlbum(ctx context.Context, in UpdateAlbumInput) (*album.Album, error) {
+	a, err := uc.repo.FindByID(ctx, in.AlbumID, in.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Title = in.Title
+	a.Slug = in.Slug
+	a.Description = in.Description
+	a.CoverMediaID = in.CoverMediaID
+	a.MediaIDs = in.MediaIDs
+	a.IsPublic = in.IsPublic
+
+	if err := a.Validate(); err != nil {
+		return nil, apperror.NewInvalidInput("album validation failed", err)
+	}
+	if err := uc.repo.Update(ctx, a); err != nil {
+		return nil, err
+	}
+	return uc.repo.FindByID(ctx, a.ID, a.OwnerID)
+}
+
+func (uc *AlbumUseCase) DeleteAlbum(ctx context.Context, id, ownerID uuid.UUID) error {
+	return uc.repo.Delete(ctx, id, ownerID)
+}
+
+// GetAlbum returns the album with all of its media, public or not. Private
+// media gets short-lived URLs.
+func (uc *AlbumUseCase) GetAlbum(ctx context.Context, id, ownerID uuid.UUID) (*album.Album, error) {
+	a, err := uc.repo.FindByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if a.Items, err = uc.repo.ListMedia(ctx, a.ID, false); err != nil {
+		return nil, err
+	}
+	for _, m := range a.Items {
+		if err := mediaUC.SignPrivate(ctx, uc.storage, m); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// GetPublicAlbum returns a public album with only its public, processed media.
+func (uc *AlbumUseCase) GetPublicAlbum(ctx context.Context, slug string) (*album.Album, error) {
+	a, err := uc.repo.FindPublicBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if a.Items, err = uc.repo.ListMedia(ctx, a.ID, true); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (uc *AlbumUseCase) ListAlbums(ctx context.Context, ownerID uuid.UUID, page, limit int) ([]*album.Album, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	return uc.repo.ListByOwner(ctx, ownerID, limit, offset)
+}
+
+func (uc *AlbumUseCase) ListPublicAlbums(ctx context.Context, page, limit int) ([]*album.Album, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	return uc.repo.ListPublic(ctx, limit, offset)
+}
@@ -14,16 +14,16 @@ import (
 )
 
 type BackupUseCase struct {
-	cfg      config.Config
-	uploader service.Uploader
-	logger   logger.Logger
+	cfg     config.Config
+	storage service.Storage
+	logger  logger.Logger
 }
 
-func NewBackupUseCase(cfg config.Config, uploader service.Uploader, log logger.Logger) *BackupUseCase {
+func NewBackupUseCase(cfg config.Config, storage service.Storage, log logger.Logger) *BackupUseCase {
 	return &BackupUseCase{
-		cfg:      cfg,
-		uploader: uploader,
-		logger:   log,
+		cfg:     cfg,
+		storage: storage,
+		logger:  log,
 	}
 }
 
@@ -46,14 +46,13 @@ func (uc *BackupUseCase) Execute(ctx context.Context) {
 
 	timestamp := time.Now().UTC().Format("2006-01-02_15-04-05")
 	filename := fmt.Sprintf("backup-%s.dump", timestamp)
-	folder := "backups/database"
-	publicID := fmt.Sprintf("%s/%s", folder, filename)
+	publicID := fmt.Sprintf("backups/database/%s", filename)
 
 	fileReader := bytes.NewReader(out.Bytes())
 
-	uploadURL, err := uc.uploader.Upload(ctx, fileReader, folder, publicID)
+	uploadURL, err := uc.storage.Put(ctx, publicID, fileReader, "application/octet-stream")
 	if err != nil {
-		uc.logger.Error("Failed to upload backup to Cloudinary", err)
+		uc.logger.Error("Failed to upload backup to storage", err)
 		return
 	}
 
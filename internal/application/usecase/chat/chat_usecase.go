@@ -8,50 +8,152 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/khoahotran/personal-os/internal/application/service"
-	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/text"
 	"go.uber.org/zap"
 )
 
+const defaultContextTokenBudget = 2000
+
+type ChatConfig struct {
+	// ContextTokenBudget caps the estimated tokens spent on retrieved sources.
+	ContextTokenBudget int
+}
+
 type ChatUseCase struct {
-	embedder service.EmbeddingService
-	llm      service.LLMService
-	postRepo post.Repository
-	logger   logger.Logger
+	embedder      service.EmbeddingService
+	llm           service.LLMService
+	knowledgeRepo knowledge.Repository
+	promptRepo    prompt.Repository
+	cfg           ChatConfig
+	logger        logger.Logger
 }
 
 func NewChatUseCase(
 	em service.EmbeddingService,
 	llm service.LLMService,
-	pr post.Repository,
+	kr knowledge.Repository,
+	pr prompt.Repository,
+	cfg ChatConfig,
 	log logger.Logger,
 ) *ChatUseCase {
+	if cfg.ContextTokenBudget <= 0 {
+		cfg.ContextTokenBudget = defaultContextTokenBudget
+	}
 	return &ChatUseCase{
-		embedder: em,
-		llm:      llm,
-		postRepo: pr,
-		logger:   log,
+		embedder:      em,
+		llm:           llm,
+		knowledgeRepo: kr,
+		promptRepo:    pr,
+		cfg:           cfg,
+		logger:        log,
 	}
 }
 
+// ChatTurn is a prior message in a conversation, oldest first.
+type ChatTurn struct {
+	Role    string
+	Content string
+}
+
 type ChatInput struct {
 	Query   string
 	OwnerID uuid.UUID
 	Limit   int
+	History []ChatTurn
 }
 
 type ChatOutput struct {
-	Response string       `json:"response"`
-	Sources  []*post.Post `json:"sources"`
+	Response string `json:"response"`
+	// Sources are the sources given to the LLM; reference [n] is Sources[n-1].
+	Sources   []*knowledge.Source `json:"sources"`
+	Citations []Citation          `json:"citations"`
 }
 
 func (uc *ChatUseCase) Execute(ctx context.Context, input ChatInput) (*ChatOutput, error) {
 	l := uc.logger.With(zap.String("query", input.Query))
 	l.Info("ChatUseCase received query")
 
+	sources, err := uc.retrieve(ctx, input, l)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, chatPrompt, err := uc.buildPrompt(ctx, input.Query, sources, input.History)
+	if err != nil {
+		return nil, err
+	}
+	l.Info("Prompt built for LLM", zap.Int("packed_sources", len(sources)))
+
+	l.Info("Generating response from LLM...")
+	response, err := uc.llm.GenerateChatResponse(ctx, chatPrompt)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to generate LLM response", err)
+	}
+	l.Info("LLM response generated")
+
+	return &ChatOutput{
+		Response:  response,
+		Sources:   sources,
+		Citations: extractCitations(response, sources),
+	}, nil
+}
+
+type ChatStreamCallbacks struct {
+	OnSources func(sources []*knowledge.Source) error
+	OnToken   service.TokenHandler
+}
+
+// ExecuteStream behaves like Execute but reports sources as soon as they are
+// retrieved and forwards LLM tokens while they are generated.
+func (uc *ChatUseCase) ExecuteStream(ctx context.Context, input ChatInput, cb ChatStreamCallbacks) (*ChatOutput, error) {
+	l := uc.logger.With(zap.String("query", input.Query))
+	l.Info("ChatUseCase received streaming query")
+
+	sources, err := uc.retrieve(ctx, input, l)
+	if err != nil {
+		return nil, err
+	}
+	sources, chatPrompt, err := uc.buildPrompt(ctx, input.Query, sources, input.History)
+	if err != nil {
+		return nil, err
+	}
+	if err := cb.OnSources(sources); err != nil {
+		return nil, err
+	}
+
+	l.Info("Streaming response from LLM...")
+	response, err := uc.llm.StreamChatResponse(ctx, chatPrompt, cb.OnToken)
+	if err != nil {
+		if ctx.Err() != nil {
+			l.Info("Chat stream cancelled by client")
+			return nil, ctx.Err()
+		}
+		return nil, apperror.NewInternal("failed to stream LLM response", err)
+	}
+	l.Info("LLM response streamed")
+
+	return &ChatOutput{
+		Response:  response,
+		Sources:   sources,
+		Citations: extractCitations(response, sources),
+	}, nil
+}
+
+// Retrieve runs query rewriting and vector search only, without calling the
+// LLM for an answer. It is used by the evaluation tooling.
+func (uc *ChatUseCase) Retrieve(ctx context.Context, input ChatInput) ([]*knowledge.Source, error) {
+	return uc.retrieve(ctx, input, uc.logger.With(zap.String("query", input.Query)))
+}
+
+func (uc *ChatUseCase) retrieve(ctx context.Context, input ChatInput, l logger.Logger) ([]*knowledge.Source, error) {
+	retrievalQuery := uc.rewriteQuery(ctx, input.Query, input.History, l)
+
 	l.Info("Generating embedding for query...")
-	queryVector, err := uc.embedder.GenerateEmbeddings(ctx, input.Query)
+	queryVector, err := uc.embedder.GenerateEmbeddings(ctx, retrievalQuery)
 	if err != nil {
 		l.Error("Failed to generate query embedding", err)
 		return nil, apperror.NewInternal("failed to process query embedding", err)
@@ -62,44 +164,82 @@ func (uc *ChatUseCase) Execute(ctx context.Context, input ChatInput) (*ChatOutpu
 		input.Limit = 3
 	}
 
-	sources, err := uc.postRepo.SearchByEmbedding(ctx, queryVector, input.OwnerID, input.Limit)
+	sources, err := uc.knowledgeRepo.SearchByEmbedding(ctx, queryVector, input.OwnerID, input.Limit)
 	if err != nil {
 		l.Error("Failed to search by embedding", err)
 		return nil, apperror.NewInternal("failed to retrieve relevant documents", err)
 	}
 	l.Info("Found relevant sources", zap.Int("count", len(sources)))
+	return sources, nil
+}
 
-	prompt := uc.buildPrompt(input.Query, sources)
-	l.Info("Prompt built for LLM")
+// rewriteQuery turns a follow-up question into a standalone one so retrieval
+// does not depend on pronouns resolved by earlier turns. It falls back to the
+// raw query when there is no history or the LLM call fails.
+func (uc *ChatUseCase) rewriteQuery(ctx context.Context, query string, history []ChatTurn, l logger.Logger) string {
+	if len(history) == 0 {
+		return query
+	}
 
-	l.Info("Generating response from LLM...")
-	response, err := uc.llm.GenerateChatResponse(ctx, prompt)
+	chatPrompt, err := renderPrompt(ctx, uc.promptRepo, prompt.NameChatRewrite, prompt.Variables{
+		Question: query,
+		History:  formatHistory(history, maxRewriteTurnChars),
+	})
 	if err != nil {
-		return nil, apperror.NewInternal("failed to generate LLM response", err)
+		l.Warn("Failed to render rewrite prompt, using original query", zap.Error(err))
+		return query
 	}
-	l.Info("LLM response generated")
 
-	return &ChatOutput{
-		Response: response,
-		Sources:  sources,
-	}, nil
+	rewritten, err := uc.llm.GenerateChatResponse(ctx, chatPrompt)
+	if err != nil {
+		l.Warn("Failed to rewrite query, using original", zap.Error(err))
+		return query
+	}
+	rewritten = strings.Trim(strings.TrimSpace(rewritten), "\"")
+	if rewritten == "" {
+		return query
+	}
+	l.Info("Query rewritten for retrieval", zap.String("rewritten_query", rewritten))
+	return rewritten
 }
 
-func (uc *ChatUseCase) buildPrompt(query string, sources []*post.Post) string {
-	var contextBuilder strings.Builder
-	contextBuilder.WriteString("Based on the following contexts:\n\n")
-	for i, s := range sources {
-		contextBuilder.WriteString(fmt.Sprintf("--- Context %d (Title: %s) ---\n", i+1, s.Title))
-		contextBuilder.WriteString(s.ContentMarkdown)
-		contextBuilder.WriteString("\n\n")
+const maxRewriteTurnChars = 500
+
+func formatHistory(history []ChatTurn, maxChars int) string {
+	var b strings.Builder
+	for _, t := range history {
+		content := t.Content
+		if maxChars > 0 {
+			content = text.Truncate(content, maxChars, "...")
+		}
+		b.WriteString(fmt.Sprintf("%s: %s\n", t.Role, content))
 	}
+	return b.String()
+}
 
-	var promptBuilder strings.Builder
-	promptBuilder.WriteString(contextBuilder.String())
-	promptBuilder.WriteString("--- Question ---\n")
-	promptBuilder.WriteString(query)
-	promptBuilder.WriteString("\n\n--- Answer ---\n")
-	promptBuilder.WriteString("Please answer the question above based only on the provided contexts:")
+// buildPrompt packs sources into the context budget and renders the chat
+// template. It returns the sources that made it into the prompt.
+func (uc *ChatUseCase) buildPrompt(ctx context.Context, query string, sources []*knowledge.Source, history []ChatTurn) ([]*knowledge.Source, service.ChatPrompt, error) {
+	packed, contextText := packContext(sources, uc.cfg.ContextTokenBudget)
+	chatPrompt, err := renderPrompt(ctx, uc.promptRepo, prompt.NameChat, prompt.Variables{
+		Question: query,
+		Context:  contextText,
+		History:  formatHistory(history, 0),
+	})
+	if err != nil {
+		return nil, service.ChatPrompt{}, err
+	}
+	return packed, chatPrompt, nil
+}
 
-	return promptBuilder.String()
+func renderPrompt(ctx context.Context, repo prompt.Repository, name string, vars prompt.Variables) (service.ChatPrompt, error) {
+	t, err := prompt.Resolve(ctx, repo, name)
+	if err != nil {
+		return service.ChatPrompt{}, apperror.NewInternal("failed to load prompt template "+name, err)
+	}
+	system, user, err := t.Render(vars)
+	if err != nil {
+		return service.ChatPrompt{}, apperror.NewInternal("failed to render prompt template "+name, err)
+	}
+	return service.ChatPrompt{System: system, User: user}, nil
 }
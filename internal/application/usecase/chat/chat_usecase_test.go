@@ -0,0 +1,164 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/adapters/embedding"
+	"github.com/khoahotran/personal-os/adapters/llm"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type stubKnowledgeRepo struct {
+	sources  []*knowledge.Source
+	err      error
+	gotOwner uuid.UUID
+	gotLimit int
+}
+
+func (r *stubKnowledgeRepo) SearchByEmbedding(ctx context.Context, emb pgvector.Vector, ownerID uuid.UUID, limit int) ([]*knowledge.Source, error) {
+	r.gotOwner, r.gotLimit = ownerID, limit
+	return r.sources, r.err
+}
+
+func (r *stubKnowledgeRepo) SearchPublicByEmbedding(ctx context.Context, emb pgvector.Vector, ownerID uuid.UUID, limit int) ([]*knowledge.Source, error) {
+	return r.SearchByEmbedding(ctx, emb, ownerID, limit)
+}
+
+func (r *stubKnowledgeRepo) UpdateEmbedding(ctx context.Context, rt knowledge.ResourceType, id, ownerID uuid.UUID, emb pgvector.Vector) error {
+	return nil
+}
+
+// stubPromptRepo has no stored versions, so the built-in templates are used.
+type stubPromptRepo struct{}
+
+func (stubPromptRepo) FindActive(ctx context.Context, name string) (*prompt.Template, error) {
+	return nil, prompt.ErrTemplateNotFound
+}
+
+func (stubPromptRepo) ListVersions(ctx context.Context, name string) ([]*prompt.Template, error) {
+	return nil, nil
+}
+
+func (stubPromptRepo) CreateVersion(ctx context.Context, t *prompt.Template) error { return nil }
+
+func (stubPromptRepo) Activate(ctx context.Context, name string, version int) error { return nil }
+
+func newTestChatUseCase() (*ChatUseCase, *embedding.FakeEmbedder, *llm.FakeLLM, *stubKnowledgeRepo) {
+	em := embedding.NewFakeEmbedder(8)
+	fakeLLM := llm.NewFakeLLM()
+	repo := &stubKnowledgeRepo{sources: []*knowledge.Source{
+		{ResourceID: uuid.New(), ResourceType: knowledge.ResourcePost, Slug: "go-generics", Title: "Go generics", Content: "Type parameters in Go."},
+	}}
+	uc := NewChatUseCase(em, fakeLLM, repo, stubPromptRepo{}, ChatConfig{}, logger.NewZapLogger("development"))
+	return uc, em, fakeLLM, repo
+}
+
+func TestChatUseCase_Execute(t *testing.T) {
+	uc, em, fakeLLM, repo := newTestChatUseCase()
+	fakeLLM.Respond("Generics arrived in Go 1.18 [1]. See also [7].")
+	ownerID := uuid.New()
+
+	out, err := uc.Execute(context.Background(), ChatInput{Query: "When did Go get generics?", OwnerID: ownerID})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Generics arrived in Go 1.18 [1]. See also [7].", out.Response)
+	assert.Len(t, out.Sources, 1)
+	require.Len(t, out.Citations, 1, "out-of-range references are ignored")
+	assert.Equal(t, 1, out.Citations[0].Ref)
+	assert.Same(t, out.Sources[0], out.Citations[0].Source)
+	assert.Equal(t, ownerID, repo.gotOwner)
+	assert.Equal(t, 3, repo.gotLimit, "limit defaults to 3")
+	assert.Equal(t, []string{"When did Go get generics?"}, em.Inputs())
+
+	prompts := fakeLLM.Prompts()
+	require.Len(t, prompts, 1)
+	assert.Contains(t, prompts[0].System, "square brackets")
+	assert.Contains(t, prompts[0].User, "[1] post: Go generics\nType parameters in Go.")
+	assert.Contains(t, prompts[0].User, "When did Go get generics?")
+}
+
+func TestChatUseCase_Execute_RewritesFollowUpWithHistory(t *testing.T) {
+	uc, em, fakeLLM, _ := newTestChatUseCase()
+	fakeLLM.Respond("What are Go generics used for?", "For reusable containers.")
+
+	out, err := uc.Execute(context.Background(), ChatInput{
+		Query:   "What are they used for?",
+		OwnerID: uuid.New(),
+		History: []ChatTurn{
+			{Role: "user", Content: "Tell me about Go generics"},
+			{Role: "assistant", Content: "They are type parameters."},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "For reusable containers.", out.Response)
+	assert.Equal(t, []string{"What are Go generics used for?"}, em.Inputs(), "retrieval uses the rewritten query")
+
+	prompts := fakeLLM.Prompts()
+	require.Len(t, prompts, 2)
+	assert.Contains(t, prompts[0].User, "assistant: They are type parameters.")
+	assert.Contains(t, prompts[1].User, "--- Conversation so far ---")
+	assert.Contains(t, prompts[1].User, "What are they used for?")
+}
+
+func TestChatUseCase_Execute_EmbedderFailure(t *testing.T) {
+	uc, em, fakeLLM, _ := newTestChatUseCase()
+	em.FailWith(errors.New("embedder down"))
+
+	_, err := uc.Execute(context.Background(), ChatInput{Query: "anything", OwnerID: uuid.New()})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperror.ErrInternal))
+	assert.Empty(t, fakeLLM.Prompts(), "LLM is not called without retrieval")
+}
+
+func TestChatUseCase_ExecuteStream(t *testing.T) {
+	uc, _, fakeLLM, _ := newTestChatUseCase()
+	fakeLLM.Respond("one two three")
+
+	var gotSources []*knowledge.Source
+	var tokens []string
+	out, err := uc.ExecuteStream(context.Background(), ChatInput{Query: "count", OwnerID: uuid.New()}, ChatStreamCallbacks{
+		OnSources: func(s []*knowledge.Source) error {
+			gotSources = s
+			return nil
+		},
+		OnToken: func(token string) error {
+			tokens = append(tokens, token)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, gotSources, 1)
+	assert.Equal(t, "one two three", strings.Join(tokens, ""))
+	assert.Equal(t, "one two three", out.Response)
+}
+
+func TestPackContext_RespectsTokenBudget(t *testing.T) {
+	sources := []*knowledge.Source{
+		{ResourceType: knowledge.ResourcePost, Title: "A", Content: strings.Repeat("a", 400)},
+		{ResourceType: knowledge.ResourcePost, Title: "B", Content: strings.Repeat("b", 800)},
+		{ResourceType: knowledge.ResourcePost, Title: "C", Content: strings.Repeat("c", 400)},
+	}
+
+	packed, text := packContext(sources, 200)
+	require.Len(t, packed, 2, "the second source is truncated and the third dropped")
+	assert.Contains(t, text, "[1] post: A\n")
+	assert.Contains(t, text, "[2] post: B\n")
+	assert.NotContains(t, text, "[3]")
+	assert.LessOrEqual(t, approxTokens(text), 210)
+
+	packed, _ = packContext(sources, 0)
+	assert.Len(t, packed, 3, "zero budget disables the limit")
+}
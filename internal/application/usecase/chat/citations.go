@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
+)
+
+// Citation maps a numbered reference such as [2] in an answer back to the
+// source it points at.
+type Citation struct {
+	Ref    int
+	Source *knowledge.Source
+}
+
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// extractCitations returns the references used in response in order of first
+// appearance. Numbers outside the source list are ignored.
+func extractCitations(response string, sources []*knowledge.Source) []Citation {
+	citations := make([]Citation, 0)
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(response, -1) {
+		ref, err := strconv.Atoi(m[1])
+		if err != nil || ref < 1 || ref > len(sources) || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		citations = append(citations, Citation{Ref: ref, Source: sources[ref-1]})
+	}
+	return citations
+}
@@ -0,0 +1,60 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
+	"github.com/khoahotran/personal-os/pkg/text"
+)
+
+// charsPerToken is a rough, model-independent estimate used for budgeting.
+const charsPerToken = 4
+
+// minPartialTokens is the smallest slice of a source worth truncating into
+// the remaining budget; anything smaller is dropped instead.
+const minPartialTokens = 50
+
+func approxTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+// packContext formats sources as numbered references, most relevant first,
+// until tokenBudget is spent. The source that crosses the budget is truncated
+// if enough room is left, and the rest are dropped. The returned sources are
+// the ones included, so reference [n] is sources[n-1]. A budget of zero or
+// less disables the limit.
+func packContext(sources []*knowledge.Source, tokenBudget int) ([]*knowledge.Source, string) {
+	var b strings.Builder
+	packed := make([]*knowledge.Source, 0, len(sources))
+	remaining := tokenBudget
+
+	for _, s := range sources {
+		header := fmt.Sprintf("[%d] %s: %s\n", len(packed)+1, s.ResourceType, s.Title)
+		content := s.Content
+		truncated := false
+
+		if tokenBudget > 0 {
+			cost := approxTokens(header) + approxTokens(content)
+			if cost > remaining {
+				room := remaining - approxTokens(header)
+				if room < minPartialTokens {
+					break
+				}
+				content = text.Truncate(content, room*charsPerToken, "...")
+				truncated = true
+			}
+			remaining -= cost
+		}
+
+		b.WriteString(header)
+		b.WriteString(content)
+		b.WriteString("\n\n")
+		packed = append(packed, s)
+		if truncated {
+			break
+		}
+	}
+	return packed, b.String()
+}
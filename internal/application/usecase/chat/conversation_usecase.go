@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/domain/conversation"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// historyWindow bounds how many prior messages are fed back into the prompt.
+const historyWindow = 6
+
+const maxTitleRunes = 60
+
+type ConversationUseCase struct {
+	convRepo    conversation.Repository
+	chatUseCase *ChatUseCase
+	logger      logger.Logger
+}
+
+func NewConversationUseCase(cr conversation.Repository, chatUC *ChatUseCase, log logger.Logger) *ConversationUseCase {
+	return &ConversationUseCase{
+		convRepo:    cr,
+		chatUseCase: chatUC,
+		logger:      log,
+	}
+}
+
+type CreateConversationInput struct {
+	OwnerID uuid.UUID
+	Title   string
+}
+
+func (uc *ConversationUseCase) CreateConversation(ctx context.Context, in CreateConversationInput) (*conversation.Conversation, error) {
+	now := time.Now().UTC()
+	c := &conversation.Conversation{
+		ID:        uuid.New(),
+		OwnerID:   in.OwnerID,
+		Title:     strings.TrimSpace(in.Title),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := uc.convRepo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (uc *ConversationUseCase) ListConversations(ctx context.Context, ownerID uuid.UUID, page, limit int) ([]*conversation.Conversation, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+	return uc.convRepo.ListByOwner(ctx, ownerID, limit, offset)
+}
+
+type GetConversationOutput struct {
+	Conversation *conversation.Conversation
+	Messages     []*conversation.Message
+}
+
+func (uc *ConversationUseCase) GetConversation(ctx context.Context, id, ownerID uuid.UUID) (*GetConversationOutput, error) {
+	c, err := uc.convRepo.FindByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := uc.convRepo.ListMessages(ctx, c.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &GetConversationOutput{Conversation: c, Messages: messages}, nil
+}
+
+func (uc *ConversationUseCase) DeleteConversation(ctx context.Context, id, ownerID uuid.UUID) error {
+	return uc.convRepo.Delete(ctx, id, ownerID)
+}
+
+type SendMessageInput struct {
+	ConversationID uuid.UUID
+	OwnerID        uuid.UUID
+	Query          string
+	Limit          int
+}
+
+type SendMessageOutput struct {
+	UserMessage      *conversation.Message
+	AssistantMessage *conversation.Message
+}
+
+func (uc *ConversationUseCase) SendMessage(ctx context.Context, in SendMessageInput) (*SendMessageOutput, error) {
+	l := uc.logger.With(zap.String("conversation_id", in.ConversationID.String()))
+
+	c, err := uc.convRepo.FindByID(ctx, in.ConversationID, in.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	userMsg := &conversation.Message{
+		ID:             uuid.New(),
+		ConversationID: c.ID,
+		Role:           conversation.RoleUser,
+		Content:        strings.TrimSpace(in.Query),
+		Sources:        []conversation.SourceRef{},
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := userMsg.Validate(); err != nil {
+		return nil, apperror.NewInvalidInput("message validation failed", err)
+	}
+
+	previous, err := uc.convRepo.ListMessages(ctx, c.ID, historyWindow)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]ChatTurn, len(previous))
+	for i, m := range previous {
+		history[i] = ChatTurn{Role: string(m.Role), Content: m.Content}
+	}
+
+	output, err := uc.chatUseCase.Execute(ctx, ChatInput{
+		Query:   userMsg.Content,
+		OwnerID: in.OwnerID,
+		Limit:   in.Limit,
+		History: history,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cited := make(map[int]bool, len(output.Citations))
+	for _, c := range output.Citations {
+		cited[c.Ref] = true
+	}
+	// Sources keep their order so reference [n] in the answer is sources[n-1].
+	sources := make([]conversation.SourceRef, len(output.Sources))
+	for i, s := range output.Sources {
+		sources[i] = conversation.SourceRef{
+			ResourceID:   s.ResourceID,
+			ResourceType: string(s.ResourceType),
+			Slug:         s.Slug,
+			Title:        s.Title,
+			Cited:        cited[i+1],
+		}
+	}
+	assistantMsg := &conversation.Message{
+		ID:             uuid.New(),
+		ConversationID: c.ID,
+		Role:           conversation.RoleAssistant,
+		Content:        output.Response,
+		Sources:        sources,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	if err := uc.convRepo.AddMessage(ctx, userMsg); err != nil {
+		return nil, err
+	}
+	if err := uc.convRepo.AddMessage(ctx, assistantMsg); err != nil {
+		return nil, err
+	}
+
+	if c.Title == "" {
+		l.Info("Conversation has no title, deriving from first message")
+		if err := uc.convRepo.UpdateTitle(ctx, c.ID, c.OwnerID, deriveTitle(userMsg.Content)); err != nil {
+			l.Warn("Failed to set conversation title", zap.Error(err))
+		}
+	}
+
+	return &SendMessageOutput{UserMessage: userMsg, AssistantMessage: assistantMsg}, nil
+}
+
+func deriveTitle(content string) string {
+	runes := []rune(strings.Join(strings.Fields(content), " "))
+	if len(runes) > maxTitleRunes {
+		return string(runes[:maxTitleRunes]) + "..."
+	}
+	return string(runes)
+}
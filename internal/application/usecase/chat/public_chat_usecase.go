@@ -0,0 +1,166 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
+	"github.com/khoahotran/personal-os/internal/domain/profile"
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+const (
+	defaultPublicMaxQueryLength = 500
+	defaultPublicMaxDistance    = 0.5
+	defaultPublicMaxSources     = 3
+)
+
+const defaultPersona = "You are a friendly assistant on a personal blog. You answer visitors' questions about the author's posts and projects."
+
+// PublicRefusal is returned instead of an LLM answer when no public source is
+// close enough to the question.
+const PublicRefusal = "Sorry, I couldn't find anything on this site that answers that question."
+
+type PublicChatConfig struct {
+	OwnerID        uuid.UUID
+	MaxQueryLength int
+	// MaxDistance is the largest cosine distance a source may have to be used.
+	MaxDistance float64
+	MaxSources  int
+	// ContextTokenBudget caps the estimated tokens spent on retrieved sources.
+	ContextTokenBudget int
+}
+
+type PublicChatUseCase struct {
+	embedder      service.EmbeddingService
+	llm           service.LLMService
+	knowledgeRepo knowledge.Repository
+	profileRepo   profile.Repository
+	promptRepo    prompt.Repository
+	cfg           PublicChatConfig
+	logger        logger.Logger
+}
+
+func NewPublicChatUseCase(
+	em service.EmbeddingService,
+	llm service.LLMService,
+	kr knowledge.Repository,
+	pr profile.Repository,
+	tr prompt.Repository,
+	cfg PublicChatConfig,
+	log logger.Logger,
+) *PublicChatUseCase {
+	if cfg.MaxQueryLength <= 0 {
+		cfg.MaxQueryLength = defaultPublicMaxQueryLength
+	}
+	if cfg.MaxDistance <= 0 {
+		cfg.MaxDistance = defaultPublicMaxDistance
+	}
+	if cfg.MaxSources <= 0 {
+		cfg.MaxSources = defaultPublicMaxSources
+	}
+	if cfg.ContextTokenBudget <= 0 {
+		cfg.ContextTokenBudget = defaultContextTokenBudget
+	}
+	return &PublicChatUseCase{
+		embedder:      em,
+		llm:           llm,
+		knowledgeRepo: kr,
+		profileRepo:   pr,
+		promptRepo:    tr,
+		cfg:           cfg,
+		logger:        log,
+	}
+}
+
+type PublicChatInput struct {
+	Query string
+}
+
+type PublicChatOutput struct {
+	Response  string
+	Sources   []*knowledge.Source
+	Citations []Citation
+	// Refused is true when no source was similar enough and the LLM was not called.
+	Refused bool
+}
+
+func (uc *PublicChatUseCase) Execute(ctx context.Context, input PublicChatInput) (*PublicChatOutput, error) {
+	query := strings.TrimSpace(input.Query)
+	if query == "" {
+		return nil, apperror.NewInvalidInput("query is required", nil)
+	}
+	if utf8.RuneCountInString(query) > uc.cfg.MaxQueryLength {
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("query must be at most %d characters", uc.cfg.MaxQueryLength), nil)
+	}
+
+	l := uc.logger.With(zap.String("query", query))
+	l.Info("PublicChatUseCase received query")
+
+	queryVector, err := uc.embedder.GenerateEmbeddings(ctx, query)
+	if err != nil {
+		l.Error("Failed to generate query embedding", err)
+		return nil, apperror.NewInternal("failed to process query embedding", err)
+	}
+
+	candidates, err := uc.knowledgeRepo.SearchPublicByEmbedding(ctx, queryVector, uc.cfg.OwnerID, uc.cfg.MaxSources)
+	if err != nil {
+		l.Error("Failed to search public knowledge", err)
+		return nil, apperror.NewInternal("failed to retrieve relevant documents", err)
+	}
+
+	sources := make([]*knowledge.Source, 0, len(candidates))
+	for _, s := range candidates {
+		if s.Distance <= uc.cfg.MaxDistance {
+			sources = append(sources, s)
+		}
+	}
+	if len(sources) == 0 {
+		l.Info("No sufficiently similar public source, refusing", zap.Int("candidates", len(candidates)))
+		return &PublicChatOutput{Response: PublicRefusal, Sources: sources, Refused: true}, nil
+	}
+
+	sources, contextText := packContext(sources, uc.cfg.ContextTokenBudget)
+	chatPrompt, err := renderPrompt(ctx, uc.promptRepo, prompt.NamePublicChat, prompt.Variables{
+		Question: query,
+		Context:  contextText,
+		Persona:  uc.persona(ctx, l),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := uc.llm.GenerateChatResponse(ctx, chatPrompt)
+	if err != nil {
+		return nil, apperror.NewInternal("failed to generate LLM response", err)
+	}
+	l.Info("Public LLM response generated", zap.Int("sources", len(sources)))
+
+	return &PublicChatOutput{
+		Response:  response,
+		Sources:   sources,
+		Citations: extractCitations(response, sources),
+	}, nil
+}
+
+// persona returns the owner's configured chat persona, falling back to a
+// neutral default when the profile has none or cannot be loaded.
+func (uc *PublicChatUseCase) persona(ctx context.Context, l logger.Logger) string {
+	p, err := uc.profileRepo.GetByUserID(ctx, uc.cfg.OwnerID)
+	if err != nil {
+		l.Warn("Failed to load profile persona, using default", zap.Error(err))
+		return defaultPersona
+	}
+	if persona := strings.TrimSpace(p.ChatPersona); persona != "" {
+		return persona
+	}
+	return defaultPersona
+}
@@ -0,0 +1,79 @@
+package cleanup
+
+import (
+	"context"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/uploadsession"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// abandonedUploadsBatch caps how many sessions one run removes; the rest wait
+// for the next run.
+const abandonedUploadsBatch = 100
+
+// AbandonedUploadsUseCase removes resumable upload sessions that expired
+// before they were completed, along with the parts they received.
+type AbandonedUploadsUseCase struct {
+	repo    uploadsession.Repository
+	storage service.Storage
+	logger  logger.Logger
+}
+
+func NewAbandonedUploadsUseCase(r uploadsession.Repository, s service.Storage, log logger.Logger) *AbandonedUploadsUseCase {
+	return &AbandonedUploadsUseCase{repo: r, storage: s, logger: log}
+}
+
+type AbandonedUploadsReport struct {
+	Sessions     int
+	DeletedParts int
+	// Failed counts sessions kept because their parts could not all be
+	// deleted. They are retried on the next run.
+	Failed int
+}
+
+func (uc *AbandonedUploadsUseCase) Execute(ctx context.Context) (*AbandonedUploadsReport, error) {
+	sessions, err := uc.repo.ListExpired(ctx, time.Now(), abandonedUploadsBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AbandonedUploadsReport{}
+	for _, s := range sessions {
+		deleted, ok := uc.deleteParts(ctx, s)
+		report.DeletedParts += deleted
+		if !ok {
+			report.Failed++
+			continue
+		}
+		if err := uc.repo.Delete(ctx, s.ID); err != nil {
+			uc.logger.Warn("Failed to delete abandoned upload session", zap.String("session_id", s.ID.String()), zap.Error(err))
+			report.Failed++
+			continue
+		}
+		report.Sessions++
+	}
+	return report, nil
+}
+
+// deleteParts lists the session's folder rather than trusting its recorded
+// parts, which miss a part stored just before its request failed.
+func (uc *AbandonedUploadsUseCase) deleteParts(ctx context.Context, s *uploadsession.Session) (int, bool) {
+	objects, err := uc.storage.List(ctx, uploadsession.Folder(s.OwnerID, s.ID)+"/")
+	if err != nil {
+		uc.logger.Warn("Failed to list abandoned upload parts", zap.String("session_id", s.ID.String()), zap.Error(err))
+		return 0, false
+	}
+	deleted, ok := 0, true
+	for _, obj := range objects {
+		if err := uc.storage.Delete(ctx, obj.Key); err != nil {
+			uc.logger.Warn("Failed to delete abandoned upload part", zap.String("key", obj.Key), zap.Error(err))
+			ok = false
+			continue
+		}
+		deleted++
+	}
+	return deleted, ok
+}
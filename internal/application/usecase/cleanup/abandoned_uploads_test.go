@@ -0,0 +1,57 @@
+package cleanup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/domain/uploadsession"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSessionRepo struct {
+	uploadsession.Repository
+	sessions map[uuid.UUID]*uploadsession.Session
+}
+
+func (r *stubSessionRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]*uploadsession.Session, error) {
+	var out []*uploadsession.Session
+	for _, s := range r.sessions {
+		if s.ExpiresAt.Before(before) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubSessionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+func TestAbandonedUploadsUseCase_Execute(t *testing.T) {
+	ownerID := uuid.New()
+	expired := &uploadsession.Session{ID: uuid.New(), OwnerID: ownerID, Size: 10, PartSize: 4, Parts: []int{1}, ExpiresAt: time.Now().Add(-time.Minute)}
+	active := &uploadsession.Session{ID: uuid.New(), OwnerID: ownerID, Size: 10, PartSize: 4, Parts: []int{1}, ExpiresAt: time.Now().Add(time.Hour)}
+	repo := &stubSessionRepo{sessions: map[uuid.UUID]*uploadsession.Session{expired.ID: expired, active.ID: active}}
+	storage := testutil.NewFakeStorage()
+	storage.PutAt(expired.PartKey(1), []byte("part"), time.Now())
+	// Stored, but its request failed before the part was recorded.
+	storage.PutAt(expired.PartKey(2), []byte("part"), time.Now())
+	storage.PutAt(active.PartKey(1), []byte("part"), time.Now())
+	uc := NewAbandonedUploadsUseCase(repo, storage, logger.NewZapLogger("development"))
+
+	report, err := uc.Execute(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Sessions)
+	assert.Equal(t, 2, report.DeletedParts)
+	assert.Zero(t, report.Failed)
+	assert.NotContains(t, repo.sessions, expired.ID)
+	assert.Contains(t, repo.sessions, active.ID)
+	assert.Equal(t, []string{active.PartKey(1)}, storage.Keys())
+}
@@ -0,0 +1,144 @@
+package cleanup
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// userPrefixes hold every object written on behalf of a user, public or
+// private. Other prefixes, such as backups, are never collected.
+var userPrefixes = []string{"users/", service.PrivatePrefix + "users/"}
+
+// OrphanedAssetsUseCase reconciles stored objects with the media and posts
+// that refer to them. Objects nothing refers to are orphans; references to
+// objects that do not exist are dangling.
+type OrphanedAssetsUseCase struct {
+	mediaRepo media.Repository
+	postRepo  post.Repository
+	storage   service.Storage
+	logger    logger.Logger
+}
+
+func NewOrphanedAssetsUseCase(mr media.Repository, pr post.Repository, s service.Storage, log logger.Logger) *OrphanedAssetsUseCase {
+	return &OrphanedAssetsUseCase{mediaRepo: mr, postRepo: pr, storage: s, logger: log}
+}
+
+type OrphanedAssetsInput struct {
+	// GracePeriod spares orphans younger than this, such as an upload whose
+	// database row is still being written.
+	GracePeriod time.Duration
+	// DryRun reports what would be deleted without deleting it.
+	DryRun bool
+}
+
+type OrphanedAssetsReport struct {
+	DryRun  bool
+	Scanned int
+	// Orphans are unreferenced objects past the grace period, sorted by key.
+	Orphans []service.StoredObject
+	// InGracePeriod counts unreferenced objects left alone for now.
+	InGracePeriod int
+	// Dangling are referenced keys with no stored object, sorted.
+	Dangling []string
+	Deleted  int
+	Failed   int
+}
+
+func (uc *OrphanedAssetsUseCase) Execute(ctx context.Context, in OrphanedAssetsInput) (*OrphanedAssetsReport, error) {
+	keys, prefixes, err := uc.references(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var objects []service.StoredObject
+	for _, prefix := range userPrefixes {
+		listed, err := uc.storage.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, listed...)
+	}
+
+	report := &OrphanedAssetsReport{DryRun: in.DryRun, Scanned: len(objects)}
+	cutoff := time.Now().Add(-in.GracePeriod)
+	stored := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		stored[obj.Key] = true
+		if keys[obj.Key] || hasAnyPrefix(obj.Key, prefixes) {
+			continue
+		}
+		if obj.ModifiedAt.After(cutoff) {
+			report.InGracePeriod++
+			continue
+		}
+		report.Orphans = append(report.Orphans, obj)
+	}
+	for key := range keys {
+		if hasAnyPrefix(key, userPrefixes) && !stored[key] {
+			report.Dangling = append(report.Dangling, key)
+		}
+	}
+	sort.Slice(report.Orphans, func(i, j int) bool { return report.Orphans[i].Key < report.Orphans[j].Key })
+	sort.Strings(report.Dangling)
+
+	for _, key := range report.Dangling {
+		uc.logger.Warn("Dangling storage reference", zap.String("key", key))
+	}
+	for _, obj := range report.Orphans {
+		l := uc.logger.With(zap.String("key", obj.Key), zap.Int64("size", obj.Size), zap.Time("modified_at", obj.ModifiedAt))
+		if in.DryRun {
+			l.Info("Orphaned object found (dry run, not deleted)")
+			continue
+		}
+		if err := uc.storage.Delete(ctx, obj.Key); err != nil {
+			l.Error("Failed to delete orphaned object", err)
+			report.Failed++
+			continue
+		}
+		l.Info("Deleted orphaned object")
+		report.Deleted++
+	}
+	return report, nil
+}
+
+// references returns the keys the database points at, and the folders claimed
+// as a whole because their keys are derived rather than stored.
+func (uc *OrphanedAssetsUseCase) references(ctx context.Context) (map[string]bool, []string, error) {
+	mediaKeys, err := uc.mediaRepo.StorageKeys(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	postRefs, err := uc.postRepo.ListAssetRefs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make(map[string]bool, len(mediaKeys)+len(postRefs))
+	for _, k := range mediaKeys {
+		keys[k] = true
+	}
+	prefixes := make([]string, 0, len(postRefs))
+	for _, ref := range postRefs {
+		if ref.OriginalKey != "" {
+			keys[ref.OriginalKey] = true
+		}
+		prefixes = append(prefixes, post.VariantsFolder(ref.OwnerID, ref.PostID)+"/")
+	}
+	return keys, prefixes, nil
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
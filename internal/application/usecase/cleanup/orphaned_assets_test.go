@@ -0,0 +1,84 @@
+package cleanup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubMediaRepo struct {
+	media.Repository
+	keys []string
+}
+
+func (r *stubMediaRepo) StorageKeys(ctx context.Context) ([]string, error) {
+	return r.keys, nil
+}
+
+type stubPostRepo struct {
+	post.Repository
+	refs []post.AssetRef
+}
+
+func (r *stubPostRepo) ListAssetRefs(ctx context.Context) ([]post.AssetRef, error) {
+	return r.refs, nil
+}
+
+func TestOrphanedAssetsUseCase_Execute_DeletesOrphans(t *testing.T) {
+	ownerID, postID := uuid.New(), uuid.New()
+	old := time.Now().Add(-48 * time.Hour)
+	variants := post.VariantsFolder(ownerID, postID)
+
+	mediaRepo := &stubMediaRepo{keys: []string{
+		"users/a/media/originals/kept",
+		"users/a/media/variants/kept/thumbnail.webp",
+		"users/a/media/originals/missing",
+		"private/users/a/media/originals/kept",
+	}}
+	postRepo := &stubPostRepo{refs: []post.AssetRef{{PostID: postID, OwnerID: ownerID, OriginalKey: "users/a/posts/cover"}}}
+	storage := testutil.NewFakeStorage()
+	storage.PutAt("users/a/media/originals/kept", []byte("x"), old)
+	storage.PutAt("users/a/media/variants/kept/thumbnail.webp", []byte("x"), old)
+	storage.PutAt("users/a/posts/cover", []byte("x"), old)
+	storage.PutAt(variants+"/thumbnail.webp", []byte("x"), old)
+	storage.PutAt("users/a/media/originals/orphan", []byte("x"), old)
+	storage.PutAt("private/users/a/media/originals/kept", []byte("x"), old)
+	storage.PutAt("private/users/a/media/originals/orphan", []byte("x"), old)
+	storage.PutAt("users/a/media/originals/fresh", []byte("x"), time.Now())
+	storage.PutAt("backups/db.sql", []byte("x"), old)
+	uc := NewOrphanedAssetsUseCase(mediaRepo, postRepo, storage, logger.NewZapLogger("development"))
+
+	report, err := uc.Execute(context.Background(), OrphanedAssetsInput{GracePeriod: 24 * time.Hour})
+	require.NoError(t, err)
+
+	assert.Equal(t, 8, report.Scanned)
+	require.Len(t, report.Orphans, 2)
+	assert.Equal(t, "private/users/a/media/originals/orphan", report.Orphans[0].Key, "private objects are collected too")
+	assert.Equal(t, "users/a/media/originals/orphan", report.Orphans[1].Key)
+	assert.Equal(t, 1, report.InGracePeriod)
+	assert.Equal(t, []string{"users/a/media/originals/missing"}, report.Dangling)
+	assert.Equal(t, 2, report.Deleted)
+	assert.Equal(t, []string{"private/users/a/media/originals/orphan", "users/a/media/originals/orphan"}, storage.Deleted())
+}
+
+func TestOrphanedAssetsUseCase_Execute_DryRun(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	storage.PutAt("users/a/media/originals/orphan", []byte("x"), time.Now().Add(-48*time.Hour))
+	uc := NewOrphanedAssetsUseCase(&stubMediaRepo{}, &stubPostRepo{}, storage, logger.NewZapLogger("development"))
+
+	report, err := uc.Execute(context.Background(), OrphanedAssetsInput{GracePeriod: 24 * time.Hour, DryRun: true})
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	assert.Len(t, report.Orphans, 1)
+	assert.Zero(t, report.Deleted)
+	assert.Empty(t, storage.Deleted())
+}
@@ -5,18 +5,22 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/adapters/event"
 	"github.com/khoahotran/personal-os/internal/domain/hobby"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
+	"go.uber.org/zap"
 )
 
 type HobbyUseCase struct {
-	repo   hobby.Repository
-	logger logger.Logger
+	repo        hobby.Repository
+	kafkaClient *event.KafkaProducerClient
+	logger      logger.Logger
 }
 
-func NewHobbyUseCase(r hobby.Repository, log logger.Logger) *HobbyUseCase {
-	return &HobbyUseCase{repo: r, logger: log}
+func NewHobbyUseCase(r hobby.Repository, kClient *event.KafkaProducerClient, log logger.Logger) *HobbyUseCase {
+	return &HobbyUseCase{repo: r, kafkaClient: kClient, logger: log}
 }
 
 type CreateHobbyItemInput struct {
@@ -51,6 +55,7 @@ func (uc *HobbyUseCase) CreateHobbyItem(ctx context.Context, in CreateHobbyItemI
 	if err := uc.repo.Save(ctx, item); err != nil {
 		return nil, err
 	}
+	uc.publishUpserted(item)
 	return item, nil
 }
 
@@ -86,9 +91,24 @@ func (uc *HobbyUseCase) UpdateHobbyItem(ctx context.Context, in UpdateHobbyItemI
 	if err := uc.repo.Update(ctx, item); err != nil {
 		return nil, err
 	}
+	uc.publishUpserted(item)
 	return item, nil
 }
 
+func (uc *HobbyUseCase) publishUpserted(item *hobby.HobbyItem) {
+	go func() {
+		err := uc.kafkaClient.PublishKnowledgeEvent(context.Background(), event.KnowledgeEventPayload{
+			EventType:    event.KnowledgeEventTypeUpserted,
+			ResourceType: string(knowledge.ResourceHobby),
+			ResourceID:   item.ID,
+			OwnerID:      item.OwnerID,
+		})
+		if err != nil {
+			uc.logger.Error("Failed to publish Kafka hobby knowledge event", err, zap.String("item_id", item.ID.String()))
+		}
+	}()
+}
+
 func (uc *HobbyUseCase) DeleteHobbyItem(ctx context.Context, id, ownerID uuid.UUID) error {
 	return uc.repo.Delete(ctx, id, ownerID)
 }
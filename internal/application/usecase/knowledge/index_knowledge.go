@@ -0,0 +1,128 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/hobby"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
+	"github.com/khoahotran/personal-os/internal/domain/profile"
+	"github.com/khoahotran/personal-os/internal/domain/project"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type IndexKnowledgeUseCase struct {
+	knowledgeRepo knowledge.Repository
+	projectRepo   project.Repository
+	hobbyRepo     hobby.Repository
+	profileRepo   profile.Repository
+	embedder      service.EmbeddingService
+	logger        logger.Logger
+}
+
+func NewIndexKnowledgeUseCase(
+	kr knowledge.Repository,
+	pr project.Repository,
+	hr hobby.Repository,
+	prof profile.Repository,
+	em service.EmbeddingService,
+	log logger.Logger,
+) *IndexKnowledgeUseCase {
+	return &IndexKnowledgeUseCase{
+		knowledgeRepo: kr,
+		projectRepo:   pr,
+		hobbyRepo:     hr,
+		profileRepo:   prof,
+		embedder:      em,
+		logger:        log,
+	}
+}
+
+func (uc *IndexKnowledgeUseCase) Execute(ctx context.Context, payload event.KnowledgeEventPayload) error {
+	resourceType := knowledge.ResourceType(payload.ResourceType)
+	l := uc.logger.With(zap.String("resource_id", payload.ResourceID.String()), zap.String("resource_type", payload.ResourceType))
+	l.Info("Worker UseCase indexing knowledge resource")
+
+	text, err := uc.buildText(ctx, resourceType, payload)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) || errors.Is(err, hobby.ErrHobbyItemNotFound) {
+			l.Warn("Resource not found, skipping event")
+			return nil
+		}
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		l.Info("Resource has no indexable text, skipping")
+		return nil
+	}
+
+	embedding, err := uc.embedder.GenerateEmbeddings(ctx, text)
+	if err != nil {
+		return apperror.NewInternal("failed to generate embeddings", err)
+	}
+
+	if err := uc.knowledgeRepo.UpdateEmbedding(ctx, resourceType, payload.ResourceID, payload.OwnerID, embedding); err != nil {
+		return err
+	}
+
+	l.Info("Knowledge resource indexed successfully")
+	return nil
+}
+
+func (uc *IndexKnowledgeUseCase) buildText(ctx context.Context, resourceType knowledge.ResourceType, payload event.KnowledgeEventPayload) (string, error) {
+	switch resourceType {
+	case knowledge.ResourceProject:
+		p, err := uc.projectRepo.FindByID(ctx, payload.ResourceID, payload.OwnerID)
+		if err != nil {
+			return "", err
+		}
+		return ProjectText(p), nil
+	case knowledge.ResourceHobby:
+		hi, err := uc.hobbyRepo.FindByID(ctx, payload.ResourceID, payload.OwnerID)
+		if err != nil {
+			return "", err
+		}
+		return HobbyText(hi), nil
+	case knowledge.ResourceProfile:
+		p, err := uc.profileRepo.GetByUserID(ctx, payload.OwnerID)
+		if err != nil {
+			return "", err
+		}
+		return ProfileText(p), nil
+	default:
+		return "", apperror.NewInvalidInput(fmt.Sprintf("resource type '%s' is not indexed by this use case", resourceType), nil)
+	}
+}
+
+func ProjectText(p *project.Project) string {
+	parts := []string{p.Title, p.Description}
+	if len(p.Stack) > 0 {
+		parts = append(parts, "Stack: "+strings.Join(p.Stack, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func HobbyText(hi *hobby.HobbyItem) string {
+	parts := []string{
+		fmt.Sprintf("%s (%s)", hi.Title, hi.Category),
+		fmt.Sprintf("Status: %s", hi.Status),
+		fmt.Sprintf("Rating: %d/10", hi.Rating),
+		hi.Notes,
+	}
+	return strings.Join(parts, "\n")
+}
+
+func ProfileText(p *profile.Profile) string {
+	parts := []string{p.Bio}
+	for _, m := range p.CareerTimeline {
+		parts = append(parts, fmt.Sprintf("%s - %s - %s", m.Date.Format("2006-01"), m.Title, m.Description))
+	}
+	return strings.Join(parts, "\n")
+}
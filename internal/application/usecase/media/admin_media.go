@@ -0,0 +1,204 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type ListMediaUseCase struct {
+	mediaRepo media.Repository
+	tagRepo   tag.Repository
+	storage   service.Storage
+	logger    logger.Logger
+}
+
+func NewListMediaUseCase(r media.Repository, t tag.Repository, s service.Storage, log logger.Logger) *ListMediaUseCase {
+	return &ListMediaUseCase{mediaRepo: r, tagRepo: t, storage: s, logger: log}
+}
+
+type ListMediaInput struct {
+	OwnerID uuid.UUID
+	Filter  media.ListFilter
+	Page    int
+	Limit   int
+}
+type ListMediaOutput struct {
+	Medias []*media.Media
+	// Tags holds each media item's tags by media ID.
+	Tags map[uuid.UUID][]tag.Tag
+}
+
+func (uc *ListMediaUseCase) Execute(ctx context.Context, in ListMediaInput) (*ListMediaOutput, error) {
+	if in.Limit <= 0 || in.Limit > 100 {
+		in.Limit = 30
+	}
+	if in.Page <= 0 {
+		in.Page = 1
+	}
+	if in.Filter.Sort == "" {
+		in.Filter.Sort = media.SortCreatedAt
+	}
+	offset := (in.Page - 1) * in.Limit
+
+	medias, err := uc.mediaRepo.ListByOwner(ctx, in.OwnerID, in.Filter, in.Limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[uuid.UUID][]tag.Tag, len(medias))
+	for _, m := range medias {
+		if err := SignPrivate(ctx, uc.storage, m); err != nil {
+			return nil, err
+		}
+		t, err := uc.tagRepo.GetTagsForResource(ctx, m.ID, media.ResourceType)
+		if err != nil {
+			uc.logger.Warn("Failed to get tags for media", zap.String("media_id", m.ID.String()), zap.Error(err))
+			continue
+		}
+		tags[m.ID] = t
+	}
+	return &ListMediaOutput{Medias: medias, Tags: tags}, nil
+}
+
+// SignPrivate gives private media short-lived URLs, so that the owner can see
+// it without its permanent URLs being handed out.
+func SignPrivate(ctx context.Context, storage service.Storage, m *media.Media) error {
+	if m.IsPublic {
+		return nil
+	}
+	return m.SignURLs(func(key, contentType string) (string, error) { return storage.SignedURL(ctx, key, contentType) })
+}
+
+// BulkAction is an operation applied to each media item of a bulk request.
+type BulkAction string
+
+const (
+	BulkMakePublic  BulkAction = "make_public"
+	BulkMakePrivate BulkAction = "make_private"
+	// BulkTag adds tags, keeping the ones each item already has.
+	BulkTag    BulkAction = "tag"
+	BulkDelete BulkAction = "delete"
+)
+
+// MaxBulkItems caps the media a single bulk request may touch.
+const MaxBulkItems = 100
+
+type BulkMediaUseCase struct {
+	mediaRepo  media.Repository
+	tagRepo    tag.Repository
+	visibility *visibilityChanger
+	deleteUC   *DeleteMediaUseCase
+	logger     logger.Logger
+}
+
+func NewBulkMediaUseCase(r media.Repository, t tag.Repository, s service.Storage, d *DeleteMediaUseCase, log logger.Logger) *BulkMediaUseCase {
+	return &BulkMediaUseCase{
+		mediaRepo:  r,
+		tagRepo:    t,
+		visibility: &visibilityChanger{repo: r, storage: s, logger: log},
+		deleteUC:   d,
+		logger:     log,
+	}
+}
+
+type BulkMediaInput struct {
+	OwnerID  uuid.UUID
+	Action   BulkAction
+	MediaIDs []uuid.UUID
+	TagNames []string
+}
+
+// BulkResult is the outcome for one media item. Err is nil on success.
+type BulkResult struct {
+	MediaID uuid.UUID
+	Err     error
+}
+
+type BulkMediaOutput struct {
+	Results []BulkResult
+}
+
+// Execute applies the action to each item in turn. A failing item does not
+// stop the rest; its error is reported in its result.
+func (uc *BulkMediaUseCase) Execute(ctx context.Context, in BulkMediaInput) (*BulkMediaOutput, error) {
+	if len(in.MediaIDs) == 0 {
+		return nil, apperror.NewInvalidInput("media_ids must not be empty", nil)
+	}
+	if len(in.MediaIDs) > MaxBulkItems {
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("at most %d media can be changed at once", MaxBulkItems), nil)
+	}
+
+	var apply func(ctx context.Context, id uuid.UUID) error
+	switch in.Action {
+	case BulkMakePublic, BulkMakePrivate:
+		public := in.Action == BulkMakePublic
+		apply = func(ctx context.Context, id uuid.UUID) error { return uc.setPublic(ctx, in.OwnerID, id, public) }
+	case BulkTag:
+		if len(in.TagNames) == 0 {
+			return nil, apperror.NewInvalidInput("tags must not be empty for the tag action", nil)
+		}
+		tags, err := uc.tagRepo.FindOrCreateTags(ctx, in.TagNames)
+		if err != nil {
+			return nil, apperror.NewInternal("failed to process tags", err)
+		}
+		apply = func(ctx context.Context, id uuid.UUID) error { return uc.addTags(ctx, in.OwnerID, id, tags) }
+	case BulkDelete:
+		apply = func(ctx context.Context, id uuid.UUID) error {
+			return uc.deleteUC.Execute(ctx, DeleteMediaInput{OwnerID: in.OwnerID, MediaID: id})
+		}
+	default:
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("unknown bulk action %q", in.Action), nil)
+	}
+
+	results := make([]BulkResult, len(in.MediaIDs))
+	for i, id := range in.MediaIDs {
+		results[i] = BulkResult{MediaID: id, Err: apply(ctx, id)}
+		if results[i].Err != nil && !errors.Is(results[i].Err, apperror.ErrNotFound) {
+			uc.logger.Warn("Bulk media action failed for item", zap.String("action", string(in.Action)), zap.String("media_id", id.String()), zap.Error(results[i].Err))
+		}
+	}
+	return &BulkMediaOutput{Results: results}, nil
+}
+
+func (uc *BulkMediaUseCase) setPublic(ctx context.Context, ownerID, id uuid.UUID, public bool) error {
+	m, err := uc.mediaRepo.FindByID(ctx, id, ownerID)
+	if err != nil {
+		return err
+	}
+	if m.IsPublic == public {
+		return nil
+	}
+	return uc.visibility.setPublic(ctx, m, public)
+}
+
+func (uc *BulkMediaUseCase) addTags(ctx context.Context, ownerID, id uuid.UUID, tags []tag.Tag) error {
+	if _, err := uc.mediaRepo.FindByID(ctx, id, ownerID); err != nil {
+		return err
+	}
+	current, err := uc.tagRepo.GetTagsForResource(ctx, id, media.ResourceType)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(current)+len(tags))
+	tagIDs := make([]uuid.UUID, 0, len(current)+len(tags))
+	for _, t := range append(current, tags...) {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			tagIDs = append(tagIDs, t.ID)
+		}
+	}
+	if err := uc.tagRepo.SetTagsForResource(ctx, id, media.ResourceType, tagIDs); err != nil {
+		return apperror.NewInternal("failed to set media tags", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package media
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// seedMedia adds n media items of ownerID to repo and returns their IDs.
+func seedMedia(repo *stubMediaRepo, ownerID uuid.UUID, n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		m := &media.Media{ID: uuid.New(), OwnerID: ownerID, Metadata: map[string]any{}}
+		repo.medias[m.ID] = m
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func newBulkMediaUseCase(repo *stubMediaRepo, tagRepo *stubTagRepo) *BulkMediaUseCase {
+	log := logger.NewZapLogger("development")
+	storage := testutil.NewFakeStorage()
+	return NewBulkMediaUseCase(repo, tagRepo, storage, NewDeleteMediaUseCase(repo, tagRepo, storage, log), log)
+}
+
+func TestBulkMediaUseCase_Execute_MakePublicReportsEachItem(t *testing.T) {
+	ownerID := uuid.New()
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{}}
+	ids := seedMedia(repo, ownerID, 2)
+	uc := newBulkMediaUseCase(repo, &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}})
+
+	out, err := uc.Execute(context.Background(), BulkMediaInput{
+		OwnerID:  ownerID,
+		Action:   BulkMakePublic,
+		MediaIDs: []uuid.UUID{ids[0], uuid.New(), ids[1]},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, out.Results, 3)
+	assert.NoError(t, out.Results[0].Err)
+	assert.ErrorIs(t, out.Results[1].Err, apperror.ErrNotFound, "a missing item does not stop the rest")
+	assert.NoError(t, out.Results[2].Err)
+	assert.True(t, repo.medias[ids[0]].IsPublic)
+	assert.True(t, repo.medias[ids[1]].IsPublic)
+}
+
+func TestBulkMediaUseCase_Execute_TagKeepsExistingTags(t *testing.T) {
+	ownerID := uuid.New()
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{}}
+	ids := seedMedia(repo, ownerID, 1)
+	existing := uuid.New()
+	tagRepo := &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{ids[0]: {existing}}}
+	uc := newBulkMediaUseCase(repo, tagRepo)
+
+	out, err := uc.Execute(context.Background(), BulkMediaInput{
+		OwnerID:  ownerID,
+		Action:   BulkTag,
+		MediaIDs: ids,
+		TagNames: []string{"travel", "travel"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Results[0].Err)
+
+	assert.Equal(t, []uuid.UUID{existing, uuid.NewSHA1(uuid.Nil, []byte("travel"))}, tagRepo.set[ids[0]])
+}
+
+func TestBulkMediaUseCase_Execute_Delete(t *testing.T) {
+	ownerID := uuid.New()
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{}}
+	ids := seedMedia(repo, ownerID, 2)
+	uc := newBulkMediaUseCase(repo, &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}})
+
+	out, err := uc.Execute(context.Background(), BulkMediaInput{OwnerID: ownerID, Action: BulkDelete, MediaIDs: ids})
+	require.NoError(t, err)
+	for _, r := range out.Results {
+		assert.NoError(t, r.Err)
+	}
+	assert.Empty(t, repo.medias)
+}
+
+func TestBulkMediaUseCase_Execute_RejectsBadRequests(t *testing.T) {
+	ownerID := uuid.New()
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{}}
+	ids := seedMedia(repo, ownerID, 1)
+	uc := newBulkMediaUseCase(repo, &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}})
+	tooMany := make([]uuid.UUID, MaxBulkItems+1)
+
+	tests := []struct {
+		name  string
+		input BulkMediaInput
+	}{
+		{name: "unknown action", input: BulkMediaInput{Action: "archive", MediaIDs: ids}},
+		{name: "no media", input: BulkMediaInput{Action: BulkDelete}},
+		{name: "too many media", input: BulkMediaInput{Action: BulkDelete, MediaIDs: tooMany}},
+		{name: "tag without tags", input: BulkMediaInput{Action: BulkTag, MediaIDs: ids}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.OwnerID = ownerID
+			_, err := uc.Execute(context.Background(), tt.input)
+			assert.ErrorIs(t, err, apperror.ErrInvalidInput)
+		})
+	}
+	assert.Len(t, repo.medias, 1)
+}
+
+func TestListMediaUseCase_Execute_SignsPrivateMedia(t *testing.T) {
+	ownerID := uuid.New()
+	newMedia := func(public bool) *media.Media {
+		id := uuid.New()
+		thumb := media.Variant{Name: media.VariantThumbnail, Key: "users/o/media/variants/" + id.String() + "/thumbnail.webp"}
+		thumb.URL = "https://media.test/" + thumb.Key
+		return &media.Media{
+			ID:           id,
+			OwnerID:      ownerID,
+			IsPublic:     public,
+			URL:          "https://media.test/users/o/media/originals/" + id.String(),
+			ThumbnailURL: &thumb.URL,
+			Variants:     []media.Variant{thumb},
+			Metadata: map[string]any{
+				"original_public_id": "users/o/media/originals/" + id.String(),
+				"original_url":       "https://media.test/users/o/media/originals/" + id.String(),
+			},
+		}
+	}
+	public, private := newMedia(true), newMedia(false)
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{public.ID: public, private.ID: private}}
+	uc := NewListMediaUseCase(repo, &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}}, testutil.NewFakeStorage(), logger.NewZapLogger("development"))
+
+	_, err := uc.Execute(context.Background(), ListMediaInput{OwnerID: ownerID})
+	require.NoError(t, err)
+
+	assert.NotContains(t, public.URL, "signature")
+	assert.NotContains(t, *public.ThumbnailURL, "signature")
+
+	assert.Equal(t, "https://media.test/users/o/media/originals/"+private.ID.String()+"?signature=test", private.URL)
+	assert.Equal(t, private.URL, private.Metadata["original_url"])
+	assert.Equal(t, private.Variants[0].URL, *private.ThumbnailURL)
+	assert.Contains(t, *private.ThumbnailURL, "thumbnail.webp?signature=test")
+}
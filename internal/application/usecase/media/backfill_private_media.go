@@ -0,0 +1,58 @@
+package media
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// privateBackfillBatch is how many media are loaded at a time.
+const privateBackfillBatch = 100
+
+// BackfillPrivateMediaUseCase moves the files of private media stored before
+// private media was kept apart under service.PrivatePrefix, off any blob they
+// share with public media. Moved media are not found again, so running it
+// more than once is harmless.
+type BackfillPrivateMediaUseCase struct {
+	mediaRepo  media.Repository
+	visibility *visibilityChanger
+	logger     logger.Logger
+}
+
+func NewBackfillPrivateMediaUseCase(r media.Repository, s service.Storage, log logger.Logger) *BackfillPrivateMediaUseCase {
+	return &BackfillPrivateMediaUseCase{mediaRepo: r, visibility: &visibilityChanger{repo: r, storage: s, logger: log}, logger: log}
+}
+
+type BackfillPrivateMediaReport struct {
+	Moved int
+	// Failed counts media left where they were. They are retried on the
+	// next run.
+	Failed int
+}
+
+func (uc *BackfillPrivateMediaUseCase) Execute(ctx context.Context) (*BackfillPrivateMediaReport, error) {
+	report := &BackfillPrivateMediaReport{}
+	after := uuid.Nil
+	for {
+		medias, err := uc.mediaRepo.ListPrivateOutside(ctx, service.PrivatePrefix, after, privateBackfillBatch)
+		if err != nil {
+			return report, err
+		}
+		for _, m := range medias {
+			after = m.ID
+			if err := uc.visibility.setPublic(ctx, m, false); err != nil {
+				uc.logger.Warn("Failed to move private media files", zap.String("media_id", m.ID.String()), zap.Error(err))
+				report.Failed++
+				continue
+			}
+			report.Moved++
+		}
+		if len(medias) < privateBackfillBatch {
+			return report, nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package media
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+func TestBackfillPrivateMediaUseCase_Execute(t *testing.T) {
+	ownerID := uuid.New()
+	storage := testutil.NewFakeStorage()
+	// A blob shared by public and private media, as stored before private
+	// media was kept apart.
+	shared := &media.Blob{ID: uuid.New(), OwnerID: ownerID, SHA256: "abc", StorageKey: "users/o/media/originals/shared", RefCount: 2}
+	storage.PutAt(shared.StorageKey, []byte("png"), time.Now())
+	newMedia := func(public bool, blobID *uuid.UUID, key string) *media.Media {
+		return &media.Media{
+			ID:       uuid.New(),
+			OwnerID:  ownerID,
+			BlobID:   blobID,
+			IsPublic: public,
+			Status:   media.StatusReady,
+			URL:      storage.URL(key, "image/png"),
+			Metadata: map[string]any{
+				"original_public_id": key,
+				"original_url":       storage.URL(key, "image/png"),
+				"sha256":             "abc",
+				"content_type":       "image/png",
+			},
+		}
+	}
+	public := newMedia(true, &shared.ID, shared.StorageKey)
+	private := newMedia(false, &shared.ID, shared.StorageKey)
+	// Media uploaded before deduplication own their files.
+	legacy := newMedia(false, nil, "users/o/media/originals/legacy")
+	storage.PutAt(legacy.OriginalKey(), []byte("jpg"), time.Now())
+
+	repo := &stubMediaRepo{
+		medias: map[uuid.UUID]*media.Media{public.ID: public, private.ID: private, legacy.ID: legacy},
+		blobs:  map[uuid.UUID]*media.Blob{shared.ID: shared},
+	}
+	uc := NewBackfillPrivateMediaUseCase(repo, storage, logger.NewZapLogger("development"))
+
+	report, err := uc.Execute(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Moved)
+	assert.Zero(t, report.Failed)
+
+	private = repo.medias[private.ID]
+	assert.Equal(t, "private/users/o/media/originals/shared", private.OriginalKey())
+	assert.True(t, repo.blobs[*private.BlobID].IsPrivate, "the private media leaves the shared blob")
+	assert.Equal(t, 1, shared.RefCount)
+	assert.Equal(t, "private/users/o/media/originals/legacy", repo.medias[legacy.ID].OriginalKey())
+	assert.Equal(t, shared.StorageKey, repo.medias[public.ID].OriginalKey())
+	assert.Equal(t, []string{
+		"private/users/o/media/originals/legacy",
+		"private/users/o/media/originals/shared",
+		"users/o/media/originals/shared",
+	}, storage.Keys(), "the public media keeps its file")
+
+	report, err = uc.Execute(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, report.Moved, "moved media are not found again")
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/khoahotran/personal-os/internal/application/service"
 	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -19,7 +21,10 @@ func NewListPublicMediaUseCase(r media.Repository, log logger.Logger) *ListPubli
 	return &ListPublicMediaUseCase{mediaRepo: r, logger: log}
 }
 
-type ListPublicMediaInput struct{ Limit, Offset int }
+type ListPublicMediaInput struct {
+	Limit, Offset int
+	Sort          media.Sort
+}
 type ListPublicMediaOutput struct{ Medias []*media.Media }
 
 func (uc *ListPublicMediaUseCase) Execute(ctx context.Context, in ListPublicMediaInput) (*ListPublicMediaOutput, error) {
@@ -29,7 +34,10 @@ func (uc *ListPublicMediaUseCase) Execute(ctx context.Context, in ListPublicMedi
 	if in.Offset < 0 {
 		in.Offset = 0
 	}
-	medias, err := uc.mediaRepo.ListPublic(ctx, in.Limit, in.Offset)
+	if in.Sort == "" {
+		in.Sort = media.SortCreatedAt
+	}
+	medias, err := uc.mediaRepo.ListPublic(ctx, in.Sort, in.Limit, in.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -37,12 +45,13 @@ func (uc *ListPublicMediaUseCase) Execute(ctx context.Context, in ListPublicMedi
 }
 
 type UpdateMediaUseCase struct {
-	mediaRepo media.Repository
-	logger    logger.Logger
+	mediaRepo  media.Repository
+	visibility *visibilityChanger
+	logger     logger.Logger
 }
 
-func NewUpdateMediaUseCase(r media.Repository, log logger.Logger) *UpdateMediaUseCase {
-	return &UpdateMediaUseCase{mediaRepo: r, logger: log}
+func NewUpdateMediaUseCase(r media.Repository, s service.Storage, log logger.Logger) *UpdateMediaUseCase {
+	return &UpdateMediaUseCase{mediaRepo: r, visibility: &visibilityChanger{repo: r, storage: s, logger: log}, logger: log}
 }
 
 type UpdateMediaInput struct {
@@ -57,8 +66,10 @@ func (uc *UpdateMediaUseCase) Execute(ctx context.Context, in UpdateMediaInput)
 	if err != nil {
 		return err
 	}
-	m.Metadata = in.Metadata
-	m.IsPublic = in.IsPublic
+	m.Metadata = mergeSystemMetadata(in.Metadata, m.Metadata)
+	if m.IsPublic != in.IsPublic {
+		return uc.visibility.setPublic(ctx, m, in.IsPublic)
+	}
 
 	if err := uc.mediaRepo.Update(ctx, m); err != nil {
 		return err
@@ -66,14 +77,30 @@ func (uc *UpdateMediaUseCase) Execute(ctx context.Context, in UpdateMediaInput)
 	return nil
 }
 
+// systemMetadataKeys are written at upload and must survive metadata edits.
+var systemMetadataKeys = []string{"original_url", "original_public_id", "exif", "sha256", "size_bytes", "content_type"}
+
+func mergeSystemMetadata(updated, current map[string]any) map[string]any {
+	if updated == nil {
+		updated = make(map[string]any)
+	}
+	for _, k := range systemMetadataKeys {
+		if v, ok := current[k]; ok {
+			updated[k] = v
+		}
+	}
+	return updated
+}
+
 type DeleteMediaUseCase struct {
 	mediaRepo media.Repository
-	uploader  service.Uploader
+	tagRepo   tag.Repository
+	storage   service.Storage
 	logger    logger.Logger
 }
 
-func NewDeleteMediaUseCase(r media.Repository, u service.Uploader, log logger.Logger) *DeleteMediaUseCase {
-	return &DeleteMediaUseCase{mediaRepo: r, uploader: u, logger: log}
+func NewDeleteMediaUseCase(r media.Repository, t tag.Repository, s service.Storage, log logger.Logger) *DeleteMediaUseCase {
+	return &DeleteMediaUseCase{mediaRepo: r, tagRepo: t, storage: s, logger: log}
 }
 
 type DeleteMediaInput struct {
@@ -86,14 +113,46 @@ func (uc *DeleteMediaUseCase) Execute(ctx context.Context, in DeleteMediaInput)
 	if err != nil {
 		return err
 	}
+	if err := uc.tagRepo.SetTagsForResource(ctx, m.ID, media.ResourceType, []uuid.UUID{}); err != nil {
+		return apperror.NewInternal("failed to delete tag relations", err)
+	}
+
+	// The row goes first, so that a failure never leaves media pointing at
+	// deleted files.
+	orphaned, err := uc.mediaRepo.Delete(ctx, in.MediaID, in.OwnerID)
+	if err != nil {
+		return err
+	}
 
-	if publicID, ok := m.Metadata["original_public_id"].(string); ok {
-		if err := uc.uploader.Delete(ctx, publicID); err != nil {
-			uc.logger.Warn("Failed to delete media from Cloudinary, proceeding with DB delete", zap.String("public_id", publicID), zap.Error(err))
+	if m.BlobID == nil {
+		// Media uploaded before deduplication own their files outright.
+		publicID, ok := m.Metadata["original_public_id"].(string)
+		if !ok {
+			uc.logger.Warn("No 'original_public_id' found in metadata, cannot delete from storage", zap.String("media_id", m.ID.String()))
 		}
-	} else {
-		uc.logger.Warn("No 'original_public_id' found in metadata, cannot delete from Cloudinary", zap.String("media_id", m.ID.String()))
+		uc.deleteFiles(ctx, m.Variants, publicID)
+		return nil
+	}
+
+	// The shared files go only with the last reference to the blob.
+	if orphaned != nil {
+		uc.deleteFiles(ctx, m.Variants, orphaned.StorageKey)
 	}
+	return nil
+}
 
-	return uc.mediaRepo.Delete(ctx, in.MediaID, in.OwnerID)
+// deleteFiles removes variants and the original from storage, logging rather
+// than returning failures.
+func (uc *DeleteMediaUseCase) deleteFiles(ctx context.Context, variants []media.Variant, originalKey string) {
+	for _, v := range variants {
+		if err := uc.storage.Delete(ctx, v.Key); err != nil {
+			uc.logger.Warn("Failed to delete media variant from storage", zap.String("key", v.Key), zap.Error(err))
+		}
+	}
+	if originalKey == "" {
+		return
+	}
+	if err := uc.storage.Delete(ctx, originalKey); err != nil {
+		uc.logger.Warn("Failed to delete media from storage", zap.String("public_id", originalKey), zap.Error(err))
+	}
 }
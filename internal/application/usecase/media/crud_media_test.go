@@ -0,0 +1,221 @@
+package media
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// stubMediaRepo keeps media in memory and counts blob references the way the
+// Postgres repository does.
+type stubMediaRepo struct {
+	media.Repository
+	medias map[uuid.UUID]*media.Media
+	blobs  map[uuid.UUID]*media.Blob
+}
+
+func (r *stubMediaRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*media.Media, error) {
+	m, ok := r.medias[id]
+	if !ok || m.OwnerID != ownerID {
+		return nil, apperror.NewNotFound("media", id.String())
+	}
+	return m, nil
+}
+
+// Save creates blobs that have no ID yet, as the Postgres repository does.
+func (r *stubMediaRepo) Save(ctx context.Context, m *media.Media, blob *media.Blob) error {
+	if blob != nil {
+		if blob.ID == uuid.Nil {
+			blob.ID = uuid.New()
+			blob.OwnerID = m.OwnerID
+			r.blobs[blob.ID] = blob
+		}
+		r.blobs[blob.ID].RefCount++
+		m.BlobID = &blob.ID
+	}
+	r.medias[m.ID] = m
+	return nil
+}
+
+func (r *stubMediaRepo) FindBlob(ctx context.Context, ownerID uuid.UUID, sha256 string, private bool) (*media.Blob, error) {
+	for _, b := range r.blobs {
+		if b.OwnerID == ownerID && b.SHA256 == sha256 && b.IsPrivate == private {
+			return b, nil
+		}
+	}
+	return nil, apperror.NewNotFound("media blob", sha256)
+}
+
+func (r *stubMediaRepo) FindReadyByBlob(ctx context.Context, blobID uuid.UUID) (*media.Media, error) {
+	for _, m := range r.medias {
+		if m.BlobID != nil && *m.BlobID == blobID && m.Status == media.StatusReady {
+			return m, nil
+		}
+	}
+	return nil, apperror.NewNotFound("media", blobID.String())
+}
+
+func (r *stubMediaRepo) Update(ctx context.Context, m *media.Media) error {
+	r.medias[m.ID] = m
+	return nil
+}
+
+func (r *stubMediaRepo) UpdateProcessing(ctx context.Context, m *media.Media, originalKey string) error {
+	current, ok := r.medias[m.ID]
+	if !ok || current.OriginalKey() != originalKey {
+		return media.ErrOriginalMoved
+	}
+	r.medias[m.ID] = m
+	return nil
+}
+
+func (r *stubMediaRepo) ListByOwner(ctx context.Context, ownerID uuid.UUID, filter media.ListFilter, limit, offset int) ([]*media.Media, error) {
+	var medias []*media.Media
+	for _, m := range r.medias {
+		if m.OwnerID == ownerID {
+			medias = append(medias, m)
+		}
+	}
+	return medias, nil
+}
+
+func (r *stubMediaRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*media.Blob, error) {
+	m, err := r.FindByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	delete(r.medias, id)
+	return r.dropRef(m.BlobID), nil
+}
+
+func (r *stubMediaRepo) ListPrivateOutside(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]*media.Media, error) {
+	var medias []*media.Media
+	for _, m := range r.medias {
+		if !m.IsPublic && !strings.HasPrefix(m.OriginalKey(), prefix) && m.ID.String() > after.String() {
+			medias = append(medias, m)
+		}
+	}
+	slices.SortFunc(medias, func(a, b *media.Media) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+	return medias[:min(limit, len(medias))], nil
+}
+
+func (r *stubMediaRepo) MoveToBlob(ctx context.Context, m *media.Media, blob *media.Blob) (*media.Blob, error) {
+	old := r.medias[m.ID].BlobID
+	if err := r.Save(ctx, m, blob); err != nil {
+		return nil, err
+	}
+	return r.dropRef(old), nil
+}
+
+// dropRef releases a reference to the blob, returning it when that was the
+// last one.
+func (r *stubMediaRepo) dropRef(blobID *uuid.UUID) *media.Blob {
+	if blobID == nil {
+		return nil
+	}
+	b := r.blobs[*blobID]
+	b.RefCount--
+	if b.RefCount > 0 {
+		return nil
+	}
+	delete(r.blobs, b.ID)
+	return b
+}
+
+// stubTagRepo records the tags set on each resource. Tag IDs are derived from
+// their names.
+type stubTagRepo struct {
+	tag.Repository
+	set map[uuid.UUID][]uuid.UUID
+}
+
+func (r *stubTagRepo) FindOrCreateTags(ctx context.Context, names []string) ([]tag.Tag, error) {
+	tags := make([]tag.Tag, len(names))
+	for i, n := range names {
+		tags[i] = tag.Tag{ID: uuid.NewSHA1(uuid.Nil, []byte(n)), Name: n, Slug: n}
+	}
+	return tags, nil
+}
+
+func (r *stubTagRepo) GetTagsForResource(ctx context.Context, resourceID uuid.UUID, resourceType string) ([]tag.Tag, error) {
+	tags := make([]tag.Tag, len(r.set[resourceID]))
+	for i, id := range r.set[resourceID] {
+		tags[i] = tag.Tag{ID: id}
+	}
+	return tags, nil
+}
+
+func (r *stubTagRepo) SetTagsForResource(ctx context.Context, resourceID uuid.UUID, resourceType string, tagIDs []uuid.UUID) error {
+	r.set[resourceID] = tagIDs
+	return nil
+}
+
+func TestDeleteMediaUseCase_Execute_SharedBlob(t *testing.T) {
+	ownerID := uuid.New()
+	blob := &media.Blob{ID: uuid.New(), OwnerID: ownerID, StorageKey: "originals/abc", RefCount: 2}
+	variants := []media.Variant{{Name: media.VariantThumbnail, Key: "variants/abc/thumbnail.jpg"}}
+	first := &media.Media{ID: uuid.New(), OwnerID: ownerID, BlobID: &blob.ID, Variants: variants, Metadata: map[string]any{}}
+	second := &media.Media{ID: uuid.New(), OwnerID: ownerID, BlobID: &blob.ID, Variants: variants, Metadata: map[string]any{}}
+
+	repo := &stubMediaRepo{
+		medias: map[uuid.UUID]*media.Media{first.ID: first, second.ID: second},
+		blobs:  map[uuid.UUID]*media.Blob{blob.ID: blob},
+	}
+	storage := testutil.NewFakeStorage()
+	storage.PutAt("originals/abc", []byte("original"), time.Now())
+	storage.PutAt("variants/abc/thumbnail.jpg", []byte("thumbnail"), time.Now())
+	uc := NewDeleteMediaUseCase(repo, &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}}, storage, logger.NewZapLogger("development"))
+
+	require.NoError(t, uc.Execute(context.Background(), DeleteMediaInput{OwnerID: ownerID, MediaID: first.ID}))
+	assert.Len(t, storage.Keys(), 2, "files stay while another media references the blob")
+
+	require.NoError(t, uc.Execute(context.Background(), DeleteMediaInput{OwnerID: ownerID, MediaID: second.ID}))
+	assert.Empty(t, storage.Keys(), "the last reference removes the shared files")
+	assert.Empty(t, repo.blobs)
+}
+
+func TestDeleteMediaUseCase_Execute_LegacyMedia(t *testing.T) {
+	ownerID := uuid.New()
+	m := &media.Media{ID: uuid.New(), OwnerID: ownerID, Metadata: map[string]any{"original_public_id": "originals/legacy"}}
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{m.ID: m}}
+	storage := testutil.NewFakeStorage()
+	storage.PutAt("originals/legacy", []byte("original"), time.Now())
+	uc := NewDeleteMediaUseCase(repo, &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}}, storage, logger.NewZapLogger("development"))
+
+	require.NoError(t, uc.Execute(context.Background(), DeleteMediaInput{OwnerID: ownerID, MediaID: m.ID}))
+	assert.Empty(t, storage.Keys())
+	assert.Empty(t, repo.medias)
+}
+
+// failingDeleteRepo fails to delete media.
+type failingDeleteRepo struct {
+	*stubMediaRepo
+}
+
+func (r failingDeleteRepo) Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*media.Blob, error) {
+	return nil, apperror.NewInternal("failed to delete media", nil)
+}
+
+func TestDeleteMediaUseCase_Execute_LegacyMediaKeepsFilesWhenRowStays(t *testing.T) {
+	ownerID := uuid.New()
+	m := &media.Media{ID: uuid.New(), OwnerID: ownerID, Metadata: map[string]any{"original_public_id": "originals/legacy"}}
+	repo := failingDeleteRepo{&stubMediaRepo{medias: map[uuid.UUID]*media.Media{m.ID: m}}}
+	storage := testutil.NewFakeStorage()
+	storage.PutAt("originals/legacy", []byte("original"), time.Now())
+	uc := NewDeleteMediaUseCase(repo, &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}}, storage, logger.NewZapLogger("development"))
+
+	require.Error(t, uc.Execute(context.Background(), DeleteMediaInput{OwnerID: ownerID, MediaID: m.ID}))
+	assert.Equal(t, []string{"originals/legacy"}, storage.Keys())
+}
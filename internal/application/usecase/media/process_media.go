@@ -1,8 +1,12 @@
 package media
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/khoahotran/personal-os/adapters/event"
 	"github.com/khoahotran/personal-os/internal/application/service"
@@ -12,66 +16,234 @@ import (
 	"go.uber.org/zap"
 )
 
+var mediaVariants = []service.ImageVariant{
+	{Name: media.VariantThumbnail, Width: 400, Height: 400, Fit: service.FitFill},
+	{Name: media.VariantMedium, Width: 1200, Fit: service.FitLimit},
+	{Name: media.VariantOG, Width: 1200, Height: 630, Fit: service.FitFill},
+}
+
 type ProcessMediaUseCase struct {
 	mediaRepo media.Repository
-	uploader  service.Uploader
+	storage   service.Storage
+	processor service.ImageProcessor
+	prober    service.AVProber
 	logger    logger.Logger
+	// retryDelay is the wait before the second attempt; it doubles after
+	// each further failure.
+	retryDelay time.Duration
 }
 
-func NewProcessMediaUseCase(r media.Repository, u service.Uploader, log logger.Logger) *ProcessMediaUseCase {
-	return &ProcessMediaUseCase{mediaRepo: r, uploader: u, logger: log}
+func NewProcessMediaUseCase(r media.Repository, s service.Storage, p service.ImageProcessor, av service.AVProber, log logger.Logger) *ProcessMediaUseCase {
+	return &ProcessMediaUseCase{mediaRepo: r, storage: s, processor: p, prober: av, logger: log, retryDelay: 5 * time.Second}
 }
 
+// Execute processes the media, retrying failures up to
+// media.MaxProcessingAttempts times. Every attempt is recorded on the media;
+// once they are exhausted the media is left in StatusError and Execute
+// returns nil, as there is nothing more the event can do.
 func (uc *ProcessMediaUseCase) Execute(ctx context.Context, payload event.MediaEventPayload) error {
 	l := uc.logger.With(zap.String("media_id", payload.MediaID.String()), zap.String("event_type", string(payload.EventType)))
 	l.Info("Worker UseCase processing media event")
 
+	m, err := uc.findPending(ctx, payload, l)
+	if m == nil {
+		return err
+	}
+
+	delay := uc.retryDelay
+	for {
+		// The original is read from where the media keeps it now rather than
+		// from the event, as a visibility change moves it.
+		originalKey := m.OriginalKey()
+		m.BeginAttempt(time.Now().UTC())
+		err := uc.process(ctx, m, originalKey, l)
+		if err == nil {
+			m.MarkProcessed(time.Now().UTC())
+			err := uc.mediaRepo.UpdateProcessing(ctx, m, originalKey)
+			if errors.Is(err, media.ErrOriginalMoved) {
+				// Variants written beside the old original are left to the
+				// orphaned asset cleanup, as other media of the blob may use
+				// them.
+				l.Info("Media original moved while processing, starting over")
+				if m, err = uc.findPending(ctx, payload, l); m == nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return apperror.NewInternal("failed to update media to 'ready'", err)
+			}
+			l.Info("Successfully processed media", zap.String("status", string(m.Status)), zap.Int("attempts", m.ProcessingAttempts))
+			return nil
+		}
+
+		// A missing or unreadable original fails the same way every time.
+		retryable := !errors.Is(err, apperror.ErrNotFound) && !errors.Is(err, apperror.ErrInvalidInput)
+		exhausted := m.MarkAttemptFailed(err, retryable)
+		updateErr := uc.mediaRepo.UpdateProcessing(ctx, m, originalKey)
+		if errors.Is(updateErr, media.ErrOriginalMoved) {
+			// The move is likely why the original could not be read.
+			l.Info("Media original moved while processing, starting over", zap.Error(err))
+			if m, err = uc.findPending(ctx, payload, l); m == nil {
+				return err
+			}
+			continue
+		}
+		if updateErr != nil {
+			return apperror.NewInternal("failed to record media processing attempt", updateErr)
+		}
+		if exhausted {
+			l.Error("Media processing failed, giving up", err, zap.Int("attempts", m.ProcessingAttempts))
+			return nil
+		}
+		l.Warn("Media processing attempt failed, retrying", zap.Int("attempts", m.ProcessingAttempts), zap.Duration("delay", delay), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// findPending loads the media of the event. It returns nil, with nil error,
+// when the media is gone or no longer pending, as there is nothing to do.
+func (uc *ProcessMediaUseCase) findPending(ctx context.Context, payload event.MediaEventPayload, l logger.Logger) (*media.Media, error) {
 	m, err := uc.mediaRepo.FindByID(ctx, payload.MediaID, payload.OwnerID)
 	if err != nil {
 		if errors.Is(err, apperror.ErrNotFound) {
 			l.Warn("Media not found, skipping event", zap.String("media_id", payload.MediaID.String()))
-			return nil
+			return nil, nil
 		}
-		return apperror.NewInternal("failed to get media", err)
+		return nil, apperror.NewInternal("failed to get media", err)
 	}
 
-	if m.Status == media.StatusReady {
-		l.Info("Media already in 'ready' state, skipping", zap.String("status", string(m.Status)))
-		return nil
+	if m.Status != media.StatusPending {
+		l.Info("Media not in 'pending' state, skipping", zap.String("status", string(m.Status)))
+		return nil, nil
 	}
+	return m, nil
+}
 
-	cldClient := uc.uploader.GetClient()
-	if cldClient == nil {
-		return apperror.NewInternal("could not get cloudinary client from uploader", nil)
+// process reads the original stored under originalKey and sets what it
+// learns on m: variants for images, metadata and a poster for video and
+// audio. Anything else is served as is.
+func (uc *ProcessMediaUseCase) process(ctx context.Context, m *media.Media, originalKey string, l logger.Logger) error {
+	original, err := uc.storage.Open(ctx, originalKey)
+	if err != nil {
+		return err
 	}
+	defer original.Close()
 
-	imgAsset, err := cldClient.Image(payload.OriginalPublicID)
-	if err != nil {
-		return apperror.NewInternal("failed to create cloudinary asset", err)
+	switch m.Kind {
+	case media.KindVideo, media.KindAudio:
+		return uc.processAV(ctx, m, original, l)
+	case media.KindFile:
+		l.Info("Media is not an image, video or audio, serving it as is")
+		return nil
 	}
 
-	imgAsset.Transformation = "c_limit,w_1200"
-	mainURLStr, err := imgAsset.String()
+	processed, err := uc.processor.Process(ctx, original, mediaVariants)
+	if errors.Is(err, service.ErrUnsupportedImage) {
+		l.Info("Media is not a supported image, skipping variants")
+		return nil
+	}
 	if err != nil {
-		return apperror.NewInternal("failed to build main image URL", err)
+		return err
+	}
+	if err := uc.applyVariants(ctx, m, processed); err != nil {
+		return err
+	}
+	m.Width = processed.Width
+	m.Height = processed.Height
+	if v, ok := m.Variant(media.VariantMedium); ok {
+		m.URL = v.URL
 	}
+	l.Info("Generated image variants for media", zap.Int("variants", len(m.Variants)))
+	return nil
+}
 
-	imgAsset.Transformation = "c_fill,g_auto,w_400,h_400"
-	thumbURLStr, err := imgAsset.String()
+// processAV records the streams of a video or audio file and renders the
+// variants of a video from its poster frame. The original stays the URL.
+func (uc *ProcessMediaUseCase) processAV(ctx context.Context, m *media.Media, original io.Reader, l logger.Logger) error {
+	info, err := uc.prober.Probe(ctx, original)
+	if errors.Is(err, service.ErrProberUnavailable) {
+		l.Info("No audio/video prober available, serving media without metadata")
+		return nil
+	}
 	if err != nil {
-		return apperror.NewInternal("failed to build thumbnail URL", err)
+		return err
 	}
 
-	l.Info("Generated Cloudinary URLs for media")
-
-	m.URL = mainURLStr
-	m.ThumbnailURL = &thumbURLStr
-	m.Status = media.StatusReady
+	m.Duration = info.Duration
+	m.VideoCodec = info.VideoCodec
+	m.AudioCodec = info.AudioCodec
+	m.Width = info.Width
+	m.Height = info.Height
+	// Containers such as Ogg and WebM hold either, so the streams decide.
+	if info.HasVideo() {
+		m.Kind = media.KindVideo
+	} else {
+		m.Kind = media.KindAudio
+	}
+	l.Info("Probed media", zap.String("kind", string(m.Kind)), zap.Duration("duration", m.Duration))
 
-	if err := uc.mediaRepo.Update(ctx, m); err != nil {
-		return apperror.NewInternal("failed to update media to 'ready'", err)
+	if info.Poster == nil {
+		return nil
+	}
+	processed, err := uc.processor.Process(ctx, bytes.NewReader(info.Poster), mediaVariants)
+	if err != nil {
+		return err
 	}
+	if err := uc.applyVariants(ctx, m, processed); err != nil {
+		return err
+	}
+	l.Info("Generated poster variants for media", zap.Int("variants", len(m.Variants)))
+	return nil
+}
 
-	l.Info("Successfully processed media", zap.String("status", string(m.Status)))
+// applyVariants stores the rendered variants and sets them, the thumbnail
+// and the placeholder colours on m.
+func (uc *ProcessMediaUseCase) applyVariants(ctx context.Context, m *media.Media, processed *service.ProcessedImage) error {
+	// Variants of a shared blob are shared too, so they live beside it.
+	variantsID := m.ID
+	if m.BlobID != nil {
+		variantsID = *m.BlobID
+	}
+	folder := fmt.Sprintf("users/%s/media/variants/%s", m.OwnerID.String(), variantsID.String())
+	folder = service.KeyWithVisibility(folder, !m.IsPublic)
+	variants, err := storeVariants(ctx, uc.storage, folder, processed.Variants)
+	if err != nil {
+		return err
+	}
+	m.Variants = variants
+	m.DominantColor = processed.DominantColor
+	m.BlurHash = processed.BlurHash
+	if v, ok := m.Variant(media.VariantThumbnail); ok {
+		m.ThumbnailURL = &v.URL
+	}
 	return nil
 }
+
+// storeVariants puts each rendered variant under folder and returns them in
+// the same order.
+func storeVariants(ctx context.Context, storage service.Storage, folder string, encoded []service.EncodedImage) ([]media.Variant, error) {
+	variants := make([]media.Variant, 0, len(encoded))
+	for _, e := range encoded {
+		key := fmt.Sprintf("%s/%s.%s", folder, e.Name, e.Extension)
+		url, err := storage.Put(ctx, key, bytes.NewReader(e.Data), e.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, media.Variant{
+			Name:        e.Name,
+			Key:         key,
+			URL:         url,
+			Width:       e.Width,
+			Height:      e.Height,
+			ContentType: e.ContentType,
+		})
+	}
+	return variants, nil
+}
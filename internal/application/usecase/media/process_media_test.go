@@ -0,0 +1,216 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// flakyProcessor fails the first `failures` calls, then renders a thumbnail
+// and a medium variant.
+type flakyProcessor struct {
+	failures int
+	calls    int
+	// onCall, when set, runs at the start of every call.
+	onCall func()
+}
+
+func (p *flakyProcessor) Process(ctx context.Context, r io.Reader, variants []service.ImageVariant) (*service.ProcessedImage, error) {
+	p.calls++
+	if p.onCall != nil {
+		p.onCall()
+	}
+	if p.calls <= p.failures {
+		return nil, errors.New("decoder crashed")
+	}
+	return &service.ProcessedImage{
+		Width:  800,
+		Height: 600,
+		Variants: []service.EncodedImage{
+			{Name: media.VariantThumbnail, Extension: "webp", ContentType: "image/webp", Data: []byte("thumb"), Width: 400, Height: 400},
+			{Name: media.VariantMedium, Extension: "jpg", ContentType: "image/jpeg", Data: []byte("medium"), Width: 800, Height: 600},
+		},
+	}, nil
+}
+
+// stubProber returns info, or err when set.
+type stubProber struct {
+	info *service.AVInfo
+	err  error
+}
+
+func (p stubProber) Probe(ctx context.Context, r io.Reader) (*service.AVInfo, error) {
+	return p.info, p.err
+}
+
+func newPendingMedia(storage *testutil.FakeStorage) (*stubMediaRepo, *media.Media, event.MediaEventPayload) {
+	m := &media.Media{ID: uuid.New(), OwnerID: uuid.New(), Status: media.StatusPending, Kind: media.KindImage}
+	key := "users/" + m.OwnerID.String() + "/media/originals/" + m.ID.String()
+	m.Metadata = map[string]any{"original_public_id": key}
+	storage.PutAt(key, []byte("original"), time.Now())
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{m.ID: m}}
+	payload := event.MediaEventPayload{EventType: event.MediaEventTypeUploaded, MediaID: m.ID, OwnerID: m.OwnerID, OriginalPublicID: key}
+	return repo, m, payload
+}
+
+func TestProcessMediaUseCase_Execute_RetriesUntilSuccess(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	repo, m, payload := newPendingMedia(storage)
+	uc := NewProcessMediaUseCase(repo, storage, &flakyProcessor{failures: 1}, stubProber{}, logger.NewZapLogger("development"))
+	uc.retryDelay = 0
+
+	require.NoError(t, uc.Execute(context.Background(), payload))
+
+	assert.Equal(t, media.StatusReady, m.Status)
+	assert.Equal(t, 2, m.ProcessingAttempts)
+	assert.Empty(t, m.ProcessingError)
+	assert.NotNil(t, m.ProcessedAt)
+	assert.NotNil(t, m.ThumbnailURL)
+}
+
+func TestProcessMediaUseCase_Execute_MarksErrorWhenExhausted(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	repo, m, payload := newPendingMedia(storage)
+	processor := &flakyProcessor{failures: media.MaxProcessingAttempts}
+	uc := NewProcessMediaUseCase(repo, storage, processor, stubProber{}, logger.NewZapLogger("development"))
+	uc.retryDelay = 0
+
+	require.NoError(t, uc.Execute(context.Background(), payload))
+
+	assert.Equal(t, media.StatusError, m.Status)
+	assert.Equal(t, media.MaxProcessingAttempts, m.ProcessingAttempts)
+	assert.Equal(t, media.MaxProcessingAttempts, processor.calls)
+	assert.Equal(t, "decoder crashed", m.ProcessingError)
+	assert.NotNil(t, m.LastAttemptAt)
+	assert.Nil(t, m.ProcessedAt)
+}
+
+func TestProcessMediaUseCase_Execute_MissingOriginalIsNotRetried(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	repo, m, payload := newPendingMedia(storage)
+	require.NoError(t, storage.Delete(context.Background(), payload.OriginalPublicID))
+	processor := &flakyProcessor{}
+	uc := NewProcessMediaUseCase(repo, storage, processor, stubProber{}, logger.NewZapLogger("development"))
+	uc.retryDelay = 0
+
+	require.NoError(t, uc.Execute(context.Background(), payload))
+
+	assert.Equal(t, media.StatusError, m.Status)
+	assert.Equal(t, 1, m.ProcessingAttempts)
+	assert.Zero(t, processor.calls)
+}
+
+func TestProcessMediaUseCase_Execute_OriginalMovedWhileProcessing(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	repo, m, payload := newPendingMedia(storage)
+	processor := &flakyProcessor{}
+	moved := false
+	processor.onCall = func() {
+		if moved {
+			return
+		}
+		// A visibility change moves the original and saves the media anew.
+		moved = true
+		private := *m
+		private.IsPublic = false
+		private.ProcessingAttempts = 0 // as saved, before this attempt
+		private.Metadata = map[string]any{"original_public_id": service.PrivatePrefix + payload.OriginalPublicID}
+		data, _ := storage.Object(payload.OriginalPublicID)
+		storage.PutAt(private.OriginalKey(), data, time.Now())
+		require.NoError(t, storage.Delete(context.Background(), payload.OriginalPublicID))
+		repo.medias[m.ID] = &private
+	}
+	uc := NewProcessMediaUseCase(repo, storage, processor, stubProber{}, logger.NewZapLogger("development"))
+	uc.retryDelay = 0
+
+	require.NoError(t, uc.Execute(context.Background(), payload))
+
+	saved := repo.medias[m.ID]
+	assert.Equal(t, 2, processor.calls, "processing starts over from the moved original")
+	assert.Equal(t, media.StatusReady, saved.Status)
+	assert.Equal(t, 1, saved.ProcessingAttempts, "the interrupted run is not counted")
+	assert.False(t, saved.IsPublic, "the visibility change is kept")
+	require.NotEmpty(t, saved.Variants)
+	for _, v := range saved.Variants {
+		assert.True(t, service.IsPrivateKey(v.Key), v.Key)
+	}
+}
+
+func TestMedia_ResetProcessing(t *testing.T) {
+	m := &media.Media{Status: media.StatusError, ProcessingAttempts: media.MaxProcessingAttempts}
+	require.NoError(t, m.ResetProcessing())
+	assert.Equal(t, media.StatusPending, m.Status)
+	assert.Zero(t, m.ProcessingAttempts)
+
+	m.Status = media.StatusReady
+	assert.ErrorIs(t, m.ResetProcessing(), media.ErrAlreadyProcessed)
+}
+
+func TestProcessMediaUseCase_Execute_VideoGetsPosterVariants(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	repo, m, payload := newPendingMedia(storage)
+	m.Kind = media.KindVideo
+	originalURL := "https://media.test/" + payload.OriginalPublicID
+	m.URL = originalURL
+	prober := stubProber{info: &service.AVInfo{
+		Duration:   90 * time.Second,
+		Width:      1920,
+		Height:     1080,
+		VideoCodec: "h264",
+		AudioCodec: "aac",
+		Poster:     []byte("png"),
+	}}
+	uc := NewProcessMediaUseCase(repo, storage, &flakyProcessor{}, prober, logger.NewZapLogger("development"))
+
+	require.NoError(t, uc.Execute(context.Background(), payload))
+
+	assert.Equal(t, media.StatusReady, m.Status)
+	assert.Equal(t, originalURL, m.URL, "the video itself stays the URL")
+	assert.Equal(t, 90*time.Second, m.Duration)
+	assert.Equal(t, "h264", m.VideoCodec)
+	assert.Equal(t, 1920, m.Width, "dimensions come from the video, not the poster")
+	require.NotNil(t, m.ThumbnailURL)
+	poster, ok := m.PosterURL()
+	assert.True(t, ok)
+	assert.Contains(t, poster, "/medium.jpg")
+}
+
+func TestProcessMediaUseCase_Execute_AudioOnlyContainer(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	repo, m, payload := newPendingMedia(storage)
+	m.Kind = media.KindVideo
+	prober := stubProber{info: &service.AVInfo{Duration: 3 * time.Minute, AudioCodec: "opus"}}
+	processor := &flakyProcessor{}
+	uc := NewProcessMediaUseCase(repo, storage, processor, prober, logger.NewZapLogger("development"))
+
+	require.NoError(t, uc.Execute(context.Background(), payload))
+
+	assert.Equal(t, media.KindAudio, m.Kind)
+	assert.Equal(t, "opus", m.AudioCodec)
+	assert.Zero(t, processor.calls)
+	assert.Nil(t, m.ThumbnailURL)
+}
+
+func TestProcessMediaUseCase_Execute_WithoutProber(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	repo, m, payload := newPendingMedia(storage)
+	m.Kind = media.KindAudio
+	uc := NewProcessMediaUseCase(repo, storage, &flakyProcessor{}, stubProber{err: service.ErrProberUnavailable}, logger.NewZapLogger("development"))
+
+	require.NoError(t, uc.Execute(context.Background(), payload))
+
+	assert.Equal(t, media.StatusReady, m.Status, "media is served as uploaded")
+	assert.Zero(t, m.Duration)
+}
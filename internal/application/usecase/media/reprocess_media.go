@@ -0,0 +1,69 @@
+package media
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// ReprocessMediaUseCase puts pending or errored media back in the processing
+// queue with a fresh set of attempts.
+type ReprocessMediaUseCase struct {
+	mediaRepo   media.Repository
+	storage     service.Storage
+	kafkaClient *event.KafkaProducerClient
+	logger      logger.Logger
+}
+
+func NewReprocessMediaUseCase(r media.Repository, s service.Storage, k *event.KafkaProducerClient, log logger.Logger) *ReprocessMediaUseCase {
+	return &ReprocessMediaUseCase{mediaRepo: r, storage: s, kafkaClient: k, logger: log}
+}
+
+type ReprocessMediaInput struct {
+	OwnerID uuid.UUID
+	MediaID uuid.UUID
+}
+
+func (uc *ReprocessMediaUseCase) Execute(ctx context.Context, in ReprocessMediaInput) (*media.Media, error) {
+	m, err := uc.mediaRepo.FindByID(ctx, in.MediaID, in.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalKey := m.OriginalKey()
+	if originalKey == "" {
+		return nil, apperror.NewInvalidInput("media has no stored original to process", nil)
+	}
+	if err := m.ResetProcessing(); err != nil {
+		if errors.Is(err, media.ErrAlreadyProcessed) {
+			return nil, apperror.NewAppError(apperror.ErrConflict, "media is already processed", "only pending or errored media can be reprocessed", err)
+		}
+		return nil, err
+	}
+	if err := uc.mediaRepo.Update(ctx, m); err != nil {
+		return nil, err
+	}
+
+	originalURL, _ := m.Metadata["original_url"].(string)
+	err = uc.kafkaClient.PublishMediaEvent(ctx, event.MediaEventPayload{
+		EventType:        event.MediaEventTypeReprocess,
+		MediaID:          m.ID,
+		OwnerID:          m.OwnerID,
+		Provider:         m.Provider,
+		OriginalURL:      originalURL,
+		OriginalPublicID: originalKey,
+	})
+	if err != nil {
+		return nil, apperror.NewInternal("failed to publish media reprocess event", err)
+	}
+	if err := SignPrivate(ctx, uc.storage, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,276 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/uploadsession"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/upload"
+	"go.uber.org/zap"
+)
+
+// ResumableUploadUseCase receives a file in parts over several requests, so
+// an interrupted upload only resends the parts that did not arrive. Parts
+// are kept in storage until the file is complete, then the assembled file
+// goes through UploadMediaUseCase like any other upload.
+type ResumableUploadUseCase struct {
+	repo     uploadsession.Repository
+	storage  service.Storage
+	uploader *UploadMediaUseCase
+	policy   upload.Policy
+	partSize int64
+	ttl      time.Duration
+	logger   logger.Logger
+}
+
+type ResumableUploadSettings struct {
+	// PartSize is the size of every part but the last.
+	PartSize int64
+	// TTL is how long a session is kept after its last part arrives.
+	TTL time.Duration
+}
+
+func NewResumableUploadUseCase(
+	r uploadsession.Repository,
+	s service.Storage,
+	u *UploadMediaUseCase,
+	p upload.Policy,
+	settings ResumableUploadSettings,
+	log logger.Logger,
+) *ResumableUploadUseCase {
+	return &ResumableUploadUseCase{
+		repo:     r,
+		storage:  s,
+		uploader: u,
+		policy:   p,
+		partSize: settings.PartSize,
+		ttl:      settings.TTL,
+		logger:   log,
+	}
+}
+
+type StartUploadInput struct {
+	OwnerID  uuid.UUID
+	FileName string
+	Size     int64
+	// SHA256 is the hex digest of the whole file, checked once it is
+	// assembled.
+	SHA256       string
+	Metadata     map[string]any
+	IsPublic     bool
+	KeepLocation bool
+}
+
+func (uc *ResumableUploadUseCase) StartUpload(ctx context.Context, in StartUploadInput) (*uploadsession.Session, error) {
+	if uc.policy.MaxSize > 0 && in.Size > uc.policy.MaxSize {
+		return nil, uc.policy.TooLarge()
+	}
+	if in.Metadata == nil {
+		in.Metadata = make(map[string]any)
+	}
+
+	now := time.Now().UTC()
+	s := &uploadsession.Session{
+		ID:           uuid.New(),
+		OwnerID:      in.OwnerID,
+		FileName:     in.FileName,
+		Size:         in.Size,
+		PartSize:     uc.partSize,
+		SHA256:       strings.ToLower(in.SHA256),
+		Metadata:     in.Metadata,
+		IsPublic:     in.IsPublic,
+		KeepLocation: in.KeepLocation,
+		Parts:        []int{},
+		ExpiresAt:    now.Add(uc.ttl),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.Validate(); err != nil {
+		return nil, apperror.NewInvalidInput("upload session validation failed", err)
+	}
+	if err := uc.repo.Save(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (uc *ResumableUploadUseCase) GetUpload(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*uploadsession.Session, error) {
+	return uc.repo.FindByID(ctx, id, ownerID)
+}
+
+type UploadPartInput struct {
+	SessionID uuid.UUID
+	OwnerID   uuid.UUID
+	Part      int
+	Body      io.Reader
+}
+
+// UploadPart stores one part. Sending a part again replaces it, so a part
+// whose request failed midway can simply be retried.
+func (uc *ResumableUploadUseCase) UploadPart(ctx context.Context, in UploadPartInput) (*uploadsession.Session, error) {
+	s, err := uc.repo.FindByID(ctx, in.SessionID, in.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := s.ExpectedPartSize(in.Part)
+	if err != nil {
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("part must be between 1 and %d", s.PartCount()), err)
+	}
+
+	// One byte past the expected size is enough to tell the part is too big.
+	data, err := io.ReadAll(io.LimitReader(in.Body, expected+1))
+	if err != nil {
+		return nil, apperror.NewInvalidInput("failed to read part", err)
+	}
+	if int64(len(data)) != expected {
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("part %d must be %d bytes", in.Part, expected), uploadsession.ErrPartSize)
+	}
+
+	if _, err := uc.storage.Put(ctx, s.PartKey(in.Part), bytes.NewReader(data), "application/octet-stream"); err != nil {
+		return nil, apperror.NewInternal("failed to store upload part", err)
+	}
+	expiresAt := time.Now().UTC().Add(uc.ttl)
+	if err := uc.repo.AddPart(ctx, s.ID, in.Part, expiresAt); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(s.Parts, in.Part) {
+		s.Parts = append(s.Parts, in.Part)
+		slices.Sort(s.Parts)
+	}
+	s.ExpiresAt = expiresAt
+	return s, nil
+}
+
+// CompleteUpload assembles the parts, checks the result against the
+// session's digest and content policy, and uploads it as media. The session
+// stays open when a check fails, so bad parts can be sent again.
+func (uc *ResumableUploadUseCase) CompleteUpload(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*UploadMediaOutput, error) {
+	s, err := uc.repo.FindByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if missing := s.MissingParts(); len(missing) > 0 {
+		return nil, apperror.NewInvalidInput(fmt.Sprintf("%d of %d parts are missing, starting with part %d", len(missing), s.PartCount(), missing[0]), uploadsession.ErrIncomplete)
+	}
+
+	file, err := uc.assemble(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	defer removeTempFile(file)
+
+	contentType, err := uc.policy.Check(s.Size, file)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := s.Metadata
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	if s.FileName != "" {
+		metadata["original_filename"] = s.FileName
+	}
+	output, err := uc.uploader.Execute(ctx, UploadMediaInput{
+		OwnerID:      s.OwnerID,
+		File:         file,
+		ContentType:  contentType,
+		Metadata:     metadata,
+		IsPublic:     s.IsPublic,
+		KeepLocation: s.KeepLocation,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The media exists now, so failing to tidy up the session is only logged.
+	if err := uc.discard(ctx, s); err != nil {
+		uc.logger.Warn("Failed to remove completed upload session", zap.String("session_id", s.ID.String()), zap.Error(err))
+	}
+	return output, nil
+}
+
+// AbortUpload removes the session and the parts received so far.
+func (uc *ResumableUploadUseCase) AbortUpload(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error {
+	s, err := uc.repo.FindByID(ctx, id, ownerID)
+	if err != nil {
+		return err
+	}
+	return uc.discard(ctx, s)
+}
+
+// assemble writes the parts in order to a temporary file and checks its
+// digest. The returned file is rewound; the caller removes it.
+func (uc *ResumableUploadUseCase) assemble(ctx context.Context, s *uploadsession.Session) (*os.File, error) {
+	file, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, apperror.NewInternal("failed to create temporary file", err)
+	}
+
+	hash := sha256.New()
+	w := io.MultiWriter(file, hash)
+	for n := 1; n <= s.PartCount(); n++ {
+		if err := copyPart(ctx, uc.storage, s.PartKey(n), w); err != nil {
+			removeTempFile(file)
+			return nil, apperror.NewInternal(fmt.Sprintf("failed to read upload part %d", n), err)
+		}
+	}
+
+	if hex.EncodeToString(hash.Sum(nil)) != s.SHA256 {
+		removeTempFile(file)
+		return nil, apperror.NewInvalidInput("uploaded file does not match the sha256 given when the upload started", uploadsession.ErrChecksum)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		removeTempFile(file)
+		return nil, apperror.NewInternal("failed to rewind assembled upload", err)
+	}
+	return file, nil
+}
+
+func copyPart(ctx context.Context, storage service.Storage, key string, w io.Writer) error {
+	part, err := storage.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer part.Close()
+	_, err = io.Copy(w, part)
+	return err
+}
+
+func removeTempFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// discard deletes the session, then every object in its folder, including a
+// part stored just before its request failed. Parts that fail to delete are
+// logged and left in storage.
+func (uc *ResumableUploadUseCase) discard(ctx context.Context, s *uploadsession.Session) error {
+	if err := uc.repo.Delete(ctx, s.ID); err != nil {
+		return err
+	}
+	objects, err := uc.storage.List(ctx, uploadsession.Folder(s.OwnerID, s.ID)+"/")
+	if err != nil {
+		uc.logger.Warn("Failed to list upload parts", zap.String("session_id", s.ID.String()), zap.Error(err))
+		return nil
+	}
+	for _, obj := range objects {
+		if err := uc.storage.Delete(ctx, obj.Key); err != nil {
+			uc.logger.Warn("Failed to delete upload part", zap.String("key", obj.Key), zap.Error(err))
+		}
+	}
+	return nil
+}
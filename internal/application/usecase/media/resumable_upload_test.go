@@ -0,0 +1,199 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/domain/uploadsession"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"github.com/khoahotran/personal-os/pkg/upload"
+)
+
+type stubSessionRepo struct {
+	uploadsession.Repository
+	sessions map[uuid.UUID]*uploadsession.Session
+}
+
+func (r *stubSessionRepo) Save(ctx context.Context, s *uploadsession.Session) error {
+	r.sessions[s.ID] = s
+	return nil
+}
+
+func (r *stubSessionRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*uploadsession.Session, error) {
+	s, ok := r.sessions[id]
+	if !ok || s.OwnerID != ownerID {
+		return nil, apperror.NewNotFound("upload session", id.String())
+	}
+	// Return a copy, as a database would.
+	found := *s
+	found.Parts = slices.Clone(s.Parts)
+	return &found, nil
+}
+
+func (r *stubSessionRepo) AddPart(ctx context.Context, id uuid.UUID, n int, expiresAt time.Time) error {
+	s := r.sessions[id]
+	if !slices.Contains(s.Parts, n) {
+		s.Parts = append(s.Parts, n)
+		slices.Sort(s.Parts)
+	}
+	s.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *stubSessionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+// dedupMediaRepo already holds a processed copy of every upload, so completing
+// an upload links to it without queueing any processing.
+type dedupMediaRepo struct {
+	stubMediaRepo
+	saved []*media.Media
+}
+
+func (r *dedupMediaRepo) FindBlob(ctx context.Context, ownerID uuid.UUID, sha256 string, private bool) (*media.Blob, error) {
+	return &media.Blob{ID: uuid.New(), SHA256: sha256}, nil
+}
+
+func (r *dedupMediaRepo) FindReadyByBlob(ctx context.Context, blobID uuid.UUID) (*media.Media, error) {
+	return &media.Media{URL: "https://media.test/existing", Status: media.StatusReady, Kind: media.KindImage}, nil
+}
+
+func (r *dedupMediaRepo) Save(ctx context.Context, m *media.Media, blob *media.Blob) error {
+	r.saved = append(r.saved, m)
+	return nil
+}
+
+type noExif struct{}
+
+func (noExif) Extract(r io.Reader) (*service.PhotoMetadata, error) { return nil, nil }
+
+func (noExif) StripLocation(dst io.Writer, src io.Reader) error {
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+// pngFile is uploaded in parts of 4 bytes.
+var pngFile = []byte("\x89PNG\r\n\x1a\n\x00\x00")
+
+func newResumableUploadUseCase(sessions *stubSessionRepo, mediaRepo media.Repository, storage service.Storage) *ResumableUploadUseCase {
+	log := logger.NewZapLogger("development")
+	uploader := NewUploadMediaUseCase(mediaRepo, storage, noExif{}, nil, log)
+	policy := upload.Policy{MaxSize: 1 << 10, Allowed: upload.MediaTypes}
+	return NewResumableUploadUseCase(sessions, storage, uploader, policy, ResumableUploadSettings{PartSize: 4, TTL: time.Hour}, log)
+}
+
+func startUpload(t *testing.T, uc *ResumableUploadUseCase, ownerID uuid.UUID) *uploadsession.Session {
+	t.Helper()
+	sum := sha256.Sum256(pngFile)
+	s, err := uc.StartUpload(context.Background(), StartUploadInput{
+		OwnerID:  ownerID,
+		FileName: "photo.png",
+		Size:     int64(len(pngFile)),
+		SHA256:   hex.EncodeToString(sum[:]),
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func sendParts(t *testing.T, uc *ResumableUploadUseCase, s *uploadsession.Session, file []byte, parts ...int) {
+	t.Helper()
+	for _, n := range parts {
+		start := int64(n-1) * s.PartSize
+		end := min(start+s.PartSize, int64(len(file)))
+		_, err := uc.UploadPart(context.Background(), UploadPartInput{SessionID: s.ID, OwnerID: s.OwnerID, Part: n, Body: bytes.NewReader(file[start:end])})
+		require.NoError(t, err)
+	}
+}
+
+func TestResumableUploadUseCase_CompleteUpload(t *testing.T) {
+	sessions := &stubSessionRepo{sessions: map[uuid.UUID]*uploadsession.Session{}}
+	mediaRepo := &dedupMediaRepo{}
+	storage := testutil.NewFakeStorage()
+	uc := newResumableUploadUseCase(sessions, mediaRepo, storage)
+	ownerID := uuid.New()
+	s := startUpload(t, uc, ownerID)
+	assert.Equal(t, 3, s.PartCount())
+
+	// Parts may arrive in any order, and a resent part replaces the first.
+	sendParts(t, uc, s, pngFile, 3, 1, 1)
+	for _, key := range storage.Keys() {
+		assert.True(t, service.IsPrivateKey(key), "parts are never served: %s", key)
+	}
+	_, err := uc.CompleteUpload(context.Background(), s.ID, ownerID)
+	assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+	assert.Contains(t, err.Error(), "starting with part 2")
+
+	sendParts(t, uc, s, pngFile, 2)
+	out, err := uc.CompleteUpload(context.Background(), s.ID, ownerID)
+	require.NoError(t, err)
+
+	assert.True(t, out.Deduplicated)
+	require.Len(t, mediaRepo.saved, 1)
+	saved := mediaRepo.saved[0]
+	assert.Equal(t, "image/png", saved.Metadata["content_type"])
+	assert.Equal(t, "photo.png", saved.Metadata["original_filename"])
+	assert.Empty(t, sessions.sessions, "the session is removed")
+	assert.Empty(t, storage.Keys(), "the parts are removed")
+}
+
+func TestResumableUploadUseCase_CompleteUpload_ChecksumMismatch(t *testing.T) {
+	sessions := &stubSessionRepo{sessions: map[uuid.UUID]*uploadsession.Session{}}
+	mediaRepo := &dedupMediaRepo{}
+	uc := newResumableUploadUseCase(sessions, mediaRepo, testutil.NewFakeStorage())
+	ownerID := uuid.New()
+	s := startUpload(t, uc, ownerID)
+	corrupted := slices.Clone(pngFile)
+	corrupted[5] ^= 0xff
+	sendParts(t, uc, s, corrupted, 1, 2, 3)
+
+	_, err := uc.CompleteUpload(context.Background(), s.ID, ownerID)
+	assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+	assert.Contains(t, err.Error(), uploadsession.ErrChecksum.Error())
+	assert.Empty(t, mediaRepo.saved)
+	assert.Contains(t, sessions.sessions, s.ID, "the session stays open to resend parts")
+
+	sendParts(t, uc, s, pngFile, 2)
+	_, err = uc.CompleteUpload(context.Background(), s.ID, ownerID)
+	assert.NoError(t, err)
+}
+
+func TestResumableUploadUseCase_UploadPart_RejectsWrongSize(t *testing.T) {
+	storage := testutil.NewFakeStorage()
+	uc := newResumableUploadUseCase(&stubSessionRepo{sessions: map[uuid.UUID]*uploadsession.Session{}}, &dedupMediaRepo{}, storage)
+	ownerID := uuid.New()
+	s := startUpload(t, uc, ownerID)
+
+	_, err := uc.UploadPart(context.Background(), UploadPartInput{SessionID: s.ID, OwnerID: ownerID, Part: 1, Body: bytes.NewReader(pngFile[:5])})
+	assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+	assert.Contains(t, err.Error(), uploadsession.ErrPartSize.Error())
+	_, err = uc.UploadPart(context.Background(), UploadPartInput{SessionID: s.ID, OwnerID: ownerID, Part: 4, Body: bytes.NewReader(pngFile[:2])})
+	assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+	assert.Contains(t, err.Error(), uploadsession.ErrUnknownPart.Error())
+	assert.Empty(t, storage.Keys())
+}
+
+func TestResumableUploadUseCase_StartUpload_TooLarge(t *testing.T) {
+	sessions := &stubSessionRepo{sessions: map[uuid.UUID]*uploadsession.Session{}}
+	uc := newResumableUploadUseCase(sessions, &dedupMediaRepo{}, testutil.NewFakeStorage())
+
+	_, err := uc.StartUpload(context.Background(), StartUploadInput{OwnerID: uuid.New(), Size: 2 << 10, SHA256: hex.EncodeToString(make([]byte, 32))})
+	assert.True(t, errors.Is(err, apperror.ErrTooLarge))
+	assert.Empty(t, sessions.sessions)
+}
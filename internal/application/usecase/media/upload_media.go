@@ -2,8 +2,12 @@ package media
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,78 +21,217 @@ import (
 
 type UploadMediaUseCase struct {
 	mediaRepo   media.Repository
-	uploader    service.Uploader
+	storage     service.Storage
+	exif        service.ExifService
 	kafkaClient *event.KafkaProducerClient
 	logger      logger.Logger
 }
 
 func NewUploadMediaUseCase(
 	r media.Repository,
-	u service.Uploader,
+	s service.Storage,
+	x service.ExifService,
 	k *event.KafkaProducerClient,
 	log logger.Logger,
 ) *UploadMediaUseCase {
-	return &UploadMediaUseCase{mediaRepo: r, uploader: u, kafkaClient: k, logger: log}
+	return &UploadMediaUseCase{mediaRepo: r, storage: s, exif: x, kafkaClient: k, logger: log}
 }
 
 type UploadMediaInput struct {
-	OwnerID  uuid.UUID
-	File     io.Reader
-	Metadata map[string]any
-	IsPublic bool
-	Provider string
+	OwnerID uuid.UUID
+	// File is read more than once, so it must be seekable, such as a
+	// multipart or temporary file. It is never read into memory whole.
+	File        io.ReadSeeker
+	ContentType string
+	Metadata    map[string]any
+	IsPublic    bool
+	// KeepLocation opts out of stripping GPS coordinates from the stored
+	// file and metadata.
+	KeepLocation bool
 }
 type UploadMediaOutput struct {
 	MediaID uuid.UUID
+	// Deduplicated reports that the bytes were already stored for this owner,
+	// so the new media shares the existing original and its variants.
+	Deduplicated bool
 }
 
 func (uc *UploadMediaUseCase) Execute(ctx context.Context, input UploadMediaInput) (*UploadMediaOutput, error) {
 	mediaID := uuid.New()
 
-	originalFolder := fmt.Sprintf("users/%s/media/originals/", input.OwnerID.String())
-	originalPublicID := mediaID.String()
+	if input.Metadata == nil {
+		input.Metadata = make(map[string]any)
+	}
+	input.Metadata["content_type"] = input.ContentType
+	photo := uc.readPhotoMetadata(input.File, mediaID)
+	if _, err := input.File.Seek(0, io.SeekStart); err != nil {
+		return nil, apperror.NewInternal("failed to rewind media file", err)
+	}
+	var takenAt *time.Time
+	if photo != nil {
+		if !input.KeepLocation {
+			photo.Latitude, photo.Longitude = nil, nil
+		}
+		input.Metadata["exif"] = photo
+		takenAt = photo.TakenAt
+	}
 
-	originalURL, err := uc.uploader.Upload(ctx, input.File, originalFolder, originalPublicID)
-	if err != nil {
-		return nil, apperror.NewInternal("failed to upload original media file", err)
+	// Hash what is stored, after stripping, so the same photo uploaded with
+	// and without its location is kept as two blobs.
+	hash := sha256.New()
+	body := input.File
+	var size int64
+	var err error
+	if !input.KeepLocation && media.KindOf(input.ContentType) == media.KindImage {
+		stripped, err := uc.stripLocation(input.File, hash)
+		if err != nil {
+			return nil, err
+		}
+		defer removeTempFile(stripped)
+		body = stripped
+		if size, err = stripped.Seek(0, io.SeekEnd); err != nil {
+			return nil, apperror.NewInternal("failed to measure media file", err)
+		}
+	} else if size, err = io.Copy(hash, input.File); err != nil {
+		return nil, apperror.NewInternal("failed to read media file", err)
 	}
+	digest := hex.EncodeToString(hash.Sum(nil))
+	input.Metadata["sha256"] = digest
+	input.Metadata["size_bytes"] = size
 
-	if input.Metadata == nil {
-		input.Metadata = make(map[string]any)
+	var newMedia *media.Media
+	var deduplicated bool
+	for attempt := 1; ; attempt++ {
+		// Each attempt starts afresh, as the last may have borrowed variants
+		// from a blob that is gone.
+		newMedia = &media.Media{
+			ID:        mediaID,
+			OwnerID:   input.OwnerID,
+			Provider:  uc.storage.Driver(),
+			Status:    media.StatusPending,
+			Metadata:  input.Metadata,
+			IsPublic:  input.IsPublic,
+			Kind:      media.KindOf(input.ContentType),
+			TakenAt:   takenAt,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		deduplicated, err = uc.save(ctx, newMedia, body, size, digest, input.ContentType)
+		if errors.Is(err, media.ErrBlobChanged) && attempt < saveAttempts {
+			uc.logger.Info("Media blob changed while saving, retrying", zap.String("media_id", mediaID.String()), zap.Int("attempt", attempt))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
 	}
-	input.Metadata["original_url"] = originalURL
-	input.Metadata["original_public_id"] = originalPublicID
-
-	newMedia := &media.Media{
-		ID:        mediaID,
-		OwnerID:   input.OwnerID,
-		Provider:  input.Provider,
-		URL:       originalURL,
-		Status:    media.StatusPending,
-		Metadata:  input.Metadata,
-		IsPublic:  input.IsPublic,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+	originalURL, _ := newMedia.Metadata["original_url"].(string)
+	originalPublicID := newMedia.OriginalKey()
+
+	if newMedia.Status == media.StatusPending {
+		go func() {
+			payload := event.MediaEventPayload{
+				EventType:        event.MediaEventTypeUploaded,
+				MediaID:          newMedia.ID,
+				OwnerID:          newMedia.OwnerID,
+				Provider:         newMedia.Provider,
+				OriginalURL:      originalURL,
+				OriginalPublicID: originalPublicID,
+			}
+			if err := uc.kafkaClient.PublishMediaEvent(context.Background(), payload); err != nil {
+				uc.logger.Error("Failed to publish Kafka 'media.uploaded' event", err, zap.String("media_id", newMedia.ID.String()))
+			}
+		}()
 	}
 
-	if err := uc.mediaRepo.Save(ctx, newMedia); err != nil {
-		go uc.uploader.Delete(context.Background(), originalPublicID)
-		return nil, err
+	return &UploadMediaOutput{MediaID: mediaID, Deduplicated: deduplicated}, nil
+}
+
+// saveAttempts bounds how often save is retried when a concurrent upload or
+// delete changes the blob between looking it up and referencing it.
+const saveAttempts = 3
+
+// save references the owner's blob with the given digest and m's visibility,
+// storing body as a new blob when there is none, and inserts m. It reports
+// whether an existing blob was reused.
+func (uc *UploadMediaUseCase) save(ctx context.Context, m *media.Media, body io.ReadSeeker, size int64, digest, contentType string) (bool, error) {
+	blob, err := uc.mediaRepo.FindBlob(ctx, m.OwnerID, digest, !m.IsPublic)
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		return false, err
+	}
+	deduplicated := blob != nil
+	originalURL := ""
+	if !deduplicated {
+		// The key is named after the media rather than the digest, so files
+		// of a blob deleted concurrently are never mistaken for this one's.
+		originalKey := fmt.Sprintf("users/%s/media/originals/%s", m.OwnerID.String(), m.ID.String())
+		blob = &media.Blob{
+			SHA256:      digest,
+			IsPrivate:   !m.IsPublic,
+			StorageKey:  service.KeyWithVisibility(originalKey, !m.IsPublic),
+			ContentType: contentType,
+			SizeBytes:   size,
+		}
+		// An earlier attempt may have read body already.
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return false, apperror.NewInternal("failed to rewind media file", err)
+		}
+		if originalURL, err = uc.storage.Put(ctx, blob.StorageKey, body, contentType); err != nil {
+			return false, apperror.NewInternal("failed to upload original media file", err)
+		}
+	} else {
+		originalURL = uc.storage.URL(blob.StorageKey, blob.ContentType)
 	}
 
-	go func() {
-		payload := event.MediaEventPayload{
-			EventType:        event.MediaEventTypeUploaded,
-			MediaID:          newMedia.ID,
-			OwnerID:          newMedia.OwnerID,
-			Provider:         newMedia.Provider,
-			OriginalURL:      originalURL,
-			OriginalPublicID: originalPublicID,
+	m.URL = originalURL
+	m.Metadata["original_url"] = originalURL
+	m.Metadata["original_public_id"] = blob.StorageKey
+
+	// A blob that has already been processed lends its variants, so the
+	// reference is ready straight away.
+	if deduplicated {
+		if ready, err := uc.mediaRepo.FindReadyByBlob(ctx, blob.ID); err == nil {
+			m.ShareProcessed(ready, time.Now().UTC())
+		} else if !errors.Is(err, apperror.ErrNotFound) {
+			return false, err
 		}
-		if err := uc.kafkaClient.PublishMediaEvent(context.Background(), payload); err != nil {
-			uc.logger.Error("Failed to publish Kafka 'media.uploaded' event", err, zap.String("media_id", newMedia.ID.String()))
+	}
+
+	if err := uc.mediaRepo.Save(ctx, m, blob); err != nil {
+		// Deleted before returning, so a retry storing the same key cannot
+		// race with it.
+		if !deduplicated {
+			if delErr := uc.storage.Delete(ctx, blob.StorageKey); delErr != nil {
+				uc.logger.Warn("Failed to delete unsaved media original", zap.String("key", blob.StorageKey), zap.Error(delErr))
+			}
 		}
-	}()
+		return false, err
+	}
+	return deduplicated, nil
+}
+
+// stripLocation copies an image without its location into a temporary file,
+// writing it to hash on the way. The caller removes the file.
+func (uc *UploadMediaUseCase) stripLocation(src io.Reader, hash io.Writer) (*os.File, error) {
+	file, err := os.CreateTemp("", "media-*")
+	if err != nil {
+		return nil, apperror.NewInternal("failed to create temporary file", err)
+	}
+	if err := uc.exif.StripLocation(io.MultiWriter(file, hash), src); err != nil {
+		removeTempFile(file)
+		return nil, apperror.NewInternal("failed to strip location from media file", err)
+	}
+	return file, nil
+}
 
-	return &UploadMediaOutput{MediaID: mediaID}, nil
+// readPhotoMetadata returns the photo's EXIF fields, or nil when there are
+// none. Unreadable EXIF never fails the upload.
+func (uc *UploadMediaUseCase) readPhotoMetadata(r io.Reader, mediaID uuid.UUID) *service.PhotoMetadata {
+	photo, err := uc.exif.Extract(r)
+	if err != nil {
+		uc.logger.Warn("Failed to read EXIF, skipping", zap.String("media_id", mediaID.String()), zap.Error(err))
+		return nil
+	}
+	return photo
 }
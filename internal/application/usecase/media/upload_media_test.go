@@ -0,0 +1,91 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// racingMediaRepo has no blob at first, and another upload of the same bytes
+// creates one, already processed, just before Save.
+type racingMediaRepo struct {
+	stubMediaRepo
+	winner *media.Blob
+	saved  []*media.Media
+}
+
+func (r *racingMediaRepo) FindBlob(ctx context.Context, ownerID uuid.UUID, sha256 string, private bool) (*media.Blob, error) {
+	if r.winner.RefCount == 0 {
+		return nil, apperror.NewNotFound("media blob", sha256)
+	}
+	return r.winner, nil
+}
+
+func (r *racingMediaRepo) FindReadyByBlob(ctx context.Context, blobID uuid.UUID) (*media.Media, error) {
+	return &media.Media{URL: "https://media.test/" + r.winner.StorageKey, Status: media.StatusReady, Kind: media.KindImage}, nil
+}
+
+func (r *racingMediaRepo) Save(ctx context.Context, m *media.Media, blob *media.Blob) error {
+	if blob.ID != r.winner.ID {
+		r.winner.RefCount = 1
+		return media.ErrBlobChanged
+	}
+	r.winner.RefCount++
+	m.BlobID = &blob.ID
+	r.saved = append(r.saved, m)
+	return nil
+}
+
+func TestUploadMediaUseCase_Execute_RetriesWhenBlobChanges(t *testing.T) {
+	ownerID := uuid.New()
+	repo := &racingMediaRepo{winner: &media.Blob{ID: uuid.New(), OwnerID: ownerID, StorageKey: "users/o/media/originals/winner"}}
+	storage := testutil.NewFakeStorage()
+	storage.PutAt(repo.winner.StorageKey, pngFile, time.Now())
+	uc := NewUploadMediaUseCase(repo, storage, noExif{}, nil, logger.NewZapLogger("development"))
+
+	out, err := uc.Execute(context.Background(), UploadMediaInput{OwnerID: ownerID, File: bytes.NewReader(pngFile), ContentType: "image/png"})
+	require.NoError(t, err)
+
+	assert.True(t, out.Deduplicated)
+	require.Len(t, repo.saved, 1)
+	saved := repo.saved[0]
+	assert.Equal(t, media.StatusReady, saved.Status)
+	assert.Equal(t, repo.winner.StorageKey, saved.OriginalKey())
+	assert.Equal(t, []string{repo.winner.StorageKey}, storage.Keys(), "the original stored by the losing attempt is removed")
+}
+
+func TestUploadMediaUseCase_Execute_KeepsPublicAndPrivateBlobsApart(t *testing.T) {
+	ownerID := uuid.New()
+	sum := sha256.Sum256(pngFile)
+	repo := &stubMediaRepo{medias: map[uuid.UUID]*media.Media{}, blobs: map[uuid.UUID]*media.Blob{}}
+	storage := testutil.NewFakeStorage()
+	blobs := map[bool]*media.Blob{}
+	for _, private := range []bool{false, true} {
+		b := &media.Blob{ID: uuid.New(), OwnerID: ownerID, SHA256: hex.EncodeToString(sum[:]), IsPrivate: private, StorageKey: "originals/" + uuid.NewString(), RefCount: 1}
+		m := &media.Media{ID: uuid.New(), OwnerID: ownerID, BlobID: &b.ID, IsPublic: !private, Status: media.StatusReady, URL: storage.URL(b.StorageKey, b.ContentType)}
+		repo.blobs[b.ID], repo.medias[m.ID], blobs[private] = b, m, b
+		storage.PutAt(b.StorageKey, pngFile, time.Now())
+	}
+	uc := NewUploadMediaUseCase(repo, storage, noExif{}, nil, logger.NewZapLogger("development"))
+
+	for _, public := range []bool{false, true} {
+		out, err := uc.Execute(context.Background(), UploadMediaInput{OwnerID: ownerID, File: bytes.NewReader(pngFile), ContentType: "image/png", IsPublic: public})
+		require.NoError(t, err)
+		assert.True(t, out.Deduplicated)
+		blob := blobs[!public]
+		assert.Equal(t, blob.ID, *repo.medias[out.MediaID].BlobID, "media shares only the blob of its own visibility")
+		assert.Equal(t, 2, blob.RefCount)
+	}
+}
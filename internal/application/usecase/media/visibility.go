@@ -0,0 +1,189 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// visibilityChanger makes media public or private. Private files are kept
+// under service.PrivatePrefix and a blob is either public or private, so the
+// files follow the media: it takes the blob of the same content with the new
+// visibility, and its files are copied there when there is none.
+type visibilityChanger struct {
+	repo    media.Repository
+	storage service.Storage
+	logger  logger.Logger
+}
+
+// setPublic changes the visibility of m and saves m, along with any other
+// change made to it.
+func (v *visibilityChanger) setPublic(ctx context.Context, m *media.Media, public bool) error {
+	for attempt := 1; ; attempt++ {
+		// Each attempt starts from m as it was, as the last may have taken
+		// the files of a blob that is gone.
+		moved := *m
+		moved.Metadata = maps.Clone(m.Metadata)
+		moved.Variants = slices.Clone(m.Variants)
+		moved.IsPublic = public
+
+		err := v.move(ctx, &moved)
+		if errors.Is(err, media.ErrBlobChanged) && attempt < saveAttempts {
+			v.logger.Info("Media blob changed while changing visibility, retrying", zap.String("media_id", m.ID.String()), zap.Int("attempt", attempt))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		*m = moved
+		return nil
+	}
+}
+
+// move points m at files stored for its visibility, saves it, and removes the
+// files nothing uses any more.
+func (v *visibilityChanger) move(ctx context.Context, m *media.Media) error {
+	oldKeys := fileKeys(m)
+
+	if m.BlobID == nil {
+		// Media uploaded before deduplication own their files outright.
+		copied, err := v.copyFiles(ctx, m, nil)
+		if err == nil {
+			err = v.repo.Update(ctx, m)
+		}
+		if err != nil {
+			v.removeFiles(ctx, copied)
+			return err
+		}
+		v.removeFiles(ctx, unusedKeys(oldKeys, m))
+		return nil
+	}
+
+	digest, _ := m.Metadata["sha256"].(string)
+	blob, err := v.repo.FindBlob(ctx, m.OwnerID, digest, !m.IsPublic)
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		return err
+	}
+
+	var ready *media.Media
+	if blob != nil {
+		ready, err = v.repo.FindReadyByBlob(ctx, blob.ID)
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+	}
+
+	var copied []string
+	if ready != nil {
+		// A processed item of the blob lends its files, as on upload.
+		m.ShareProcessed(ready, time.Now().UTC())
+		m.Metadata["original_public_id"] = ready.OriginalKey()
+		m.Metadata["original_url"] = ready.Metadata["original_url"]
+	} else if copied, err = v.copyFiles(ctx, m, blob); err != nil {
+		v.removeFiles(ctx, copied)
+		return err
+	}
+	if blob == nil {
+		blob = &media.Blob{
+			SHA256:      digest,
+			IsPrivate:   !m.IsPublic,
+			StorageKey:  m.OriginalKey(),
+			ContentType: m.OriginalContentType(),
+			SizeBytes:   metadataSize(m.Metadata),
+		}
+	}
+
+	// The old files go only with the last reference to the old blob.
+	orphaned, err := v.repo.MoveToBlob(ctx, m, blob)
+	if err != nil {
+		v.removeFiles(ctx, copied)
+		return err
+	}
+	if orphaned != nil {
+		v.removeFiles(ctx, unusedKeys(oldKeys, m))
+	}
+	return nil
+}
+
+// copyFiles copies the original and variants of m to their keys for its
+// visibility and points m at the copies. The original is not copied when
+// blob, which already holds it, is given. The keys written are returned even
+// on failure, so that the caller can remove them.
+func (v *visibilityChanger) copyFiles(ctx context.Context, m *media.Media, blob *media.Blob) ([]string, error) {
+	originalKey := m.OriginalKey()
+	var copied []string
+	err := m.MoveFiles(func(key, contentType string) (string, string, error) {
+		if blob != nil && key == originalKey {
+			return blob.StorageKey, v.storage.URL(blob.StorageKey, blob.ContentType), nil
+		}
+		to := service.KeyWithVisibility(key, !m.IsPublic)
+		if to == key {
+			// Already stored for the visibility of m.
+			return key, v.storage.URL(key, contentType), nil
+		}
+		u, err := v.copyObject(ctx, key, to, contentType)
+		if err != nil {
+			return "", "", apperror.NewInternal("failed to copy media file", err)
+		}
+		copied = append(copied, to)
+		return to, u, nil
+	})
+	return copied, err
+}
+
+func (v *visibilityChanger) copyObject(ctx context.Context, from, to, contentType string) (string, error) {
+	rc, err := v.storage.Open(ctx, from)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return v.storage.Put(ctx, to, rc, contentType)
+}
+
+// removeFiles deletes keys from storage, logging rather than returning
+// failures.
+func (v *visibilityChanger) removeFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := v.storage.Delete(ctx, key); err != nil {
+			v.logger.Warn("Failed to delete moved media file from storage", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// fileKeys returns the keys of the original and every variant of m.
+func fileKeys(m *media.Media) []string {
+	var keys []string
+	if key := m.OriginalKey(); key != "" {
+		keys = append(keys, key)
+	}
+	for _, v := range m.Variants {
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
+
+// unusedKeys returns the keys of old that m no longer uses.
+func unusedKeys(old []string, m *media.Media) []string {
+	current := fileKeys(m)
+	return slices.DeleteFunc(old, func(key string) bool { return slices.Contains(current, key) })
+}
+
+// metadataSize is the size recorded at upload, which is a float64 once the
+// metadata has been read back from JSON.
+func metadataSize(metadata map[string]any) int64 {
+	switch n := metadata["size_bytes"].(type) {
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
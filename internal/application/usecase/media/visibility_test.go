@@ -0,0 +1,81 @@
+package media
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/domain/media"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+func TestVisibilityChanger_SetPublic(t *testing.T) {
+	ownerID := uuid.New()
+	log := logger.NewZapLogger("development")
+	storage := testutil.NewFakeStorage()
+	public := &media.Blob{ID: uuid.New(), OwnerID: ownerID, SHA256: "abc", StorageKey: "users/o/media/originals/first", RefCount: 2}
+	thumbKey := "users/o/media/variants/" + public.ID.String() + "/thumbnail.webp"
+	storage.PutAt(public.StorageKey, []byte("png"), time.Now())
+	storage.PutAt(thumbKey, []byte("webp"), time.Now())
+
+	newMedia := func() *media.Media {
+		thumb := media.Variant{Name: media.VariantThumbnail, Key: thumbKey, URL: storage.URL(thumbKey, "image/webp"), ContentType: "image/webp"}
+		return &media.Media{
+			ID:           uuid.New(),
+			OwnerID:      ownerID,
+			BlobID:       &public.ID,
+			IsPublic:     true,
+			Status:       media.StatusReady,
+			URL:          storage.URL(public.StorageKey, "image/png"),
+			ThumbnailURL: &thumb.URL,
+			Variants:     []media.Variant{thumb},
+			Metadata: map[string]any{
+				"original_public_id": public.StorageKey,
+				"original_url":       storage.URL(public.StorageKey, "image/png"),
+				"sha256":             "abc",
+				"content_type":       "image/png",
+				"size_bytes":         float64(3),
+			},
+		}
+	}
+	first, second := newMedia(), newMedia()
+	repo := &stubMediaRepo{
+		medias: map[uuid.UUID]*media.Media{first.ID: first, second.ID: second},
+		blobs:  map[uuid.UUID]*media.Blob{public.ID: public},
+	}
+	tagRepo := &stubTagRepo{set: map[uuid.UUID][]uuid.UUID{}}
+
+	update := NewUpdateMediaUseCase(repo, storage, log)
+	require.NoError(t, update.Execute(context.Background(), UpdateMediaInput{OwnerID: ownerID, MediaID: first.ID, Metadata: map[string]any{"title": "x"}}))
+
+	first = repo.medias[first.ID]
+	assert.False(t, first.IsPublic)
+	assert.Equal(t, "x", first.Metadata["title"])
+	assert.Equal(t, "private/users/o/media/originals/first", first.OriginalKey())
+	assert.Equal(t, "private/"+thumbKey, first.Variants[0].Key)
+	assert.Equal(t, storage.URL(first.OriginalKey(), "image/png"), first.URL)
+	assert.Equal(t, storage.URL("private/"+thumbKey, "image/webp"), *first.ThumbnailURL)
+	private := repo.blobs[*first.BlobID]
+	assert.True(t, private.IsPrivate)
+	assert.Equal(t, int64(3), private.SizeBytes)
+	assert.Equal(t, 1, public.RefCount)
+	assert.Len(t, storage.Keys(), 4, "the public files stay while another media references them")
+
+	bulk := NewBulkMediaUseCase(repo, tagRepo, storage, NewDeleteMediaUseCase(repo, tagRepo, storage, log), log)
+	out, err := bulk.Execute(context.Background(), BulkMediaInput{OwnerID: ownerID, Action: BulkMakePrivate, MediaIDs: []uuid.UUID{second.ID}})
+	require.NoError(t, err)
+	require.NoError(t, out.Results[0].Err)
+
+	second = repo.medias[second.ID]
+	assert.Equal(t, private.ID, *second.BlobID, "the media joins the private blob of the same content")
+	assert.Equal(t, first.URL, second.URL)
+	assert.Equal(t, first.Variants, second.Variants)
+	assert.Equal(t, 2, private.RefCount)
+	assert.NotContains(t, repo.blobs, public.ID)
+	assert.Equal(t, []string{"private/users/o/media/originals/first", "private/" + thumbKey}, storage.Keys(), "the public files go with their last reference")
+}
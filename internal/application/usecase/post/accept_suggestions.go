@@ -0,0 +1,112 @@
+package post
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type AcceptSuggestionsUseCase struct {
+	postRepo post.Repository
+	tagRepo  tag.Repository
+	logger   logger.Logger
+}
+
+func NewAcceptSuggestionsUseCase(pRepo post.Repository, tRepo tag.Repository, log logger.Logger) *AcceptSuggestionsUseCase {
+	return &AcceptSuggestionsUseCase{
+		postRepo: pRepo,
+		tagRepo:  tRepo,
+		logger:   log,
+	}
+}
+
+type AcceptSuggestionsInput struct {
+	PostID               uuid.UUID
+	OwnerID              uuid.UUID
+	AcceptSummary        bool
+	AcceptSEODescription bool
+	// Tags must be among the suggested tags; they are added to the post's tags.
+	Tags []string
+}
+
+type AcceptSuggestionsOutput struct {
+	Post *post.Post
+	Tags []tag.Tag
+}
+
+func (uc *AcceptSuggestionsUseCase) Execute(ctx context.Context, input AcceptSuggestionsInput) (*AcceptSuggestionsOutput, error) {
+	l := uc.logger.With(zap.String("post_id", input.PostID.String()))
+
+	p, err := uc.postRepo.FindByID(ctx, input.PostID, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, ok := p.Suggestions()
+	if !ok {
+		return nil, apperror.NewNotFound("post suggestions", input.PostID.String())
+	}
+
+	suggested := make(map[string]string)
+	for _, name := range append(append([]string{}, suggestions.Tags...), suggestions.NewTags...) {
+		suggested[tagSlug(name)] = name
+	}
+	acceptedTags := make([]string, 0, len(input.Tags))
+	for _, name := range input.Tags {
+		original, ok := suggested[tagSlug(strings.TrimSpace(name))]
+		if !ok {
+			return nil, apperror.NewInvalidInput("tag '"+name+"' was not suggested for this post", nil)
+		}
+		acceptedTags = append(acceptedTags, original)
+	}
+
+	if input.AcceptSummary {
+		p.SetSummary(suggestions.Summary)
+	}
+	if input.AcceptSEODescription {
+		p.SetSEODescription(suggestions.SEODescription)
+	}
+	p.UpdatedAt = time.Now().UTC()
+
+	if err := uc.postRepo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	tags, err := uc.tagRepo.GetTagsForResource(ctx, p.ID, "post")
+	if err != nil {
+		return nil, err
+	}
+	if len(acceptedTags) > 0 {
+		names := make([]string, 0, len(tags)+len(acceptedTags))
+		for _, t := range tags {
+			names = append(names, t.Name)
+		}
+		names = append(names, acceptedTags...)
+
+		tags, err = uc.tagRepo.FindOrCreateTags(ctx, names)
+		if err != nil {
+			return nil, apperror.NewInternal("failed to process tags", err)
+		}
+		tagIDs := make([]uuid.UUID, len(tags))
+		for i, t := range tags {
+			tagIDs[i] = t.ID
+		}
+		if err := uc.tagRepo.SetTagsForResource(ctx, p.ID, "post", tagIDs); err != nil {
+			return nil, apperror.NewInternal("failed to set accepted tags", err)
+		}
+	}
+
+	l.Info("Post suggestions accepted",
+		zap.Bool("summary", input.AcceptSummary),
+		zap.Bool("seo_description", input.AcceptSEODescription),
+		zap.Int("tags", len(acceptedTags)),
+	)
+	return &AcceptSuggestionsOutput{Post: p, Tags: tags}, nil
+}
@@ -0,0 +1,63 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+func TestAcceptSuggestionsUseCase_Execute(t *testing.T) {
+	p := pendingPost()
+	p.Status = post.StatusPublic
+	p.SetSuggestions(post.Suggestions{
+		Summary:        "A first post.",
+		SEODescription: "Hello from Go.",
+		Tags:           []string{"Go"},
+		NewTags:        []string{"Testing"},
+		GeneratedAt:    time.Now(),
+	})
+	repo := &stubPostRepo{posts: map[uuid.UUID]*post.Post{p.ID: p}}
+	tagRepo := &stubTagRepo{forPost: map[uuid.UUID][]tag.Tag{p.ID: {{ID: uuid.New(), Name: "Kafka", Slug: "kafka"}}}}
+	uc := NewAcceptSuggestionsUseCase(repo, tagRepo, logger.NewZapLogger("development"))
+
+	out, err := uc.Execute(context.Background(), AcceptSuggestionsInput{
+		PostID:        p.ID,
+		OwnerID:       p.OwnerID,
+		AcceptSummary: true,
+		Tags:          []string{"testing"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "A first post.", out.Post.Summary())
+	assert.Empty(t, out.Post.SEODescription(), "only requested fields are accepted")
+	require.Len(t, repo.updated, 1)
+
+	names := make([]string, len(out.Tags))
+	for i, tg := range out.Tags {
+		names[i] = tg.Name
+	}
+	assert.Equal(t, []string{"Kafka", "Testing"}, names, "accepted tags are added to existing ones")
+	assert.Len(t, tagRepo.setForIDs[p.ID], 2)
+}
+
+func TestAcceptSuggestionsUseCase_Execute_RejectsUnsuggestedTag(t *testing.T) {
+	p := pendingPost()
+	p.SetSuggestions(post.Suggestions{Tags: []string{"Go"}})
+	repo := &stubPostRepo{posts: map[uuid.UUID]*post.Post{p.ID: p}}
+	uc := NewAcceptSuggestionsUseCase(repo, &stubTagRepo{}, logger.NewZapLogger("development"))
+
+	_, err := uc.Execute(context.Background(), AcceptSuggestionsInput{PostID: p.ID, OwnerID: p.OwnerID, Tags: []string{"rust"}})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+	assert.Empty(t, repo.updated)
+}
@@ -2,6 +2,8 @@ package post
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"strings"
@@ -23,16 +25,18 @@ type CreatePostUseCase struct {
 	postRepo    post.Repository
 	tagRepo     tag.Repository
 	kafkaClient *event.KafkaProducerClient
-	uploader    service.Uploader
+	storage     service.Storage
+	exif        service.ExifService
 	logger      logger.Logger
 }
 
-func NewCreatePostUseCase(pRepo post.Repository, tRepo tag.Repository, kClient *event.KafkaProducerClient, uploader service.Uploader, log logger.Logger) *CreatePostUseCase {
+func NewCreatePostUseCase(pRepo post.Repository, tRepo tag.Repository, kClient *event.KafkaProducerClient, storage service.Storage, exif service.ExifService, log logger.Logger) *CreatePostUseCase {
 	return &CreatePostUseCase{
 		postRepo:    pRepo,
 		tagRepo:     tRepo,
 		kafkaClient: kClient,
-		uploader:    uploader,
+		storage:     storage,
+		exif:        exif,
 		logger:      log,
 	}
 }
@@ -44,7 +48,9 @@ type CreatePostInput struct {
 	Slug            string
 	RequestedStatus post.PostStatus
 	TagNames        []string
+	Language        post.Language
 	File            io.Reader
+	ContentType     string
 	Metadata        map[string]any
 }
 
@@ -58,6 +64,10 @@ func (uc *CreatePostUseCase) Execute(ctx context.Context, input CreatePostInput)
 		input.Slug = strings.ToLower(strings.ReplaceAll(input.Title, " ", "-"))
 	}
 
+	if input.Language == "" {
+		input.Language = post.DefaultLanguage
+	}
+
 	now := time.Now().UTC()
 
 	if input.Metadata == nil {
@@ -72,6 +82,7 @@ func (uc *CreatePostUseCase) Execute(ctx context.Context, input CreatePostInput)
 		Title:           input.Title,
 		ContentMarkdown: input.Content,
 		Status:          post.StatusPending,
+		Language:        input.Language,
 		Metadata:        input.Metadata,
 		VersionHistory:  []post.PostVersion{},
 		CreatedAt:       now,
@@ -83,20 +94,29 @@ func (uc *CreatePostUseCase) Execute(ctx context.Context, input CreatePostInput)
 		return nil, apperror.NewInvalidInput("validation failed", err)
 	}
 
-	originalFolder := fmt.Sprintf("users/%s/originals/", input.OwnerID.String())
-	originalPublicID := newPost.ID.String()
+	originalPublicID := fmt.Sprintf("users/%s/originals/%s", input.OwnerID.String(), newPost.ID.String())
 
-	originalURL, err := uc.uploader.Upload(ctx, input.File, originalFolder, originalPublicID)
+	// Covers are public, so they lose their location like uploaded media.
+	stripped, pw := io.Pipe()
+	go func() { pw.CloseWithError(uc.exif.StripLocation(pw, input.File)) }()
+	defer stripped.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	body := io.TeeReader(stripped, io.MultiWriter(hash, counter))
+	originalURL, err := uc.storage.Put(ctx, originalPublicID, body, input.ContentType)
 	if err != nil {
 		return nil, apperror.NewInternal("failed to upload original file", err)
 	}
-	originalPublicID =  originalFolder + newPost.ID.String()
 
 	if newPost.Metadata == nil {
 		newPost.Metadata = make(map[string]any)
 	}
 	newPost.Metadata["original_url"] = originalURL
 	newPost.Metadata["original_public_id"] = originalPublicID
+	newPost.Metadata["sha256"] = hex.EncodeToString(hash.Sum(nil))
+	newPost.Metadata["size_bytes"] = counter.n
+	newPost.Metadata["content_type"] = input.ContentType
 
 	tags, err := uc.tagRepo.FindOrCreateTags(ctx, input.TagNames)
 	if err != nil {
@@ -104,7 +124,9 @@ func (uc *CreatePostUseCase) Execute(ctx context.Context, input CreatePostInput)
 	}
 
 	if err := uc.postRepo.Save(ctx, newPost); err != nil {
-		go uc.uploader.Delete(context.Background(), originalPublicID)
+		if delErr := uc.storage.Delete(ctx, originalPublicID); delErr != nil {
+			uc.logger.Warn("Failed to delete original of unsaved post, leaving it to the orphaned asset cleanup", zap.String("key", originalPublicID), zap.Error(delErr))
+		}
 		return nil, err
 	}
 
@@ -133,3 +155,11 @@ func (uc *CreatePostUseCase) Execute(ctx context.Context, input CreatePostInput)
 		Slug:   newPost.Slug,
 	}, nil
 }
+
+// countingWriter counts the bytes streamed to storage.
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -2,24 +2,29 @@ package post
 
 import (
 	"context"
+	"errors"
 
+	"github.com/khoahotran/personal-os/internal/domain/album"
 	"github.com/khoahotran/personal-os/internal/domain/post"
 	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
 	"go.uber.org/zap"
 )
 
 type GetPublicPostUseCase struct {
-	postRepo post.Repository
-	tagRepo  tag.Repository
-	logger   logger.Logger
+	postRepo  post.Repository
+	tagRepo   tag.Repository
+	albumRepo album.Repository
+	logger    logger.Logger
 }
 
-func NewGetPublicPostUseCase(pRepo post.Repository, tRepo tag.Repository, log logger.Logger) *GetPublicPostUseCase {
+func NewGetPublicPostUseCase(pRepo post.Repository, tRepo tag.Repository, aRepo album.Repository, log logger.Logger) *GetPublicPostUseCase {
 	return &GetPublicPostUseCase{
-		postRepo: pRepo,
-		tagRepo:  tRepo,
-		logger:   log,
+		postRepo:  pRepo,
+		tagRepo:   tRepo,
+		albumRepo: aRepo,
+		logger:    log,
 	}
 }
 
@@ -30,6 +35,8 @@ type GetPublicPostInput struct {
 type GetPublicPostOutput struct {
 	Post *post.Post
 	Tags []tag.Tag
+	// Albums are the public albums embedded in the post, with their items.
+	Albums []*album.Album
 }
 
 func (uc *GetPublicPostUseCase) Execute(ctx context.Context, input GetPublicPostInput) (*GetPublicPostOutput, error) {
@@ -44,7 +51,33 @@ func (uc *GetPublicPostUseCase) Execute(ctx context.Context, input GetPublicPost
 	}
 
 	return &GetPublicPostOutput{
-		Post: p,
-		Tags: tags,
+		Post:   p,
+		Tags:   tags,
+		Albums: uc.embeddedAlbums(ctx, p),
 	}, nil
 }
+
+// embeddedAlbums loads the albums the post embeds. Albums that are missing,
+// private or another owner's are left out rather than failing the post.
+func (uc *GetPublicPostUseCase) embeddedAlbums(ctx context.Context, p *post.Post) []*album.Album {
+	var albums []*album.Album
+	for _, slug := range album.EmbeddedSlugs(p.ContentMarkdown) {
+		l := uc.logger.With(zap.String("post_id", p.ID.String()), zap.String("album_slug", slug))
+		a, err := uc.albumRepo.FindPublicBySlug(ctx, slug)
+		if err != nil {
+			if !errors.Is(err, apperror.ErrNotFound) {
+				l.Warn("Failed to load embedded album", zap.Error(err))
+			}
+			continue
+		}
+		if a.OwnerID != p.OwnerID {
+			continue
+		}
+		if a.Items, err = uc.albumRepo.ListMedia(ctx, a.ID, true); err != nil {
+			l.Warn("Failed to load embedded album media", zap.Error(err))
+			continue
+		}
+		albums = append(albums, a)
+	}
+	return albums
+}
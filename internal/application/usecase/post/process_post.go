@@ -1,26 +1,67 @@
 package post
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/khoahotran/personal-os/adapters/event"
 	"github.com/khoahotran/personal-os/internal/application/service"
 	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
 	"go.uber.org/zap"
 )
 
+const (
+	variantOG        = "og"
+	variantThumbnail = "thumbnail"
+)
+
+// suggestionTimeout bounds the LLM call made after a post is published, so a
+// slow model holds up the worker for at most this long.
+const suggestionTimeout = 30 * time.Second
+
+var postImageVariants = []service.ImageVariant{
+	{Name: variantOG, Width: 1200, Height: 630, Fit: service.FitFill},
+	{Name: variantThumbnail, Width: 400, Fit: service.FitLimit},
+}
+
 type ProcessPostEventUseCase struct {
-	postRepo post.Repository
-	uploader service.Uploader
-	embedder service.EmbeddingService
-	logger   logger.Logger
+	postRepo   post.Repository
+	tagRepo    tag.Repository
+	promptRepo prompt.Repository
+	storage    service.Storage
+	processor  service.ImageProcessor
+	embedder   service.EmbeddingService
+	llm        service.LLMService
+	logger     logger.Logger
 }
 
-func NewProcessPostEventUseCase(pr post.Repository, up service.Uploader, em service.EmbeddingService, log logger.Logger) *ProcessPostEventUseCase {
-	return &ProcessPostEventUseCase{postRepo: pr, uploader: up, embedder: em, logger: log}
+func NewProcessPostEventUseCase(
+	pr post.Repository,
+	tr tag.Repository,
+	promptRepo prompt.Repository,
+	storage service.Storage,
+	processor service.ImageProcessor,
+	em service.EmbeddingService,
+	llm service.LLMService,
+	log logger.Logger,
+) *ProcessPostEventUseCase {
+	return &ProcessPostEventUseCase{
+		postRepo:   pr,
+		tagRepo:    tr,
+		promptRepo: promptRepo,
+		storage:    storage,
+		processor:  processor,
+		embedder:   em,
+		llm:        llm,
+		logger:     log,
+	}
 }
 
 func (uc *ProcessPostEventUseCase) Execute(ctx context.Context, payload event.PostEventPayload) error {
@@ -40,37 +81,18 @@ func (uc *ProcessPostEventUseCase) Execute(ctx context.Context, payload event.Po
 		return nil
 	}
 
-	cldClient := uc.uploader.GetClient()
-	if cldClient == nil {
-		return apperror.NewInternal("could not get cloudinary client from uploader", nil)
-	}
-
-	originalPublicID, ok := p.Metadata["original_public_id"].(string)
-	if !ok || originalPublicID == "" {
+	originalKey, ok := p.Metadata["original_public_id"].(string)
+	if !ok || originalKey == "" {
 		return apperror.NewInvalidInput("original_public_id not found in metadata", nil)
 	}
 
-	// Get original image asset
-	imgAsset, err := cldClient.Image(originalPublicID)
-	if err != nil {
-		return apperror.NewInternal("failed to create cloudinary asset", err)
-	}
-
-	// OG image transform
-	imgAsset.Transformation = "c_fill,g_auto,w_1200,h_630"
-	ogImageURL, err := imgAsset.String()
+	urls, err := uc.renderImages(ctx, p, originalKey)
 	if err != nil {
-		return apperror.NewInternal("failed to build OG image URL", err)
+		return err
 	}
 
-	// Thumbnail transform
-	imgAsset.Transformation = "c_limit,w_400"
-	thumbURL, err := imgAsset.String()
-	if err != nil {
-		return apperror.NewInternal("failed to build Thumbnail URL", err)
-	}
-
-	if payload.EventType == event.PostEventTypeCreated || payload.EventType == event.PostEventTypeUpdated {
+	contentChanged := payload.EventType == event.PostEventTypeCreated || payload.EventType == event.PostEventTypeUpdated || payload.EventType == event.PostEventTypeReprocess
+	if contentChanged {
 		l.Info("Generating embeddings for post content...")
 		embedding, err := uc.embedder.GenerateEmbeddings(ctx, p.ContentMarkdown)
 		if err != nil {
@@ -86,12 +108,79 @@ func (uc *ProcessPostEventUseCase) Execute(ctx context.Context, payload event.Po
 		requestedStatusStr = string(post.StatusDraft)
 	}
 	p.Status = post.PostStatus(requestedStatusStr)
-	p.MarkAsReady(ogImageURL, thumbURL)
+	p.MarkAsReady(urls[variantOG], urls[variantThumbnail])
 
 	if err := uc.postRepo.Update(ctx, p); err != nil {
 		return apperror.NewInternal("failed to update post with OG image", err)
 	}
 
 	l.Info("Successfully updated Post with OG Image", zap.String("status", string(p.Status)))
+
+	if contentChanged {
+		uc.suggest(ctx, p, l)
+	}
 	return nil
 }
+
+// renderImages generates the OG image and thumbnail from the uploaded cover
+// and returns their URLs by variant name.
+func (uc *ProcessPostEventUseCase) renderImages(ctx context.Context, p *post.Post, originalKey string) (map[string]string, error) {
+	original, err := uc.storage.Open(ctx, originalKey)
+	if err != nil {
+		return nil, err
+	}
+	defer original.Close()
+
+	processed, err := uc.processor.Process(ctx, original, postImageVariants)
+	if errors.Is(err, service.ErrUnsupportedImage) {
+		return nil, apperror.NewInvalidInput("post image is not a supported image format", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make(map[string]string, len(processed.Variants))
+	for _, v := range processed.Variants {
+		key := fmt.Sprintf("%s/%s.%s", post.VariantsFolder(p.OwnerID, p.ID), v.Name, v.Extension)
+		url, err := uc.storage.Put(ctx, key, bytes.NewReader(v.Data), v.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		urls[v.Name] = url
+	}
+	return urls, nil
+}
+
+// suggest stores authoring suggestions in the post metadata once the post is
+// published. Failures are logged and ignored so that an unavailable LLM never
+// blocks publishing.
+func (uc *ProcessPostEventUseCase) suggest(ctx context.Context, p *post.Post, l logger.Logger) {
+	existingTags, err := uc.tagRepo.ListAll(ctx)
+	if err != nil {
+		l.Warn("Failed to list tags for suggestions", zap.Error(err))
+		existingTags = nil
+	}
+
+	l.Info("Generating authoring suggestions...")
+	llmCtx, cancel := context.WithTimeout(ctx, suggestionTimeout)
+	defer cancel()
+	suggestions, err := generateSuggestions(llmCtx, uc.llm, uc.promptRepo, p, existingTags)
+	if err != nil {
+		l.Warn("Failed to generate authoring suggestions, skipping", zap.Error(err))
+		return
+	}
+
+	// The post may have been edited while the LLM was answering, so only the
+	// suggestions are written. An edit queues another event, which suggests
+	// again from the new content.
+	stored, err := uc.postRepo.UpdateSuggestions(ctx, p.ID, p.OwnerID, suggestions)
+	if err != nil {
+		l.Warn("Failed to store authoring suggestions", zap.Error(err))
+		return
+	}
+	if !stored {
+		l.Info("Post changed while generating suggestions, skipping")
+		return
+	}
+	l.Info("Authoring suggestions generated", zap.Int("tag_count", len(suggestions.Tags)+len(suggestions.NewTags)))
+}
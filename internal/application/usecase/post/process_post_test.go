@@ -0,0 +1,226 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/adapters/embedding"
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/adapters/llm"
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/internal/testutil"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type stubPostRepo struct {
+	post.Repository
+	posts     map[uuid.UUID]*post.Post
+	updated   []*post.Post
+	suggested map[uuid.UUID]post.Suggestions
+}
+
+func (r *stubPostRepo) FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*post.Post, error) {
+	p, ok := r.posts[id]
+	if !ok || p.OwnerID != ownerID {
+		return nil, post.ErrPostNotFound
+	}
+	return p, nil
+}
+
+// Update records a copy, so later changes to p do not rewrite history.
+func (r *stubPostRepo) Update(ctx context.Context, p *post.Post) error {
+	saved := *p
+	saved.Metadata = maps.Clone(p.Metadata)
+	r.updated = append(r.updated, &saved)
+	return nil
+}
+
+func (r *stubPostRepo) UpdateSuggestions(ctx context.Context, id uuid.UUID, ownerID uuid.UUID, s post.Suggestions) (bool, error) {
+	p, ok := r.posts[id]
+	if !ok || p.OwnerID != ownerID || p.Status == post.StatusPending {
+		return false, nil
+	}
+	if r.suggested == nil {
+		r.suggested = map[uuid.UUID]post.Suggestions{}
+	}
+	r.suggested[id] = s
+	return true, nil
+}
+
+type stubTagRepo struct {
+	tag.Repository
+	all       []tag.Tag
+	forPost   map[uuid.UUID][]tag.Tag
+	setForIDs map[uuid.UUID][]uuid.UUID
+}
+
+func (r *stubTagRepo) ListAll(ctx context.Context) ([]tag.Tag, error) { return r.all, nil }
+
+func (r *stubTagRepo) GetTagsForResource(ctx context.Context, resourceID uuid.UUID, resourceType string) ([]tag.Tag, error) {
+	return r.forPost[resourceID], nil
+}
+
+func (r *stubTagRepo) FindOrCreateTags(ctx context.Context, names []string) ([]tag.Tag, error) {
+	tags := make([]tag.Tag, len(names))
+	for i, n := range names {
+		tags[i] = tag.Tag{ID: uuid.NewSHA1(uuid.Nil, []byte(n)), Name: n, Slug: n}
+	}
+	return tags, nil
+}
+
+func (r *stubTagRepo) SetTagsForResource(ctx context.Context, resourceID uuid.UUID, resourceType string, tagIDs []uuid.UUID) error {
+	if r.setForIDs == nil {
+		r.setForIDs = map[uuid.UUID][]uuid.UUID{}
+	}
+	r.setForIDs[resourceID] = tagIDs
+	return nil
+}
+
+// stubPromptRepo has no stored versions, so the built-in templates are used.
+type stubPromptRepo struct {
+	prompt.Repository
+}
+
+func (stubPromptRepo) FindActive(ctx context.Context, name string) (*prompt.Template, error) {
+	return nil, prompt.ErrTemplateNotFound
+}
+
+// stubProcessor renders every variant as its own name.
+type stubProcessor struct{}
+
+func (stubProcessor) Process(ctx context.Context, r io.Reader, variants []service.ImageVariant) (*service.ProcessedImage, error) {
+	out := &service.ProcessedImage{Width: 1600, Height: 900}
+	for _, v := range variants {
+		out.Variants = append(out.Variants, service.EncodedImage{Name: v.Name, ContentType: "image/jpeg", Extension: "jpg", Data: []byte(v.Name)})
+	}
+	return out, nil
+}
+
+// coverStorage holds the cover image uploaded with pendingPost.
+func coverStorage() *testutil.FakeStorage {
+	storage := testutil.NewFakeStorage()
+	storage.PutAt("posts/hello-world", []byte("original"), time.Now())
+	return storage
+}
+
+func pendingPost() *post.Post {
+	return &post.Post{
+		ID:              uuid.New(),
+		OwnerID:         uuid.New(),
+		Slug:            "hello-world",
+		Title:           "Hello world",
+		ContentMarkdown: "# Hello world",
+		Status:          post.StatusPending,
+		Metadata: map[string]any{
+			"original_public_id": "posts/hello-world",
+			"requested_status":   string(post.StatusPublic),
+		},
+	}
+}
+
+func TestProcessPostEventUseCase_Execute_PendingPost(t *testing.T) {
+	p := pendingPost()
+	repo := &stubPostRepo{posts: map[uuid.UUID]*post.Post{p.ID: p}}
+	tagRepo := &stubTagRepo{all: []tag.Tag{{ID: uuid.New(), Name: "Go", Slug: "go"}, {ID: uuid.New(), Name: "Kafka", Slug: "kafka"}}}
+	em, fakeLLM := embedding.NewFakeEmbedder(8), llm.NewFakeLLM()
+	uc := NewProcessPostEventUseCase(repo, tagRepo, stubPromptRepo{}, coverStorage(), stubProcessor{}, em, fakeLLM, logger.NewZapLogger("development"))
+	fakeLLM.Respond("Here you go:\n```json\n" + `{"summary": "A first post.", "seo_description": "Hello from Go.", "tags": ["go", "#Kafka", "Testing", "go"]}` + "\n```")
+
+	err := uc.Execute(context.Background(), event.PostEventPayload{EventType: event.PostEventTypeCreated, PostID: p.ID, OwnerID: p.OwnerID})
+	require.NoError(t, err)
+
+	// The post is published first, then only its suggestions are written.
+	require.Len(t, repo.updated, 1)
+	got := repo.updated[0]
+	assert.Equal(t, post.StatusPublic, got.Status)
+	_, ok := got.Suggestions()
+	assert.False(t, ok)
+	require.NotNil(t, got.OgImageURL)
+	require.NotNil(t, got.ThumbnailURL)
+	prefix := "https://media.test/users/" + p.OwnerID.String() + "/variants/" + p.ID.String()
+	assert.Equal(t, prefix+"/og.jpg", *got.OgImageURL)
+	assert.Equal(t, prefix+"/thumbnail.jpg", *got.ThumbnailURL)
+	assert.Equal(t, []string{"# Hello world"}, em.Inputs())
+	assert.Len(t, got.Embedding.Slice(), 8)
+
+	suggestions, ok := repo.suggested[p.ID]
+	require.True(t, ok)
+	assert.Equal(t, "A first post.", suggestions.Summary)
+	assert.Equal(t, "Hello from Go.", suggestions.SEODescription)
+	assert.Equal(t, []string{"Go", "Kafka"}, suggestions.Tags, "suggested tags are matched to existing tag names")
+	assert.Equal(t, []string{"Testing"}, suggestions.NewTags)
+	assert.Empty(t, got.Summary(), "suggestions are not accepted automatically")
+
+	prompts := fakeLLM.Prompts()
+	require.Len(t, prompts, 1)
+	assert.Contains(t, prompts[0].User, "Existing tags: Go, Kafka")
+}
+
+func TestProcessPostEventUseCase_Execute_SuggestionFailureDoesNotBlock(t *testing.T) {
+	p := pendingPost()
+	repo := &stubPostRepo{posts: map[uuid.UUID]*post.Post{p.ID: p}}
+	em, fakeLLM := embedding.NewFakeEmbedder(8), llm.NewFakeLLM()
+	uc := NewProcessPostEventUseCase(repo, &stubTagRepo{}, stubPromptRepo{}, coverStorage(), stubProcessor{}, em, fakeLLM, logger.NewZapLogger("development"))
+	fakeLLM.FailWith(errors.New("llm down"))
+
+	err := uc.Execute(context.Background(), event.PostEventPayload{EventType: event.PostEventTypeCreated, PostID: p.ID, OwnerID: p.OwnerID})
+	require.NoError(t, err)
+
+	require.Len(t, repo.updated, 1)
+	assert.Equal(t, post.StatusPublic, repo.updated[0].Status)
+	assert.Empty(t, repo.suggested)
+}
+
+func TestProcessPostEventUseCase_Execute_FallsBackToDraft(t *testing.T) {
+	p := pendingPost()
+	delete(p.Metadata, "requested_status")
+	repo := &stubPostRepo{posts: map[uuid.UUID]*post.Post{p.ID: p}}
+	em, fakeLLM := embedding.NewFakeEmbedder(8), llm.NewFakeLLM()
+	uc := NewProcessPostEventUseCase(repo, &stubTagRepo{}, stubPromptRepo{}, coverStorage(), stubProcessor{}, em, fakeLLM, logger.NewZapLogger("development"))
+
+	err := uc.Execute(context.Background(), event.PostEventPayload{EventType: event.PostEventTypeCreated, PostID: p.ID, OwnerID: p.OwnerID})
+	require.NoError(t, err)
+
+	require.Len(t, repo.updated, 1)
+	assert.Equal(t, post.StatusDraft, repo.updated[0].Status)
+}
+
+func TestProcessPostEventUseCase_Execute_SkipsMissingAndNonPending(t *testing.T) {
+	p := pendingPost()
+	p.Status = post.StatusPublic
+	repo := &stubPostRepo{posts: map[uuid.UUID]*post.Post{p.ID: p}}
+	em, fakeLLM := embedding.NewFakeEmbedder(8), llm.NewFakeLLM()
+	uc := NewProcessPostEventUseCase(repo, &stubTagRepo{}, stubPromptRepo{}, coverStorage(), stubProcessor{}, em, fakeLLM, logger.NewZapLogger("development"))
+
+	err := uc.Execute(context.Background(), event.PostEventPayload{EventType: event.PostEventTypeUpdated, PostID: uuid.New(), OwnerID: p.OwnerID})
+	require.NoError(t, err)
+
+	err = uc.Execute(context.Background(), event.PostEventPayload{EventType: event.PostEventTypeUpdated, PostID: p.ID, OwnerID: p.OwnerID})
+	require.NoError(t, err)
+
+	assert.Empty(t, repo.updated)
+	assert.Empty(t, em.Inputs())
+}
+
+func TestProcessPostEventUseCase_Execute_EmbedderFailure(t *testing.T) {
+	p := pendingPost()
+	repo := &stubPostRepo{posts: map[uuid.UUID]*post.Post{p.ID: p}}
+	em, fakeLLM := embedding.NewFakeEmbedder(8), llm.NewFakeLLM()
+	uc := NewProcessPostEventUseCase(repo, &stubTagRepo{}, stubPromptRepo{}, coverStorage(), stubProcessor{}, em, fakeLLM, logger.NewZapLogger("development"))
+	em.FailWith(errors.New("embedder down"))
+
+	err := uc.Execute(context.Background(), event.PostEventPayload{EventType: event.PostEventTypeCreated, PostID: p.ID, OwnerID: p.OwnerID})
+	require.Error(t, err)
+	assert.Empty(t, repo.updated)
+}
@@ -0,0 +1,57 @@
+package post
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// ReprocessPostUseCase re-emits the processing event of a post stuck in
+// 'pending', such as one whose event was lost or failed in the worker.
+type ReprocessPostUseCase struct {
+	postRepo    post.Repository
+	kafkaClient *event.KafkaProducerClient
+	logger      logger.Logger
+}
+
+func NewReprocessPostUseCase(pRepo post.Repository, kClient *event.KafkaProducerClient, log logger.Logger) *ReprocessPostUseCase {
+	return &ReprocessPostUseCase{
+		postRepo:    pRepo,
+		kafkaClient: kClient,
+		logger:      log,
+	}
+}
+
+type ReprocessPostInput struct {
+	PostID  uuid.UUID
+	OwnerID uuid.UUID
+}
+
+func (uc *ReprocessPostUseCase) Execute(ctx context.Context, input ReprocessPostInput) error {
+	p, err := uc.postRepo.FindByID(ctx, input.PostID, input.OwnerID)
+	if err != nil {
+		if errors.Is(err, post.ErrPostNotFound) {
+			return apperror.NewNotFound("post", input.PostID.String())
+		}
+		return err
+	}
+	if p.Status != post.StatusPending {
+		return apperror.NewAppError(apperror.ErrConflict, "post is not pending", "only posts in 'pending' state can be reprocessed", nil)
+	}
+
+	err = uc.kafkaClient.PublishPostEvent(ctx, event.PostEventPayload{
+		EventType: event.PostEventTypeReprocess,
+		PostID:    p.ID,
+		OwnerID:   p.OwnerID,
+	})
+	if err != nil {
+		return apperror.NewInternal("failed to publish post reprocess event", err)
+	}
+	return nil
+}
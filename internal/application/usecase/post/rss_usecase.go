@@ -48,10 +48,15 @@ func (uc *RSSUseCase) Execute(ctx context.Context) (*feeds.Feed, error) {
 
 		postURL := fmt.Sprintf("http://localhost:3000/blog/%s", p.Slug)
 
+		description := p.Summary()
+		if description == "" {
+			description = p.ContentMarkdown
+		}
+
 		item := &feeds.Item{
 			Title:       p.Title,
 			Link:        &feeds.Link{Href: postURL},
-			Description: p.ContentMarkdown,
+			Description: description,
 			Created:     p.CreatedAt,
 		}
 		if p.PublishedAt != nil {
@@ -0,0 +1,116 @@
+package post
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/internal/domain/post"
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
+	"github.com/khoahotran/personal-os/internal/domain/tag"
+	"github.com/khoahotran/personal-os/pkg/text"
+)
+
+const (
+	maxSuggestedTags     = 5
+	maxAssistContentRune = 6000
+	maxSEODescriptionLen = 160
+)
+
+type llmSuggestions struct {
+	Summary        string   `json:"summary"`
+	SEODescription string   `json:"seo_description"`
+	Tags           []string `json:"tags"`
+}
+
+// generateSuggestions asks the LLM for a summary, an SEO description and tags
+// for p, matching the suggested tags against existingTags.
+func generateSuggestions(ctx context.Context, llm service.LLMService, promptRepo prompt.Repository, p *post.Post, existingTags []tag.Tag) (post.Suggestions, error) {
+	names := make([]string, len(existingTags))
+	for i, t := range existingTags {
+		names[i] = t.Name
+	}
+
+	t, err := prompt.Resolve(ctx, promptRepo, prompt.NamePostAssist)
+	if err != nil {
+		return post.Suggestions{}, fmt.Errorf("failed to load post assist template: %w", err)
+	}
+	system, user, err := t.Render(prompt.Variables{
+		Title:   p.Title,
+		Content: text.Truncate(p.ContentMarkdown, maxAssistContentRune, ""),
+		Tags:    strings.Join(names, ", "),
+	})
+	if err != nil {
+		return post.Suggestions{}, err
+	}
+
+	response, err := llm.GenerateChatResponse(ctx, service.ChatPrompt{System: system, User: user})
+	if err != nil {
+		return post.Suggestions{}, fmt.Errorf("llm request failed: %w", err)
+	}
+	parsed, err := parseLLMSuggestions(response)
+	if err != nil {
+		return post.Suggestions{}, err
+	}
+
+	matched, unmatched := matchTags(parsed.Tags, existingTags)
+	return post.Suggestions{
+		Summary:        strings.TrimSpace(parsed.Summary),
+		SEODescription: text.Truncate(strings.TrimSpace(parsed.SEODescription), maxSEODescriptionLen, ""),
+		Tags:           matched,
+		NewTags:        unmatched,
+		GeneratedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// parseLLMSuggestions extracts the JSON object from the response, tolerating
+// prose or code fences around it.
+func parseLLMSuggestions(response string) (llmSuggestions, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return llmSuggestions{}, fmt.Errorf("llm response contains no JSON object")
+	}
+	var out llmSuggestions
+	if err := json.Unmarshal([]byte(response[start:end+1]), &out); err != nil {
+		return llmSuggestions{}, fmt.Errorf("failed to decode llm suggestions: %w", err)
+	}
+	return out, nil
+}
+
+// matchTags maps suggestions onto existing tag names by slug. Suggestions
+// without a matching tag are returned separately. At most maxSuggestedTags
+// are kept in total.
+func matchTags(suggested []string, existing []tag.Tag) (matched, unmatched []string) {
+	bySlug := make(map[string]string, len(existing))
+	for _, t := range existing {
+		bySlug[t.Slug] = t.Name
+	}
+
+	matched, unmatched = []string{}, []string{}
+	seen := make(map[string]bool)
+	for _, s := range suggested {
+		s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "#"))
+		slug := tagSlug(s)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		if len(matched)+len(unmatched) >= maxSuggestedTags {
+			break
+		}
+		seen[slug] = true
+		if name, ok := bySlug[slug]; ok {
+			matched = append(matched, name)
+		} else {
+			unmatched = append(unmatched, s)
+		}
+	}
+	return matched, unmatched
+}
+
+// tagSlug mirrors how the tag repository derives slugs from names.
+func tagSlug(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
@@ -39,6 +39,8 @@ type UpdatePostInput struct {
 	Slug    string
 	Status  post.PostStatus
 	Tags    []string
+	// Language is kept unchanged when empty.
+	Language post.Language
 }
 
 type UpdatePostOutput struct {
@@ -59,6 +61,9 @@ func (uc *UpdatePostUseCase) Execute(ctx context.Context, input UpdatePostInput)
 	existingPost.ContentMarkdown = input.Content
 	existingPost.Slug = input.Slug
 	existingPost.Status = input.Status
+	if input.Language != "" {
+		existingPost.Language = input.Language
+	}
 	existingPost.UpdatedAt = time.Now().UTC()
 
 	if err := existingPost.Validate(); err != nil {
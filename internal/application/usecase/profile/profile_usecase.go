@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
 	"github.com/khoahotran/personal-os/internal/domain/profile"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
@@ -13,12 +15,14 @@ import (
 
 type ProfileUseCase struct {
 	profileRepo profile.Repository
+	kafkaClient *event.KafkaProducerClient
 	logger      logger.Logger
 }
 
-func NewProfileUseCase(repo profile.Repository, log logger.Logger) *ProfileUseCase {
+func NewProfileUseCase(repo profile.Repository, kClient *event.KafkaProducerClient, log logger.Logger) *ProfileUseCase {
 	return &ProfileUseCase{
 		profileRepo: repo,
+		kafkaClient: kClient,
 		logger:      log,
 	}
 }
@@ -45,6 +49,7 @@ type UpdateProfileInput struct {
 	Bio            string
 	CareerTimeline []profile.CareerMilestone
 	ThemeSettings  map[string]any
+	ChatPersona    *string
 }
 
 type UpdateProfileOutput struct {
@@ -64,11 +69,26 @@ func (uc *ProfileUseCase) ExecuteUpdateProfile(ctx context.Context, input Update
 	if input.ThemeSettings != nil {
 		p.ThemeSettings = input.ThemeSettings
 	}
+	if input.ChatPersona != nil {
+		p.ChatPersona = *input.ChatPersona
+	}
 
 	if err := uc.profileRepo.Upsert(ctx, p); err != nil {
 		uc.logger.Error("Failed to update profile", err, zap.String("owner_id", input.OwnerID.String()))
 		return nil, apperror.NewInternal("failed to update profile", err)
 	}
 
+	go func() {
+		err := uc.kafkaClient.PublishKnowledgeEvent(context.Background(), event.KnowledgeEventPayload{
+			EventType:    event.KnowledgeEventTypeUpserted,
+			ResourceType: string(knowledge.ResourceProfile),
+			ResourceID:   p.OwnerID,
+			OwnerID:      p.OwnerID,
+		})
+		if err != nil {
+			uc.logger.Error("Failed to publish Kafka 'updated' knowledge event", err, zap.String("owner_id", p.OwnerID.String()))
+		}
+	}()
+
 	return &UpdateProfileOutput{Profile: p}, nil
 }
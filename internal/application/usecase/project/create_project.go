@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
 	"github.com/khoahotran/personal-os/internal/domain/project"
 	"github.com/khoahotran/personal-os/internal/domain/tag"
 	"github.com/khoahotran/personal-os/pkg/apperror"
@@ -16,13 +18,15 @@ import (
 type CreateProjectUseCase struct {
 	projectRepo project.Repository
 	tagRepo     tag.Repository
+	kafkaClient *event.KafkaProducerClient
 	logger      logger.Logger
 }
 
-func NewCreateProjectUseCase(pRepo project.Repository, tRepo tag.Repository, log logger.Logger) *CreateProjectUseCase {
+func NewCreateProjectUseCase(pRepo project.Repository, tRepo tag.Repository, kClient *event.KafkaProducerClient, log logger.Logger) *CreateProjectUseCase {
 	return &CreateProjectUseCase{
 		projectRepo: pRepo,
 		tagRepo:     tRepo,
+		kafkaClient: kClient,
 		logger:      log,
 	}
 }
@@ -86,6 +90,18 @@ func (uc *CreateProjectUseCase) Execute(ctx context.Context, input CreateProject
 		uc.logger.Warn("Failed to set tags for new project", zap.String("project_id", newProject.ID.String()), zap.Error(err))
 	}
 
+	go func() {
+		err := uc.kafkaClient.PublishKnowledgeEvent(context.Background(), event.KnowledgeEventPayload{
+			EventType:    event.KnowledgeEventTypeUpserted,
+			ResourceType: string(knowledge.ResourceProject),
+			ResourceID:   newProject.ID,
+			OwnerID:      newProject.OwnerID,
+		})
+		if err != nil {
+			uc.logger.Error("Failed to publish Kafka 'created' knowledge event", err, zap.String("project_id", newProject.ID.String()))
+		}
+	}()
+
 	return &CreateProjectOutput{
 		ProjectID: newProject.ID,
 		Slug:      newProject.Slug,
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/adapters/event"
+	"github.com/khoahotran/personal-os/internal/domain/knowledge"
 	"github.com/khoahotran/personal-os/internal/domain/project"
 	"github.com/khoahotran/personal-os/internal/domain/tag"
 	"github.com/khoahotran/personal-os/pkg/apperror"
@@ -15,11 +17,12 @@ import (
 type UpdateProjectUseCase struct {
 	projectRepo project.Repository
 	tagRepo     tag.Repository
+	kafkaClient *event.KafkaProducerClient
 	logger      logger.Logger
 }
 
-func NewUpdateProjectUseCase(pRepo project.Repository, tRepo tag.Repository, log logger.Logger) *UpdateProjectUseCase {
-	return &UpdateProjectUseCase{projectRepo: pRepo, tagRepo: tRepo, logger: log}
+func NewUpdateProjectUseCase(pRepo project.Repository, tRepo tag.Repository, kClient *event.KafkaProducerClient, log logger.Logger) *UpdateProjectUseCase {
+	return &UpdateProjectUseCase{projectRepo: pRepo, tagRepo: tRepo, kafkaClient: kClient, logger: log}
 }
 
 type UpdateProjectInput struct {
@@ -74,5 +77,17 @@ func (uc *UpdateProjectUseCase) Execute(ctx context.Context, input UpdateProject
 		uc.logger.Warn("Failed to set tags during project update", zap.String("project_id", p.ID.String()), zap.Error(err))
 	}
 
+	go func() {
+		err := uc.kafkaClient.PublishKnowledgeEvent(context.Background(), event.KnowledgeEventPayload{
+			EventType:    event.KnowledgeEventTypeUpserted,
+			ResourceType: string(knowledge.ResourceProject),
+			ResourceID:   p.ID,
+			OwnerID:      p.OwnerID,
+		})
+		if err != nil {
+			uc.logger.Error("Failed to publish Kafka 'updated' knowledge event", err, zap.String("project_id", p.ID.String()))
+		}
+	}()
+
 	return &UpdateProjectOutput{Project: p}, nil
 }
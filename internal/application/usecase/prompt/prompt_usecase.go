@@ -0,0 +1,110 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/khoahotran/personal-os/internal/domain/prompt"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+type PromptUseCase struct {
+	promptRepo prompt.Repository
+	logger     logger.Logger
+}
+
+func NewPromptUseCase(repo prompt.Repository, log logger.Logger) *PromptUseCase {
+	return &PromptUseCase{
+		promptRepo: repo,
+		logger:     log,
+	}
+}
+
+// ListActive returns the template currently used for every known name,
+// falling back to the built-in default (version 0).
+func (uc *PromptUseCase) ListActive(ctx context.Context) ([]*prompt.Template, error) {
+	templates := make([]*prompt.Template, 0, len(prompt.Names))
+	for _, name := range prompt.Names {
+		t, err := prompt.Resolve(ctx, uc.promptRepo, name)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+type GetTemplateOutput struct {
+	Active   *prompt.Template
+	Default  *prompt.Template
+	Versions []*prompt.Template
+}
+
+func (uc *PromptUseCase) GetTemplate(ctx context.Context, name string) (*GetTemplateOutput, error) {
+	def, err := prompt.Default(name)
+	if err != nil {
+		if errors.Is(err, prompt.ErrUnknownTemplate) {
+			return nil, apperror.NewNotFound("prompt template", name)
+		}
+		return nil, apperror.NewInternal("failed to load default prompt template", err)
+	}
+	versions, err := uc.promptRepo.ListVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	active := def
+	for _, v := range versions {
+		if v.IsActive {
+			active = v
+			break
+		}
+	}
+	return &GetTemplateOutput{Active: active, Default: def, Versions: versions}, nil
+}
+
+type SaveTemplateInput struct {
+	Name   string
+	System string
+	User   string
+}
+
+// SaveTemplate stores a new version of the template and activates it.
+func (uc *PromptUseCase) SaveTemplate(ctx context.Context, input SaveTemplateInput) (*prompt.Template, error) {
+	t := &prompt.Template{
+		ID:        uuid.New(),
+		Name:      input.Name,
+		System:    input.System,
+		User:      input.User,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := t.Validate(); err != nil {
+		if errors.Is(err, prompt.ErrUnknownTemplate) {
+			return nil, apperror.NewNotFound("prompt template", input.Name)
+		}
+		return nil, apperror.NewInvalidInput("prompt template validation failed", err)
+	}
+
+	if err := uc.promptRepo.CreateVersion(ctx, t); err != nil {
+		uc.logger.Error("Failed to save prompt template", err, zap.String("name", t.Name))
+		return nil, err
+	}
+	uc.logger.Info("Prompt template version saved", zap.String("name", t.Name), zap.Int("version", t.Version))
+	return t, nil
+}
+
+func (uc *PromptUseCase) ActivateVersion(ctx context.Context, name string, version int) error {
+	if !prompt.IsKnownName(name) {
+		return apperror.NewNotFound("prompt template", name)
+	}
+	if err := uc.promptRepo.Activate(ctx, name, version); err != nil {
+		return err
+	}
+	uc.logger.Info("Prompt template version activated", zap.String("name", name), zap.Int("version", version))
+	return nil
+}
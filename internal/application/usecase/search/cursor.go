@@ -0,0 +1,49 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/khoahotran/personal-os/internal/domain/search"
+)
+
+// cursor is the opaque pagination token handed to clients. Keyword and
+// semantic pages resume after the last result's rank position; hybrid pages
+// are fused in memory, so they resume at a depth into the fused list.
+type cursor struct {
+	Mode         search.Mode `json:"m"`
+	Rank         float32     `json:"r,omitempty"`
+	ResourceType string      `json:"t,omitempty"`
+	ID           uuid.UUID   `json:"id,omitempty"`
+	Depth        int         `json:"d,omitempty"`
+}
+
+func (c cursor) position() *search.Position {
+	return &search.Position{Rank: c.Rank, ResourceType: c.ResourceType, ID: c.ID}
+}
+
+func positionCursor(mode search.Mode, last search.SearchResult) cursor {
+	return cursor{Mode: mode, Rank: last.Rank, ResourceType: last.ResourceType, ID: last.ID}
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursor, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, false
+	}
+	if _, ok := search.ParseMode(string(c.Mode)); !ok || c.Mode == "" || c.Depth < 0 || c.Depth > maxHybridDepth {
+		return nil, false
+	}
+	return &c, true
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/khoahotran/personal-os/internal/domain/search"
+)
+
+// rrfK dampens the weight of top ranks so that agreement between lists matters
+// more than winning any single one. 60 is the value from the original paper.
+const rrfK = 60
+
+type resultKey struct {
+	resourceType string
+	id           uuid.UUID
+}
+
+// fuseRRF merges ranked result lists with reciprocal rank fusion. Each result
+// scores sum(1 / (rrfK + rank)) over the lists it appears in, and its Rank is
+// replaced by that score. The first list's copy of a result wins so that
+// keyword snippets with highlights are kept.
+func fuseRRF(limit int, lists ...[]search.SearchResult) []search.SearchResult {
+	scores := make(map[resultKey]float64)
+	byKey := make(map[resultKey]search.SearchResult)
+	order := make([]resultKey, 0)
+
+	for _, list := range lists {
+		for i, res := range list {
+			key := resultKey{resourceType: res.ResourceType, id: res.ID}
+			if _, seen := byKey[key]; !seen {
+				byKey[key] = res
+				order = append(order, key)
+			}
+			scores[key] += 1.0 / float64(rrfK+i+1)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	fused := make([]search.SearchResult, len(order))
+	for i, key := range order {
+		res := byKey[key]
+		res.Rank = float32(scores[key])
+		fused[i] = res
+	}
+	return fused
+}
@@ -2,23 +2,47 @@ package search
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 
+	"github.com/khoahotran/personal-os/internal/application/service"
 	"github.com/khoahotran/personal-os/internal/domain/search"
 	"github.com/khoahotran/personal-os/pkg/apperror"
 	"github.com/khoahotran/personal-os/pkg/logger"
 	"go.uber.org/zap"
 )
 
+// hybridCandidateFactor controls how many candidates each retriever
+// contributes before fusion, relative to the depth being served.
+const hybridCandidateFactor = 3
+
+// maxHybridDepth is how deep into the fused list hybrid pages go. Every page
+// fetches all candidates up to its depth, so deeper cursors are refused.
+const maxHybridDepth = 20 * maxLimit
+
+const (
+	defaultLimit   = 10
+	maxLimit       = 50
+	maxSuggestions = 8
+)
+
+// errSemanticUnavailable marks failures of the embedding side of a search,
+// which can fall back to keyword matching.
+var errSemanticUnavailable = errors.New("semantic search unavailable")
+
 type SearchUseCase struct {
 	searchRepo search.Repository
+	embedder   service.EmbeddingService
 	logger     logger.Logger
 }
 
-func NewSearchUseCase(sr search.Repository, log logger.Logger) *SearchUseCase {
+func NewSearchUseCase(sr search.Repository, em service.EmbeddingService, log logger.Logger) *SearchUseCase {
 	return &SearchUseCase{
 		searchRepo: sr,
+		embedder:   em,
 		logger:     log,
 	}
 }
@@ -28,37 +52,200 @@ type SearchInput struct {
 	OwnerID  uuid.UUID
 	IsPublic bool
 	Limit    int
+	Mode     search.Mode
+	Filters  search.Filters
+	// Cursor continues a previous search. Its mode wins over Mode so that a
+	// page which fell back to keyword search keeps paging the same way.
+	Cursor string
 }
 
 type SearchOutput struct {
 	Results []search.SearchResult
+	// Mode is the mode actually used, which is keyword when the requested
+	// semantic or hybrid search had to fall back.
+	Mode search.Mode
+	// Facets are only computed for the first page.
+	Facets     *search.Facets
+	NextCursor string
 }
 
 func (uc *SearchUseCase) Execute(ctx context.Context, input SearchInput) (*SearchOutput, error) {
+	if input.Mode == "" {
+		input.Mode = search.ModeHybrid
+	}
 	if input.Query == "" {
-		return &SearchOutput{Results: []search.SearchResult{}}, nil
+		return &SearchOutput{Results: []search.SearchResult{}, Mode: input.Mode}, nil
 	}
 	if input.Limit <= 0 {
-		input.Limit = 10
+		input.Limit = defaultLimit
+	}
+	if input.Limit > maxLimit {
+		input.Limit = maxLimit
+	}
+	if err := validateFilters(input); err != nil {
+		return nil, err
 	}
 
-	var results []search.SearchResult
-	var err error
-
-	if input.IsPublic {
+	var after *cursor
+	if input.Cursor != "" {
+		c, ok := decodeCursor(input.Cursor)
+		if !ok {
+			return nil, apperror.NewInvalidInput("invalid search cursor", nil)
+		}
+		after = c
+		input.Mode = c.Mode
+	}
 
-		uc.logger.Info("Executing public search", zap.String("query", input.Query))
-		results, err = uc.searchRepo.SearchPublic(ctx, input.Query, input.Limit)
-	} else {
+	l := uc.logger.With(zap.String("query", input.Query), zap.String("mode", string(input.Mode)), zap.Bool("public", input.IsPublic))
+	if !input.IsPublic {
+		l = l.With(zap.String("owner_id", input.OwnerID.String()))
+	}
+	l.Info("Executing search")
 
-		uc.logger.Info("Executing private search", zap.String("query", input.Query), zap.String("owner_id", input.OwnerID.String()))
-		results, err = uc.searchRepo.SearchPrivate(ctx, input.Query, input.OwnerID, input.Limit)
+	q := search.Query{
+		Text:    input.Query,
+		Public:  input.IsPublic,
+		OwnerID: input.OwnerID,
+		Filters: input.Filters,
 	}
 
+	out, err := uc.search(ctx, q, input.Mode, input.Limit, after)
+	// A continued page cannot switch modes without skipping or repeating
+	// results, so only first pages fall back.
+	if errors.Is(err, errSemanticUnavailable) && after == nil {
+		l.Warn("Semantic search unavailable, falling back to keyword search", zap.Error(err))
+		out, err = uc.search(ctx, q, search.ModeKeyword, input.Limit, nil)
+	}
 	if err != nil {
-		uc.logger.Error("Search execution failed", err)
+		l.Error("Search execution failed", err)
 		return nil, apperror.NewInternal("search failed", err)
 	}
 
-	return &SearchOutput{Results: results}, nil
+	if after == nil {
+		facets, err := uc.searchRepo.Facets(ctx, q)
+		if err != nil {
+			l.Warn("Failed to compute search facets", zap.Error(err))
+		} else {
+			out.Facets = facets
+		}
+	}
+	return out, nil
+}
+
+// search runs one page in the given mode.
+func (uc *SearchUseCase) search(ctx context.Context, q search.Query, mode search.Mode, limit int, after *cursor) (*SearchOutput, error) {
+	switch mode {
+	case search.ModeKeyword, search.ModeSemantic:
+		q.Limit = limit + 1
+		if after != nil {
+			q.After = after.position()
+		}
+
+		var results []search.SearchResult
+		var err error
+		if mode == search.ModeKeyword {
+			results, err = uc.searchRepo.Search(ctx, q)
+		} else {
+			results, err = uc.semantic(ctx, q)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		out := &SearchOutput{Results: results, Mode: mode}
+		if len(results) > limit {
+			out.Results = results[:limit]
+			out.NextCursor = encodeCursor(positionCursor(mode, out.Results[limit-1]))
+		}
+		return out, nil
+
+	case search.ModeHybrid:
+		depth := 0
+		if after != nil {
+			depth = after.Depth
+		}
+		q.Limit = (depth + limit) * hybridCandidateFactor
+
+		semantic, err := uc.semantic(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		keyword, err := uc.searchRepo.Search(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		fused := fuseRRF(depth+limit+1, keyword, semantic)
+		out := &SearchOutput{Results: []search.SearchResult{}, Mode: mode}
+		if depth < len(fused) {
+			out.Results = fused[depth:]
+		}
+		if len(out.Results) > limit {
+			out.Results = out.Results[:limit]
+			if depth+limit <= maxHybridDepth {
+				out.NextCursor = encodeCursor(cursor{Mode: mode, Depth: depth + limit})
+			}
+		}
+		return out, nil
+	}
+	return nil, apperror.NewInvalidInput("unknown search mode", nil)
+}
+
+// semantic fails both when the embedder is down and when the vector query
+// itself errors; either way the caller can still fall back to keyword search.
+func (uc *SearchUseCase) semantic(ctx context.Context, q search.Query) ([]search.SearchResult, error) {
+	if uc.embedder == nil {
+		return nil, fmt.Errorf("%w: no embedding service configured", errSemanticUnavailable)
+	}
+	vector, err := uc.embedder.GenerateEmbeddings(ctx, q.Text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSemanticUnavailable, err)
+	}
+	results, err := uc.searchRepo.SemanticSearch(ctx, q, vector)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSemanticUnavailable, err)
+	}
+	return results, nil
+}
+
+func validateFilters(input SearchInput) error {
+	for _, t := range input.Filters.Types {
+		if !search.IsResourceType(t) {
+			return apperror.NewInvalidInput("unknown resource type: "+t, nil)
+		}
+	}
+	if len(input.Filters.Statuses) > 0 && input.IsPublic {
+		return apperror.NewInvalidInput("status filter is only available to the owner", nil)
+	}
+	for _, s := range input.Filters.Statuses {
+		if !search.IsStatus(s) {
+			return apperror.NewInvalidInput("unknown status: "+s, nil)
+		}
+	}
+	if input.Filters.From != nil && input.Filters.To != nil && !input.Filters.From.Before(*input.Filters.To) {
+		return apperror.NewInvalidInput("'from' must be before 'to'", nil)
+	}
+	return nil
+}
+
+type SuggestInput struct {
+	Query string
+	Limit int
+}
+
+// Suggest returns title completions for public content.
+func (uc *SearchUseCase) Suggest(ctx context.Context, input SuggestInput) ([]search.Suggestion, error) {
+	if strings.TrimSpace(input.Query) == "" {
+		return []search.Suggestion{}, nil
+	}
+	if input.Limit <= 0 || input.Limit > maxSuggestions {
+		input.Limit = maxSuggestions
+	}
+
+	suggestions, err := uc.searchRepo.SuggestPublic(ctx, input.Query, input.Limit)
+	if err != nil {
+		uc.logger.Error("Search suggestions failed", err, zap.String("query", input.Query))
+		return nil, apperror.NewInternal("search suggestions failed", err)
+	}
+	return suggestions, nil
 }
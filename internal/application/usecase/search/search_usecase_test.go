@@ -0,0 +1,191 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/adapters/embedding"
+	"github.com/khoahotran/personal-os/internal/domain/search"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+	"github.com/khoahotran/personal-os/pkg/logger"
+)
+
+// stubSearchRepo serves fixed, already ranked lists and honours Limit and
+// After the way the Postgres repository does.
+type stubSearchRepo struct {
+	keyword  []search.SearchResult
+	semantic []search.SearchResult
+	facets   *search.Facets
+
+	keywordQueries []search.Query
+	semanticCalls  int
+	facetCalls     int
+}
+
+func page(results []search.SearchResult, q search.Query) []search.SearchResult {
+	if q.After != nil {
+		for i, r := range results {
+			if r.ID == q.After.ID && r.ResourceType == q.After.ResourceType {
+				results = results[i+1:]
+				break
+			}
+		}
+	}
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+func (r *stubSearchRepo) Search(ctx context.Context, q search.Query) ([]search.SearchResult, error) {
+	r.keywordQueries = append(r.keywordQueries, q)
+	return page(r.keyword, q), nil
+}
+
+func (r *stubSearchRepo) SemanticSearch(ctx context.Context, q search.Query, embedding pgvector.Vector) ([]search.SearchResult, error) {
+	r.semanticCalls++
+	return page(r.semantic, q), nil
+}
+
+func (r *stubSearchRepo) Facets(ctx context.Context, q search.Query) (*search.Facets, error) {
+	r.facetCalls++
+	return r.facets, nil
+}
+
+func (r *stubSearchRepo) SuggestPublic(ctx context.Context, query string, limit int) ([]search.Suggestion, error) {
+	return nil, nil
+}
+
+func result(resourceType, title string) search.SearchResult {
+	return search.SearchResult{ID: uuid.NewSHA1(uuid.Nil, []byte(title)), ResourceType: resourceType, Title: title}
+}
+
+func titles(results []search.SearchResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Title
+	}
+	return out
+}
+
+func newTestSearchUseCase(repo *stubSearchRepo) (*SearchUseCase, *embedding.FakeEmbedder) {
+	em := embedding.NewFakeEmbedder(8)
+	return NewSearchUseCase(repo, em, logger.NewZapLogger("development")), em
+}
+
+func TestSearchUseCase_Execute_HybridFusesBothLists(t *testing.T) {
+	repo := &stubSearchRepo{
+		keyword:  []search.SearchResult{result("post", "a"), result("post", "b"), result("project", "c")},
+		semantic: []search.SearchResult{result("project", "c"), result("post", "d"), result("post", "b")},
+		facets:   &search.Facets{Types: []search.FacetCount{{Value: "post", Count: 2}}},
+	}
+	uc, _ := newTestSearchUseCase(repo)
+
+	out, err := uc.Execute(context.Background(), SearchInput{Query: "go", IsPublic: true, Limit: 3, Mode: search.ModeHybrid})
+	require.NoError(t, err)
+
+	assert.Equal(t, search.ModeHybrid, out.Mode)
+	assert.Equal(t, []string{"c", "b", "a"}, titles(out.Results), "results found by both retrievers rank first")
+	assert.InDelta(t, 1.0/61+1.0/63, out.Results[0].Rank, 1e-6)
+	assert.Equal(t, 9, repo.keywordQueries[0].Limit, "each retriever over-fetches candidates")
+	assert.Equal(t, repo.facets, out.Facets)
+	assert.NotEmpty(t, out.NextCursor, "a fourth fused result exists")
+}
+
+func TestSearchUseCase_Execute_HybridPagesByDepth(t *testing.T) {
+	repo := &stubSearchRepo{
+		keyword:  []search.SearchResult{result("post", "a"), result("post", "b"), result("project", "c")},
+		semantic: []search.SearchResult{result("project", "c"), result("post", "d"), result("post", "b")},
+	}
+	uc, _ := newTestSearchUseCase(repo)
+
+	first, err := uc.Execute(context.Background(), SearchInput{Query: "go", IsPublic: true, Limit: 2})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.NextCursor)
+
+	second, err := uc.Execute(context.Background(), SearchInput{Query: "go", IsPublic: true, Limit: 2, Cursor: first.NextCursor})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"c", "b"}, titles(first.Results))
+	assert.Equal(t, []string{"a", "d"}, titles(second.Results))
+	assert.Empty(t, second.NextCursor)
+	assert.Nil(t, second.Facets, "facets are only computed for the first page")
+	assert.Equal(t, 1, repo.facetCalls)
+}
+
+func TestSearchUseCase_Execute_KeywordPagesByPosition(t *testing.T) {
+	repo := &stubSearchRepo{
+		keyword: []search.SearchResult{result("post", "a"), result("post", "b"), result("project", "c")},
+	}
+	uc, _ := newTestSearchUseCase(repo)
+	filters := search.Filters{Types: []string{"post", "project"}, Statuses: []string{"draft"}}
+
+	first, err := uc.Execute(context.Background(), SearchInput{Query: "go", OwnerID: uuid.New(), Limit: 2, Mode: search.ModeKeyword, Filters: filters})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, titles(first.Results))
+	require.NotEmpty(t, first.NextCursor)
+
+	second, err := uc.Execute(context.Background(), SearchInput{Query: "go", OwnerID: uuid.New(), Limit: 2, Mode: search.ModeKeyword, Filters: filters, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, titles(second.Results))
+	assert.Empty(t, second.NextCursor)
+
+	require.Len(t, repo.keywordQueries, 2)
+	assert.Equal(t, 3, repo.keywordQueries[0].Limit, "one extra row detects the next page")
+	assert.Equal(t, filters, repo.keywordQueries[1].Filters)
+	require.NotNil(t, repo.keywordQueries[1].After)
+	assert.Equal(t, first.Results[1].ID, repo.keywordQueries[1].After.ID)
+	assert.Zero(t, repo.semanticCalls)
+}
+
+func TestSearchUseCase_Execute_SemanticOnly(t *testing.T) {
+	repo := &stubSearchRepo{semantic: []search.SearchResult{result("post", "d")}}
+	uc, _ := newTestSearchUseCase(repo)
+
+	out, err := uc.Execute(context.Background(), SearchInput{Query: "go", IsPublic: true, Mode: search.ModeSemantic})
+	require.NoError(t, err)
+
+	assert.Equal(t, search.ModeSemantic, out.Mode)
+	assert.Equal(t, []string{"d"}, titles(out.Results))
+	assert.Empty(t, repo.keywordQueries)
+}
+
+func TestSearchUseCase_Execute_FallsBackToKeywordWhenEmbedderFails(t *testing.T) {
+	repo := &stubSearchRepo{keyword: []search.SearchResult{result("post", "a")}}
+	uc, em := newTestSearchUseCase(repo)
+	em.FailWith(errors.New("embedder down"))
+
+	out, err := uc.Execute(context.Background(), SearchInput{Query: "go", OwnerID: uuid.New(), Limit: 5})
+	require.NoError(t, err)
+
+	assert.Equal(t, search.ModeKeyword, out.Mode)
+	assert.Equal(t, []string{"a"}, titles(out.Results))
+	require.Len(t, repo.keywordQueries, 1)
+	assert.Equal(t, 6, repo.keywordQueries[0].Limit)
+	assert.Zero(t, repo.semanticCalls)
+}
+
+func TestSearchUseCase_Execute_RejectsInvalidInput(t *testing.T) {
+	uc, _ := newTestSearchUseCase(&stubSearchRepo{})
+
+	tests := map[string]SearchInput{
+		"bad cursor":            {Query: "go", IsPublic: true, Cursor: "not-a-cursor"},
+		"cursor too deep":       {Query: "go", IsPublic: true, Cursor: encodeCursor(cursor{Mode: search.ModeHybrid, Depth: maxHybridDepth + 1})},
+		"unknown resource type": {Query: "go", IsPublic: true, Filters: search.Filters{Types: []string{"comment"}}},
+		"public status filter":  {Query: "go", IsPublic: true, Filters: search.Filters{Statuses: []string{"draft"}}},
+		"unknown status":        {Query: "go", OwnerID: uuid.New(), Filters: search.Filters{Statuses: []string{"archived"}}},
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := uc.Execute(context.Background(), input)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+		})
+	}
+}
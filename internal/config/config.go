@@ -33,9 +33,82 @@ type Config struct {
 		ApiKey    string `mapstructure:"api_key"`
 		ApiSecret string `mapstructure:"api_secret"`
 	} `mapstructure:"cloudinary"`
+	Storage struct {
+		Driver string `mapstructure:"driver"`
+		Local  struct {
+			Root    string `mapstructure:"root"`
+			BaseURL string `mapstructure:"base_url"`
+		} `mapstructure:"local"`
+		S3 struct {
+			Endpoint      string `mapstructure:"endpoint"`
+			Region        string `mapstructure:"region"`
+			Bucket        string `mapstructure:"bucket"`
+			AccessKey     string `mapstructure:"access_key"`
+			SecretKey     string `mapstructure:"secret_key"`
+			UseSSL        bool   `mapstructure:"use_ssl"`
+			PublicBaseURL string `mapstructure:"public_base_url"`
+		} `mapstructure:"s3"`
+		// SignedURLs configures the expiring URLs private media is served at.
+		// Secret signs local storage URLs, which refuses to start without it.
+		SignedURLs struct {
+			Secret string        `mapstructure:"secret"`
+			TTL    time.Duration `mapstructure:"ttl"`
+		} `mapstructure:"signed_urls"`
+		GC struct {
+			Schedule    string        `mapstructure:"schedule"`
+			GracePeriod time.Duration `mapstructure:"grace_period"`
+			DryRun      bool          `mapstructure:"dry_run"`
+		} `mapstructure:"gc"`
+	} `mapstructure:"storage"`
+	Upload struct {
+		MaxCoverSizeMB int64 `mapstructure:"max_cover_size_mb"`
+		MaxMediaSizeMB int64 `mapstructure:"max_media_size_mb"`
+		// MaxSessionSizeMB, PartSizeMB and SessionTTL configure resumable
+		// uploads: the largest file, the size of each part, and how long a
+		// session lasts after its last part.
+		MaxSessionSizeMB int64         `mapstructure:"max_session_size_mb"`
+		PartSizeMB       int64         `mapstructure:"part_size_mb"`
+		SessionTTL       time.Duration `mapstructure:"session_ttl"`
+	} `mapstructure:"upload"`
+	// FFmpeg locates the binaries used to probe video and audio. Empty paths
+	// are looked up on PATH.
+	FFmpeg struct {
+		FFmpegPath  string `mapstructure:"ffmpeg_path"`
+		FFprobePath string `mapstructure:"ffprobe_path"`
+	} `mapstructure:"ffmpeg"`
 	Ollama struct {
 		Host string `mapstructure:"host"`
 	} `mapstructure:"ollama"`
+	LLM struct {
+		Provider    string        `mapstructure:"provider"`
+		BaseURL     string        `mapstructure:"base_url"`
+		APIKey      string        `mapstructure:"api_key"`
+		Model       string        `mapstructure:"model"`
+		Temperature float32       `mapstructure:"temperature"`
+		MaxTokens   int           `mapstructure:"max_tokens"`
+		Timeout     time.Duration `mapstructure:"timeout"`
+		MaxRetries  int           `mapstructure:"max_retries"`
+	} `mapstructure:"llm"`
+	Embedding struct {
+		Provider   string        `mapstructure:"provider"`
+		BaseURL    string        `mapstructure:"base_url"`
+		APIKey     string        `mapstructure:"api_key"`
+		Model      string        `mapstructure:"model"`
+		Dimensions int           `mapstructure:"dimensions"`
+		Timeout    time.Duration `mapstructure:"timeout"`
+		MaxRetries int           `mapstructure:"max_retries"`
+	} `mapstructure:"embedding"`
+	Chat struct {
+		ContextTokenBudget int `mapstructure:"context_token_budget"`
+	} `mapstructure:"chat"`
+	PublicChat struct {
+		OwnerID        string        `mapstructure:"owner_id"`
+		MaxQueryLength int           `mapstructure:"max_query_length"`
+		MaxDistance    float64       `mapstructure:"max_distance"`
+		MaxSources     int           `mapstructure:"max_sources"`
+		RateLimit      int           `mapstructure:"rate_limit"`
+		RateWindow     time.Duration `mapstructure:"rate_window"`
+	} `mapstructure:"public_chat"`
 	Jaeger struct {
 		OTLPEndpoint string `mapstructure:"otlp_endpoint"`
 	} `mapstructure:"jaeger"`
@@ -77,7 +150,40 @@ func LoadConfig(paths ...string) (cfg Config, err error) {
 	viper.BindEnv("cloudinary.api_key", "CLOUDINARY_API_KEY")
 	viper.BindEnv("cloudinary.api_secret", "CLOUDINARY_API_SECRET")
 
+	viper.BindEnv("storage.driver", "STORAGE_DRIVER")
+	viper.BindEnv("storage.local.root", "STORAGE_LOCAL_ROOT")
+	viper.BindEnv("storage.local.base_url", "STORAGE_LOCAL_BASE_URL")
+	viper.BindEnv("storage.s3.endpoint", "S3_ENDPOINT")
+	viper.BindEnv("storage.s3.region", "S3_REGION")
+	viper.BindEnv("storage.s3.bucket", "S3_BUCKET")
+	viper.BindEnv("storage.s3.access_key", "S3_ACCESS_KEY")
+	viper.BindEnv("storage.s3.secret_key", "S3_SECRET_KEY")
+	viper.BindEnv("storage.s3.use_ssl", "S3_USE_SSL")
+	viper.BindEnv("storage.s3.public_base_url", "S3_PUBLIC_BASE_URL")
+	viper.BindEnv("storage.signed_urls.secret", "STORAGE_SIGNED_URL_SECRET")
+	viper.BindEnv("storage.signed_urls.ttl", "STORAGE_SIGNED_URL_TTL")
+	viper.BindEnv("storage.gc.schedule", "STORAGE_GC_SCHEDULE")
+	viper.BindEnv("storage.gc.grace_period", "STORAGE_GC_GRACE_PERIOD")
+	viper.BindEnv("storage.gc.dry_run", "STORAGE_GC_DRY_RUN")
+
+	viper.BindEnv("upload.max_cover_size_mb", "UPLOAD_MAX_COVER_SIZE_MB")
+	viper.BindEnv("upload.max_media_size_mb", "UPLOAD_MAX_MEDIA_SIZE_MB")
+	viper.BindEnv("upload.max_session_size_mb", "UPLOAD_MAX_SESSION_SIZE_MB")
+	viper.BindEnv("upload.part_size_mb", "UPLOAD_PART_SIZE_MB")
+	viper.BindEnv("upload.session_ttl", "UPLOAD_SESSION_TTL")
+	viper.BindEnv("ffmpeg.ffmpeg_path", "FFMPEG_PATH")
+	viper.BindEnv("ffmpeg.ffprobe_path", "FFPROBE_PATH")
+
 	viper.BindEnv("ollama.host", "OLLAMA_HOST")
+	viper.BindEnv("llm.provider", "LLM_PROVIDER")
+	viper.BindEnv("llm.base_url", "LLM_BASE_URL")
+	viper.BindEnv("llm.api_key", "LLM_API_KEY")
+	viper.BindEnv("llm.model", "LLM_MODEL")
+	viper.BindEnv("embedding.provider", "EMBEDDING_PROVIDER")
+	viper.BindEnv("embedding.base_url", "EMBEDDING_BASE_URL")
+	viper.BindEnv("embedding.api_key", "EMBEDDING_API_KEY")
+	viper.BindEnv("embedding.model", "EMBEDDING_MODEL")
+	viper.BindEnv("public_chat.owner_id", "PUBLIC_CHAT_OWNER_ID")
 	viper.BindEnv("jaeger.otlp_endpoint", "JAEGER_OTLP_GRPC_ENDPOINT")
 
 	err = viper.Unmarshal(&cfg)
@@ -0,0 +1,93 @@
+package album
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khoahotran/personal-os/internal/domain/media"
+)
+
+// Album is an ordered collection of media. A media item can be in any number
+// of albums.
+type Album struct {
+	ID           uuid.UUID  `json:"id"`
+	OwnerID      uuid.UUID  `json:"owner_id"`
+	Slug         string     `json:"slug"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	CoverMediaID *uuid.UUID `json:"cover_media_id"`
+	// MediaIDs is the album's media in display order.
+	MediaIDs  []uuid.UUID `json:"media_ids"`
+	IsPublic  bool        `json:"is_public"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	// CoverURL and CoverThumbnailURL are read from the cover media when the
+	// album is loaded. They are empty when the cover is unset or not visible.
+	CoverURL          string  `json:"cover_url"`
+	CoverThumbnailURL *string `json:"cover_thumbnail_url"`
+	// Items holds the media themselves, in order, when they have been loaded.
+	Items []*media.Media `json:"items,omitempty"`
+}
+
+var (
+	ErrInvalidSlug     = errors.New("slug only allows lowercase letters, numbers, and hyphens")
+	ErrTitleRequired   = errors.New("title is required")
+	ErrDuplicateMedia  = errors.New("media can only appear once in an album")
+	ErrCoverNotInAlbum = errors.New("cover media must be one of the album's media")
+	ErrUnknownMedia    = errors.New("album references media that does not exist")
+	slugRegex          = regexp.MustCompile(`^[a-z0-9-]+$`)
+	embedRegex         = regexp.MustCompile(`\{\{\s*album:([a-z0-9-]+)\s*\}\}`)
+)
+
+func (a *Album) Validate() error {
+	if a.Title == "" {
+		return ErrTitleRequired
+	}
+	if !slugRegex.MatchString(a.Slug) {
+		return ErrInvalidSlug
+	}
+	seen := make(map[uuid.UUID]bool, len(a.MediaIDs))
+	for _, id := range a.MediaIDs {
+		if seen[id] {
+			return ErrDuplicateMedia
+		}
+		seen[id] = true
+	}
+	if a.CoverMediaID != nil && !seen[*a.CoverMediaID] {
+		return ErrCoverNotInAlbum
+	}
+	return nil
+}
+
+// EmbeddedSlugs returns the slugs of albums embedded in post markdown with the
+// {{album:<slug>}} shortcode, in order of first appearance.
+func EmbeddedSlugs(markdown string) []string {
+	var slugs []string
+	seen := map[string]bool{}
+	for _, m := range embedRegex.FindAllStringSubmatch(markdown, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			slugs = append(slugs, m[1])
+		}
+	}
+	return slugs
+}
+
+type Repository interface {
+	// Save and Update write the album and its media order together. Media that
+	// do not exist or belong to another owner fail with ErrUnknownMedia.
+	Save(ctx context.Context, album *Album) error
+	Update(ctx context.Context, album *Album) error
+	Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*Album, error)
+	FindPublicBySlug(ctx context.Context, slug string) (*Album, error)
+	ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*Album, error)
+	ListPublic(ctx context.Context, limit, offset int) ([]*Album, error)
+	// ListMedia returns the album's media in order. publicOnly keeps only
+	// public media that have finished processing.
+	ListMedia(ctx context.Context, albumID uuid.UUID, publicOnly bool) ([]*media.Media, error)
+}
@@ -0,0 +1,36 @@
+package album
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlbumValidate(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	other := uuid.New()
+	tests := []struct {
+		name  string
+		album Album
+		want  error
+	}{
+		{name: "valid", album: Album{Title: "Trip", Slug: "trip-2024", MediaIDs: []uuid.UUID{a, b}, CoverMediaID: &b}},
+		{name: "empty", album: Album{Title: "Empty", Slug: "empty"}},
+		{name: "missing title", album: Album{Slug: "trip"}, want: ErrTitleRequired},
+		{name: "bad slug", album: Album{Title: "Trip", Slug: "Trip 2024"}, want: ErrInvalidSlug},
+		{name: "duplicate media", album: Album{Title: "Trip", Slug: "trip", MediaIDs: []uuid.UUID{a, a}}, want: ErrDuplicateMedia},
+		{name: "foreign cover", album: Album{Title: "Trip", Slug: "trip", MediaIDs: []uuid.UUID{a}, CoverMediaID: &other}, want: ErrCoverNotInAlbum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.album.Validate(), tt.want)
+		})
+	}
+}
+
+func TestEmbeddedSlugs(t *testing.T) {
+	markdown := "Intro\n\n{{album:tokyo-2024}}\n\nMore {{ album:kyoto }} and {{album:tokyo-2024}} again, but not {{album:Bad Slug}}."
+	assert.Equal(t, []string{"tokyo-2024", "kyoto"}, EmbeddedSlugs(markdown))
+	assert.Empty(t, EmbeddedSlugs("no albums here"))
+}
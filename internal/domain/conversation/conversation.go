@@ -0,0 +1,64 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+type Conversation struct {
+	ID        uuid.UUID `json:"id"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SourceRef struct {
+	ResourceID   uuid.UUID `json:"resource_id"`
+	ResourceType string    `json:"resource_type"`
+	Slug         string    `json:"slug"`
+	Title        string    `json:"title"`
+	Cited        bool      `json:"cited"`
+}
+
+type Message struct {
+	ID             uuid.UUID   `json:"id"`
+	ConversationID uuid.UUID   `json:"conversation_id"`
+	Role           Role        `json:"role"`
+	Content        string      `json:"content"`
+	Sources        []SourceRef `json:"sources"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrEmptyMessage         = errors.New("message content is required")
+)
+
+func (m *Message) Validate() error {
+	if m.Content == "" {
+		return ErrEmptyMessage
+	}
+	return nil
+}
+
+type Repository interface {
+	Save(ctx context.Context, c *Conversation) error
+	UpdateTitle(ctx context.Context, id uuid.UUID, ownerID uuid.UUID, title string) error
+	Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*Conversation, error)
+	ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*Conversation, error)
+	AddMessage(ctx context.Context, m *Message) error
+	// ListMessages returns the most recent messages in chronological order. A non-positive limit returns all of them.
+	ListMessages(ctx context.Context, conversationID uuid.UUID, limit int) ([]*Message, error)
+}
@@ -0,0 +1,47 @@
+package knowledge
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+)
+
+type ResourceType string
+
+const (
+	ResourcePost    ResourceType = "post"
+	ResourceProject ResourceType = "project"
+	ResourceHobby   ResourceType = "hobby"
+	ResourceProfile ResourceType = "profile"
+)
+
+// ProfileSlug is the slug reported for the owner's profile, which has no slug of its own.
+const ProfileSlug = "about"
+
+type Source struct {
+	ResourceID   uuid.UUID    `json:"resource_id"`
+	ResourceType ResourceType `json:"resource_type"`
+	Slug         string       `json:"slug"`
+	Title        string       `json:"title"`
+	Content      string       `json:"content"`
+	IsPublic     bool         `json:"is_public"`
+	Distance     float64      `json:"distance"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+func (rt ResourceType) IsValid() bool {
+	switch rt {
+	case ResourcePost, ResourceProject, ResourceHobby, ResourceProfile:
+		return true
+	}
+	return false
+}
+
+type Repository interface {
+	SearchByEmbedding(ctx context.Context, embedding pgvector.Vector, ownerID uuid.UUID, limit int) ([]*Source, error)
+	// SearchPublicByEmbedding only considers public posts and public projects.
+	SearchPublicByEmbedding(ctx context.Context, embedding pgvector.Vector, ownerID uuid.UUID, limit int) ([]*Source, error)
+	UpdateEmbedding(ctx context.Context, resourceType ResourceType, resourceID uuid.UUID, ownerID uuid.UUID, embedding pgvector.Vector) error
+}
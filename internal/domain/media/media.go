@@ -2,11 +2,16 @@ package media
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ResourceType identifies media in tag relations.
+const ResourceType = "media"
+
 type MediaStatus string
 
 const (
@@ -15,6 +20,100 @@ const (
 	StatusError   MediaStatus = "error"
 )
 
+// Kind is what a media item holds, which decides how it is processed.
+type Kind string
+
+const (
+	KindImage Kind = "image"
+	KindVideo Kind = "video"
+	KindAudio Kind = "audio"
+	// KindFile is anything else, such as a PDF; it is served as uploaded.
+	KindFile Kind = "file"
+)
+
+// KindOf maps a MIME type to the kind of media it holds.
+func KindOf(contentType string) Kind {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return KindImage
+	case strings.HasPrefix(contentType, "video/"):
+		return KindVideo
+	case strings.HasPrefix(contentType, "audio/"), contentType == "application/ogg":
+		return KindAudio
+	}
+	return KindFile
+}
+
+// Sort orders media listings, newest first.
+type Sort string
+
+const (
+	SortCreatedAt Sort = "created_at"
+	// SortTakenAt orders by capture time; media without one come last.
+	SortTakenAt Sort = "taken_at"
+)
+
+var ErrInvalidSort = errors.New("sort must be one of created_at, taken_at")
+
+// ParseSort maps a query value to a Sort, defaulting to SortCreatedAt.
+func ParseSort(s string) (Sort, error) {
+	switch Sort(s) {
+	case "", SortCreatedAt:
+		return SortCreatedAt, nil
+	case SortTakenAt:
+		return SortTakenAt, nil
+	}
+	return "", ErrInvalidSort
+}
+
+var ErrInvalidStatus = errors.New("status must be one of pending, ready, error")
+
+// ParseStatus maps a query value to a MediaStatus. An empty value is returned
+// as is and matches any status.
+func ParseStatus(s string) (MediaStatus, error) {
+	switch MediaStatus(s) {
+	case "", StatusPending, StatusReady, StatusError:
+		return MediaStatus(s), nil
+	}
+	return "", ErrInvalidStatus
+}
+
+// ListFilter narrows an owner's media listing. Zero fields match everything.
+type ListFilter struct {
+	Status   MediaStatus
+	IsPublic *bool
+	// ContentType matches a full MIME type such as "image/png", or a whole
+	// family when given only its type, such as "image". The image, video and
+	// audio families match the media's Kind, so audio in an Ogg file is
+	// "audio".
+	ContentType string
+	// From and To bound the date the listing is sorted by, inclusively.
+	From    *time.Time
+	To      *time.Time
+	AlbumID *uuid.UUID
+	TagSlug string
+	Sort    Sort
+	// Ascending lists oldest first instead of newest first.
+	Ascending bool
+}
+
+// Names of the variants generated for every image.
+const (
+	VariantThumbnail = "thumbnail"
+	VariantMedium    = "medium"
+	VariantOG        = "og"
+)
+
+// Variant is a resized rendition generated from the original image.
+type Variant struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+}
+
 type Media struct {
 	ID           uuid.UUID      `json:"id"`
 	OwnerID      uuid.UUID      `json:"owner_id"`
@@ -24,15 +123,231 @@ type Media struct {
 	Status       MediaStatus    `json:"status"`
 	Metadata     map[string]any `json:"metadata"`
 	IsPublic     bool           `json:"is_public"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
+	Kind         Kind           `json:"kind"`
+	// BlobID is the stored original this item references. It is nil for
+	// media uploaded before deduplication, which own their files.
+	BlobID *uuid.UUID `json:"blob_id"`
+	// Width and Height are the original's dimensions; zero until processed.
+	Width         int       `json:"width"`
+	Height        int       `json:"height"`
+	DominantColor string    `json:"dominant_color"`
+	BlurHash      string    `json:"blurhash"`
+	Variants      []Variant `json:"variants"`
+	// Duration and the codecs are read from videos and audio when a prober
+	// is available.
+	Duration   time.Duration `json:"duration"`
+	VideoCodec string        `json:"video_codec"`
+	AudioCodec string        `json:"audio_codec"`
+	// TakenAt comes from the photo's EXIF, when present.
+	TakenAt *time.Time `json:"taken_at"`
+	// ProcessingAttempts counts processing runs since upload or the last
+	// reprocess request; ProcessingError is the last failure, if any.
+	ProcessingAttempts int        `json:"processing_attempts"`
+	ProcessingError    string     `json:"processing_error"`
+	LastAttemptAt      *time.Time `json:"last_attempt_at"`
+	ProcessedAt        *time.Time `json:"processed_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// MaxProcessingAttempts is how many times processing is tried before the
+// media is marked as errored.
+const MaxProcessingAttempts = 3
+
+var ErrAlreadyProcessed = errors.New("media is already processed")
+
+// BeginAttempt records the start of a processing run.
+func (m *Media) BeginAttempt(now time.Time) {
+	m.ProcessingAttempts++
+	m.LastAttemptAt = &now
+}
+
+// MarkProcessed records a successful processing run.
+func (m *Media) MarkProcessed(now time.Time) {
+	m.Status = StatusReady
+	m.ProcessingError = ""
+	m.ProcessedAt = &now
+}
+
+// MarkAttemptFailed records a failed processing run. The media becomes
+// StatusError once attempts are exhausted, or straight away when retryable is
+// false; it reports whether that happened.
+func (m *Media) MarkAttemptFailed(err error, retryable bool) bool {
+	m.ProcessingError = err.Error()
+	if !retryable || m.ProcessingAttempts >= MaxProcessingAttempts {
+		m.Status = StatusError
+		return true
+	}
+	return false
+}
+
+// ResetProcessing puts pending or errored media back in the queue with a
+// fresh set of attempts.
+func (m *Media) ResetProcessing() error {
+	if m.Status == StatusReady {
+		return ErrAlreadyProcessed
+	}
+	m.Status = StatusPending
+	m.ProcessingAttempts = 0
+	return nil
+}
+
+// Blob is a stored original, shared by every media item of an owner with the
+// same content and visibility. RefCount is the number of media items
+// referencing it.
+type Blob struct {
+	ID      uuid.UUID `json:"id"`
+	OwnerID uuid.UUID `json:"owner_id"`
+	SHA256  string    `json:"sha256"`
+	// IsPrivate keeps private media off the files of public media, and the
+	// other way round, since their files are stored differently.
+	IsPrivate   bool      `json:"is_private"`
+	StorageKey  string    `json:"storage_key"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	RefCount    int       `json:"ref_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ErrBlobChanged is returned by Repository.Save when the blob it was given was
+// deleted, or created by another upload, after it was looked up. Looking the
+// blob up again and retrying resolves it.
+var ErrBlobChanged = errors.New("media blob changed while saving")
+
+// ErrOriginalMoved is returned by Repository.UpdateProcessing when the media
+// is gone or its original is no longer stored under the key it was processed
+// from, as after a visibility change. What processing wrote belongs to the
+// old files, so the media has to be processed again as it is now.
+var ErrOriginalMoved = errors.New("media original moved while processing")
+
+// Variant returns the named variant, if it has been generated.
+func (m *Media) Variant(name string) (Variant, bool) {
+	for _, v := range m.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// PosterURL is the still shown for a video before it plays: its medium
+// variant, rendered from a frame of the video.
+func (m *Media) PosterURL() (string, bool) {
+	if m.Kind != KindVideo {
+		return "", false
+	}
+	v, ok := m.Variant(VariantMedium)
+	return v.URL, ok
+}
+
+// OriginalKey is the storage key of the uploaded original.
+func (m *Media) OriginalKey() string {
+	key, _ := m.Metadata["original_public_id"].(string)
+	return key
+}
+
+// OriginalContentType is the MIME type the original was uploaded with.
+func (m *Media) OriginalContentType() string {
+	contentType, _ := m.Metadata["content_type"].(string)
+	return contentType
+}
+
+// SignURLs replaces the URLs of the original and every variant with the ones
+// sign returns for their keys, such as short-lived URLs for private media.
+func (m *Media) SignURLs(sign func(key, contentType string) (string, error)) error {
+	return m.MoveFiles(func(key, contentType string) (string, string, error) {
+		u, err := sign(key, contentType)
+		return key, u, err
+	})
+}
+
+// MoveFiles replaces the keys and URLs of the original and every variant
+// with the ones move returns for their keys and content types, such as after
+// copying them elsewhere.
+func (m *Media) MoveFiles(move func(key, contentType string) (newKey, url string, err error)) error {
+	urls := make(map[string]string, len(m.Variants)+1)
+	if key := m.OriginalKey(); key != "" {
+		newKey, u, err := move(key, m.OriginalContentType())
+		if err != nil {
+			return err
+		}
+		if original, ok := m.Metadata["original_url"].(string); ok {
+			urls[original] = u
+		}
+		m.Metadata["original_public_id"] = newKey
+		m.Metadata["original_url"] = u
+	}
+	for i, v := range m.Variants {
+		newKey, u, err := move(v.Key, v.ContentType)
+		if err != nil {
+			return err
+		}
+		urls[v.URL] = u
+		m.Variants[i].Key = newKey
+		m.Variants[i].URL = u
+	}
+
+	// URL and ThumbnailURL point at the original or one of the variants.
+	if u, ok := urls[m.URL]; ok {
+		m.URL = u
+	}
+	if m.ThumbnailURL != nil {
+		if u, ok := urls[*m.ThumbnailURL]; ok {
+			m.ThumbnailURL = &u
+		}
+	}
+	return nil
+}
+
+// ShareProcessed takes the variants and everything else processing found
+// from ready, a processed item of the same content, so that m is ready
+// without being processed itself.
+func (m *Media) ShareProcessed(ready *Media, now time.Time) {
+	m.URL = ready.URL
+	m.ThumbnailURL = ready.ThumbnailURL
+	m.Variants = ready.Variants
+	m.Width = ready.Width
+	m.Height = ready.Height
+	m.DominantColor = ready.DominantColor
+	m.BlurHash = ready.BlurHash
+	m.Kind = ready.Kind
+	m.Duration = ready.Duration
+	m.VideoCodec = ready.VideoCodec
+	m.AudioCodec = ready.AudioCodec
+	m.MarkProcessed(now)
 }
 
 type Repository interface {
-	Save(ctx context.Context, media *Media) error
+	// Save inserts media. When blob is set, a reference to it is taken in the
+	// same transaction and media.BlobID is filled in: a blob with an ID must
+	// still exist, and one without is created, filling in blob.ID. Either
+	// failing returns ErrBlobChanged.
+	Save(ctx context.Context, media *Media, blob *Blob) error
 	Update(ctx context.Context, media *Media) error
-	Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error
+	// UpdateProcessing saves only what processing sets on media: its
+	// variants, dimensions, URLs, status and attempts. It saves nothing and
+	// returns ErrOriginalMoved unless the original is still stored under
+	// originalKey.
+	UpdateProcessing(ctx context.Context, media *Media, originalKey string) error
+	// MoveToBlob updates media like Update and moves its reference to blob,
+	// taking it as Save does. It returns the blob media referenced before
+	// when that was its last reference, so the caller can remove its files.
+	MoveToBlob(ctx context.Context, media *Media, blob *Blob) (*Blob, error)
+	// Delete removes media and drops its blob reference. It returns the blob
+	// when that was the last reference, so the caller can remove its files.
+	Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*Blob, error)
 	FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*Media, error)
-	ListPublic(ctx context.Context, limit, offset int) ([]*Media, error)
-	ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*Media, error)
+	// FindBlob returns the owner's blob with the given content and
+	// visibility.
+	FindBlob(ctx context.Context, ownerID uuid.UUID, sha256 string, private bool) (*Blob, error)
+	// StorageKeys returns every key that media and blobs refer to: originals
+	// and variants.
+	StorageKeys(ctx context.Context) ([]string, error)
+	// FindReadyByBlob returns a processed media item referencing the blob.
+	FindReadyByBlob(ctx context.Context, blobID uuid.UUID) (*Media, error)
+	// ListPrivateOutside returns private media whose original key does not
+	// start with prefix, ordered by ID and starting after the given one.
+	ListPrivateOutside(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]*Media, error)
+	ListPublic(ctx context.Context, sort Sort, limit, offset int) ([]*Media, error)
+	ListByOwner(ctx context.Context, ownerID uuid.UUID, filter ListFilter, limit, offset int) ([]*Media, error)
 }
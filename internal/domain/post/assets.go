@@ -0,0 +1,20 @@
+package post
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// AssetRef is a post's claim on storage: the uploaded cover original, and the
+// variants folder derived from its IDs.
+type AssetRef struct {
+	PostID      uuid.UUID
+	OwnerID     uuid.UUID
+	OriginalKey string
+}
+
+// VariantsFolder is where the images rendered from a post's cover are stored.
+func VariantsFolder(ownerID, postID uuid.UUID) string {
+	return fmt.Sprintf("users/%s/variants/%s", ownerID.String(), postID.String())
+}
@@ -19,6 +19,20 @@ const (
 	StatusPending PostStatus = "pending"
 )
 
+// Language selects the full-text configuration used to index a post.
+type Language string
+
+const (
+	LanguageEnglish    Language = "en"
+	LanguageVietnamese Language = "vi"
+
+	DefaultLanguage = LanguageEnglish
+)
+
+func (l Language) IsValid() bool {
+	return l == LanguageEnglish || l == LanguageVietnamese
+}
+
 type PostVersion struct {
 	ID          uuid.UUID `json:"id"`
 	PostID      uuid.UUID `json:"post_id"`
@@ -32,6 +46,7 @@ type Post struct {
 	Title           string          `json:"title"`
 	ContentMarkdown string          `json:"content_markdown"`
 	Status          PostStatus      `json:"status"`
+	Language        Language        `json:"language"`
 	OgImageURL      *string         `json:"og_image_url"`
 	ThumbnailURL    *string         `json:"thumbnail_url"`
 	VersionHistory  []PostVersion   `json:"version_history"`
@@ -45,6 +60,7 @@ type Post struct {
 var (
 	ErrInvalidPostStatus = errors.New("invalid status")
 	ErrInvalidPostSlug   = errors.New("slug only includes lowercase letter, digit and -")
+	ErrInvalidLanguage   = errors.New("language must be one of en, vi")
 	postSlugRegex        = regexp.MustCompile(`^[a-z0-9-]+$`)
 	ErrPostNotFound      = errors.New("post not found")
 )
@@ -58,6 +74,9 @@ func (p *Post) Validate() error {
 	default:
 		return ErrInvalidPostStatus
 	}
+	if !p.Language.IsValid() {
+		return ErrInvalidLanguage
+	}
 	return nil
 }
 
@@ -86,6 +105,10 @@ func (p *Post) MarkAsReady(imageURL, thumbnailURL string) {
 type Repository interface {
 	Save(ctx context.Context, post *Post) error
 	Update(ctx context.Context, post *Post) error
+	// UpdateSuggestions stores suggestions in the post metadata, leaving the
+	// rest of the post as it is. It stores nothing and reports false when the
+	// post is gone or pending again, as an edit queues new suggestions.
+	UpdateSuggestions(ctx context.Context, id uuid.UUID, ownerID uuid.UUID, s Suggestions) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) error
 	FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*Post, error)
 	FindBySlug(ctx context.Context, slug string) (*Post, error)
@@ -93,4 +116,6 @@ type Repository interface {
 	ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*Post, error)
 	ListPublic(ctx context.Context, limit, offset int) ([]*Post, error)
 	SearchByEmbedding(ctx context.Context, embedding pgvector.Vector, ownerID uuid.UUID, limit int) ([]*Post, error)
+	// ListAssetRefs returns the storage claimed by every post.
+	ListAssetRefs(ctx context.Context) ([]AssetRef, error)
 }
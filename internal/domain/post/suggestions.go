@@ -0,0 +1,85 @@
+package post
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Metadata keys for authoring suggestions and the values accepted from them.
+const (
+	MetaKeySuggestions    = "suggestions"
+	MetaKeySummary        = "summary"
+	MetaKeySEODescription = "seo_description"
+)
+
+// Suggestions are LLM-generated authoring hints kept in the post metadata
+// until the author accepts them.
+type Suggestions struct {
+	Summary        string `json:"summary"`
+	SEODescription string `json:"seo_description"`
+	// Tags are suggestions that match existing tags; NewTags do not exist yet.
+	Tags        []string  `json:"tags"`
+	NewTags     []string  `json:"new_tags"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+func (p *Post) SetSuggestions(s Suggestions) {
+	if p.Metadata == nil {
+		p.Metadata = make(map[string]any)
+	}
+	p.Metadata[MetaKeySuggestions] = s
+}
+
+// Suggestions returns the stored suggestions. Metadata loaded from the
+// database holds them as a generic map, so they are decoded through JSON.
+func (p *Post) Suggestions() (*Suggestions, bool) {
+	raw, ok := p.Metadata[MetaKeySuggestions]
+	if !ok || raw == nil {
+		return nil, false
+	}
+	if s, ok := raw.(Suggestions); ok {
+		return &s, true
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	var s Suggestions
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, false
+	}
+	return &s, true
+}
+
+// Summary returns the accepted summary, or "" if none was accepted.
+func (p *Post) Summary() string {
+	s, _ := p.Metadata[MetaKeySummary].(string)
+	return s
+}
+
+// SEODescription returns the accepted SEO description, or "" if none was accepted.
+func (p *Post) SEODescription() string {
+	s, _ := p.Metadata[MetaKeySEODescription].(string)
+	return s
+}
+
+func (p *Post) setMetadataString(key, value string) {
+	if p.Metadata == nil {
+		p.Metadata = make(map[string]any)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		delete(p.Metadata, key)
+		return
+	}
+	p.Metadata[key] = value
+}
+
+func (p *Post) SetSummary(summary string) {
+	p.setMetadataString(MetaKeySummary, summary)
+}
+
+func (p *Post) SetSEODescription(description string) {
+	p.setMetadataString(MetaKeySEODescription, description)
+}
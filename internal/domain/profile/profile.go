@@ -18,6 +18,7 @@ type Profile struct {
 	Bio            string            `json:"bio"`
 	CareerTimeline []CareerMilestone `json:"career_timeline"`
 	ThemeSettings  map[string]any    `json:"theme_settings"`
+	ChatPersona    string            `json:"chat_persona"`
 	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
@@ -0,0 +1,136 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Names of the templates used by the application.
+const (
+	NameChat        = "chat"
+	NameChatRewrite = "chat_rewrite"
+	NamePublicChat  = "public_chat"
+	NamePostAssist  = "post_assist"
+)
+
+// Names lists every template the application renders.
+var Names = []string{NameChat, NameChatRewrite, NamePublicChat, NamePostAssist}
+
+type Template struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	System    string    `json:"system"`
+	User      string    `json:"user"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Variables are the values available to templates as {{.Field}}.
+type Variables struct {
+	Question string
+	Context  string
+	History  string
+	Persona  string
+	Title    string
+	Content  string
+	Tags     string
+}
+
+var (
+	ErrTemplateNotFound = errors.New("prompt template not found")
+	ErrUnknownTemplate  = errors.New("unknown prompt template name")
+	ErrEmptyTemplate    = errors.New("user template is required")
+)
+
+func IsKnownName(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Template) Validate() error {
+	if !IsKnownName(t.Name) {
+		return ErrUnknownTemplate
+	}
+	if t.User == "" {
+		return ErrEmptyTemplate
+	}
+	// Rendering with empty variables catches both syntax errors and
+	// references to fields that do not exist.
+	if _, _, err := t.Render(Variables{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Render executes the system and user templates with vars.
+func (t *Template) Render(vars Variables) (system string, user string, err error) {
+	if system, err = execute(t.Name+".system", t.System, vars); err != nil {
+		return "", "", err
+	}
+	if user, err = execute(t.Name+".user", t.User, vars); err != nil {
+		return "", "", err
+	}
+	return system, user, nil
+}
+
+func execute(name, text string, vars Variables) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+//go:embed defaults/*.tmpl
+var defaultFiles embed.FS
+
+// Default returns the built-in template shipped with the binary. It has
+// version 0 and is used until a version is saved for that name.
+func Default(name string) (*Template, error) {
+	if !IsKnownName(name) {
+		return nil, ErrUnknownTemplate
+	}
+	system, err := defaultFiles.ReadFile("defaults/" + name + ".system.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("missing default system template for %s: %w", name, err)
+	}
+	user, err := defaultFiles.ReadFile("defaults/" + name + ".user.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("missing default user template for %s: %w", name, err)
+	}
+	return &Template{Name: name, Version: 0, System: string(system), User: string(user), IsActive: true}, nil
+}
+
+type Repository interface {
+	// FindActive returns ErrTemplateNotFound when no version has been saved for name.
+	FindActive(ctx context.Context, name string) (*Template, error)
+	ListVersions(ctx context.Context, name string) ([]*Template, error)
+	// CreateVersion stores t as the next version of t.Name and makes it active.
+	CreateVersion(ctx context.Context, t *Template) error
+	Activate(ctx context.Context, name string, version int) error
+}
+
+// Resolve returns the active stored version of name, or the built-in default.
+func Resolve(ctx context.Context, repo Repository, name string) (*Template, error) {
+	t, err := repo.FindActive(ctx, name)
+	if errors.Is(err, ErrTemplateNotFound) {
+		return Default(name)
+	}
+	return t, err
+}
@@ -0,0 +1,50 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TextQuery is a user query split for full-text matching. Websearch is passed
+// to websearch_to_tsquery, which accepts quoted phrases, OR and -exclusion and
+// never raises a syntax error. Prefix, when set, is the word still being typed
+// and is matched as a lexeme prefix (to_tsquery 'prefix:*').
+type TextQuery struct {
+	Websearch string
+	Prefix    string
+}
+
+// ParseTextQuery extracts the trailing word for prefix matching. Only a bare
+// alphanumeric word qualifies: a finished word (trailing space), a quoted
+// phrase, an exclusion or the right side of OR keep their websearch meaning.
+func ParseTextQuery(raw string) TextQuery {
+	q := TextQuery{Websearch: strings.TrimSpace(raw)}
+	if raw == "" || unicode.IsSpace(rune(raw[len(raw)-1])) {
+		return q
+	}
+	if strings.Count(raw, `"`)%2 != 0 {
+		return q
+	}
+
+	fields := strings.Fields(raw)
+	last := fields[len(fields)-1]
+	if !isWord(last) {
+		return q
+	}
+	if len(fields) > 1 && strings.EqualFold(fields[len(fields)-2], "or") {
+		return q
+	}
+
+	q.Websearch = strings.TrimSpace(strings.Join(fields[:len(fields)-1], " "))
+	q.Prefix = strings.ToLower(last)
+	return q
+}
+
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,30 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTextQuery(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want TextQuery
+	}{
+		{raw: "go", want: TextQuery{Prefix: "go"}},
+		{raw: "go La", want: TextQuery{Websearch: "go", Prefix: "la"}},
+		{raw: "go lang ", want: TextQuery{Websearch: "go lang"}},
+		{raw: "c++", want: TextQuery{Websearch: "c++"}},
+		{raw: `"hybrid search`, want: TextQuery{Websearch: `"hybrid search`}},
+		{raw: `"hybrid search"`, want: TextQuery{Websearch: `"hybrid search"`}},
+		{raw: "kafka -redis", want: TextQuery{Websearch: "kafka -redis"}},
+		{raw: "kafka or redis", want: TextQuery{Websearch: "kafka or redis"}},
+		{raw: "tiếng Việ", want: TextQuery{Websearch: "tiếng", Prefix: "việ"}},
+		{raw: "", want: TextQuery{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseTextQuery(tt.raw))
+		})
+	}
+}
@@ -5,8 +5,41 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
 )
 
+const (
+	ResourcePost    = "post"
+	ResourceProject = "project"
+	ResourceHobby   = "hobby"
+	ResourceMedia   = "media"
+	ResourceTag     = "tag"
+)
+
+var ResourceTypes = []string{ResourcePost, ResourceProject, ResourceHobby, ResourceMedia, ResourceTag}
+
+func IsResourceType(s string) bool {
+	for _, t := range ResourceTypes {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses a result can be filtered by. Posts use their own status; every
+// other resource is either public or private.
+var Statuses = []string{"draft", "private", "public", "pending"}
+
+func IsStatus(s string) bool {
+	for _, st := range Statuses {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
 type SearchResult struct {
 	ID           uuid.UUID `json:"id"`
 	ResourceType string    `json:"resource_type"`
@@ -15,11 +48,88 @@ type SearchResult struct {
 	Snippet      string    `json:"snippet"`
 	Rank         float32   `json:"rank"`
 	IsPublic     bool      `json:"is_public"`
+	Tags         []string  `json:"tags"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// Suggestion is a title completion offered while the user types.
+type Suggestion struct {
+	ResourceType string `json:"resource_type"`
+	Title        string `json:"title"`
+	Slug         string `json:"slug"`
+}
+
+// Mode selects how a query is matched against content.
+type Mode string
+
+const (
+	ModeKeyword  Mode = "keyword"
+	ModeSemantic Mode = "semantic"
+	ModeHybrid   Mode = "hybrid"
+)
+
+// ParseMode maps a query parameter to a Mode. An empty value selects hybrid.
+func ParseMode(s string) (Mode, bool) {
+	switch Mode(s) {
+	case "":
+		return ModeHybrid, true
+	case ModeKeyword, ModeSemantic, ModeHybrid:
+		return Mode(s), true
+	}
+	return "", false
+}
+
+// Filters narrow a search. Values within one filter are ORed, filters are
+// ANDed. The date range applies to updated_at and is half-open [From, To).
+type Filters struct {
+	Types    []string
+	Tags     []string
+	From     *time.Time
+	To       *time.Time
+	Statuses []string
+}
+
+// Position identifies the last result of a page in rank order, so the next
+// page starts strictly after it.
+type Position struct {
+	Rank         float32
+	ResourceType string
+	ID           uuid.UUID
+}
+
+type Query struct {
+	Text string
+	// Public restricts results to published content of any owner; otherwise
+	// results are scoped to OwnerID and include private content.
+	Public  bool
+	OwnerID uuid.UUID
+	Filters Filters
+	After   *Position
+	Limit   int
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets count full-text matches per resource type and per tag. Each facet
+// ignores its own filter so that clients can offer the other values.
+type Facets struct {
+	Types []FacetCount `json:"types"`
+	Tags  []FacetCount `json:"tags"`
+}
+
 type Repository interface {
-	SearchPublic(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	// Search ranks full-text matches by ts_rank_cd, ordered by rank then
+	// resource type and ID so that Position is a stable cursor.
+	Search(ctx context.Context, q Query) ([]SearchResult, error)
+
+	// SemanticSearch ranks content by cosine similarity to the query
+	// embedding. Rank is 1 - distance; q.Text is ignored.
+	SemanticSearch(ctx context.Context, q Query, embedding pgvector.Vector) ([]SearchResult, error)
+
+	Facets(ctx context.Context, q Query) (*Facets, error)
 
-	SearchPrivate(ctx context.Context, query string, ownerID uuid.UUID, limit int) ([]SearchResult, error)
+	SuggestPublic(ctx context.Context, query string, limit int) ([]Suggestion, error)
 }
@@ -22,4 +22,5 @@ type Repository interface {
 	FindOrCreateTags(ctx context.Context, tagNames []string) ([]Tag, error)
 	SetTagsForResource(ctx context.Context, resourceID uuid.UUID, resourceType string, tagIDs []uuid.UUID) error
 	GetTagsForResource(ctx context.Context, resourceID uuid.UUID, resourceType string) ([]Tag, error)
+	ListAll(ctx context.Context) ([]Tag, error)
 }
@@ -0,0 +1,129 @@
+package uploadsession
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+)
+
+// MaxParts bounds how many parts a single upload can be split into.
+const MaxParts = 10000
+
+// Session is a resumable upload in progress. The file is sent in parts of
+// PartSize bytes, the last of which may be shorter, and becomes media once
+// every part has arrived and the whole file matches SHA256.
+type Session struct {
+	ID       uuid.UUID `json:"id"`
+	OwnerID  uuid.UUID `json:"owner_id"`
+	FileName string    `json:"file_name"`
+	Size     int64     `json:"size"`
+	PartSize int64     `json:"part_size"`
+	// SHA256 is the hex digest the client computed for the whole file.
+	SHA256       string         `json:"sha256"`
+	Metadata     map[string]any `json:"metadata"`
+	IsPublic     bool           `json:"is_public"`
+	KeepLocation bool           `json:"keep_location"`
+	// Parts holds the numbers of the parts received so far, ascending.
+	// Parts are numbered from 1.
+	Parts []int `json:"parts"`
+	// ExpiresAt is pushed back each time a part arrives. Sessions past it
+	// are abandoned and removed by the worker.
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	ErrInvalidSize     = errors.New("size must be greater than zero")
+	ErrInvalidChecksum = errors.New("sha256 must be a hex-encoded SHA-256 digest")
+	ErrTooManyParts    = fmt.Errorf("file would need more than %d parts", MaxParts)
+	ErrUnknownPart     = errors.New("part number is out of range")
+	ErrPartSize        = errors.New("part does not have the expected size")
+	ErrIncomplete      = errors.New("not every part has been uploaded")
+	ErrChecksum        = errors.New("uploaded file does not match its sha256")
+	sha256Regex        = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+func (s *Session) Validate() error {
+	if s.Size <= 0 || s.PartSize <= 0 {
+		return ErrInvalidSize
+	}
+	if !sha256Regex.MatchString(s.SHA256) {
+		return ErrInvalidChecksum
+	}
+	if s.PartCount() > MaxParts {
+		return ErrTooManyParts
+	}
+	return nil
+}
+
+// PartCount is how many parts the file is split into.
+func (s *Session) PartCount() int {
+	return int((s.Size + s.PartSize - 1) / s.PartSize)
+}
+
+// ExpectedPartSize returns the size part n must have.
+func (s *Session) ExpectedPartSize(n int) (int64, error) {
+	count := s.PartCount()
+	if n < 1 || n > count {
+		return 0, ErrUnknownPart
+	}
+	if n < count {
+		return s.PartSize, nil
+	}
+	return s.Size - int64(count-1)*s.PartSize, nil
+}
+
+// MissingParts returns the numbers of the parts not yet received, ascending.
+func (s *Session) MissingParts() []int {
+	missing := make([]int, 0)
+	for n := 1; n <= s.PartCount(); n++ {
+		if !slices.Contains(s.Parts, n) {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// ReceivedBytes is the size of the parts received so far.
+func (s *Session) ReceivedBytes() int64 {
+	var total int64
+	for _, n := range s.Parts {
+		if size, err := s.ExpectedPartSize(n); err == nil {
+			total += size
+		}
+	}
+	return total
+}
+
+// Folder is where the session's parts are stored. Parts are private until
+// they are assembled, and outside "users/", so the orphaned storage collector
+// leaves them to the session cleanup.
+func Folder(ownerID, sessionID uuid.UUID) string {
+	return service.KeyWithVisibility(fmt.Sprintf("uploads/%s/%s", ownerID, sessionID), true)
+}
+
+// PartKey is the storage key of part n.
+func (s *Session) PartKey(n int) string {
+	return fmt.Sprintf("%s/part-%05d", Folder(s.OwnerID, s.ID), n)
+}
+
+type Repository interface {
+	Save(ctx context.Context, s *Session) error
+	// FindByID loads the session with its received parts. Expired sessions
+	// are not found.
+	FindByID(ctx context.Context, id uuid.UUID, ownerID uuid.UUID) (*Session, error)
+	// AddPart records part n as received and moves the expiry to expiresAt.
+	// Receiving the same part again is not an error.
+	AddPart(ctx context.Context, id uuid.UUID, n int, expiresAt time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListExpired returns sessions that expired before the given time.
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*Session, error)
+}
@@ -0,0 +1,49 @@
+package uploadsession
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionParts(t *testing.T) {
+	s := Session{Size: 25, PartSize: 10, SHA256: strings.Repeat("a", 64), Parts: []int{1, 3}}
+	require.NoError(t, s.Validate())
+	assert.Equal(t, 3, s.PartCount())
+
+	size, err := s.ExpectedPartSize(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), size)
+	size, err = s.ExpectedPartSize(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), size, "the last part holds the remainder")
+	_, err = s.ExpectedPartSize(4)
+	assert.ErrorIs(t, err, ErrUnknownPart)
+	_, err = s.ExpectedPartSize(0)
+	assert.ErrorIs(t, err, ErrUnknownPart)
+
+	assert.Equal(t, []int{2}, s.MissingParts())
+	assert.Equal(t, int64(15), s.ReceivedBytes())
+}
+
+func TestSessionValidate(t *testing.T) {
+	digest := strings.Repeat("0", 64)
+	tests := []struct {
+		name    string
+		session Session
+		want    error
+	}{
+		{name: "valid", session: Session{Size: 1, PartSize: 1, SHA256: digest}},
+		{name: "empty file", session: Session{PartSize: 1, SHA256: digest}, want: ErrInvalidSize},
+		{name: "uppercase digest", session: Session{Size: 1, PartSize: 1, SHA256: strings.Repeat("A", 64)}, want: ErrInvalidChecksum},
+		{name: "short digest", session: Session{Size: 1, PartSize: 1, SHA256: "abc"}, want: ErrInvalidChecksum},
+		{name: "too many parts", session: Session{Size: MaxParts + 1, PartSize: 1, SHA256: digest}, want: ErrTooManyParts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.session.Validate(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package testutil holds fakes shared by use case tests.
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khoahotran/personal-os/internal/application/service"
+	"github.com/khoahotran/personal-os/pkg/apperror"
+)
+
+const fakeBaseURL = "https://media.test/"
+
+// FakeStorage is an in-memory Storage for tests. Objects are served from
+// https://media.test/<key>, and signed URLs add a fixed "signature=test"
+// query.
+type FakeStorage struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	deleted []string
+}
+
+type fakeObject struct {
+	data       []byte
+	modifiedAt time.Time
+}
+
+func NewFakeStorage() *FakeStorage {
+	return &FakeStorage{objects: make(map[string]fakeObject)}
+}
+
+var _ service.Storage = (*FakeStorage)(nil)
+
+// PutAt stores data under key as if it had been written at modifiedAt.
+func (f *FakeStorage) PutAt(key string, data []byte, modifiedAt time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[key] = fakeObject{data: data, modifiedAt: modifiedAt}
+}
+
+// Object returns the data stored under key.
+func (f *FakeStorage) Object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	return obj.data, ok
+}
+
+// Keys returns every stored key, sorted.
+func (f *FakeStorage) Keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Deleted returns every key deleted so far, in order.
+func (f *FakeStorage) Deleted() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+func (f *FakeStorage) Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	f.PutAt(key, data, time.Now())
+	return f.URL(key, contentType), nil
+}
+
+func (f *FakeStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := f.Object(key)
+	if !ok {
+		return nil, apperror.NewNotFound("object", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *FakeStorage) Delete(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, key)
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func (f *FakeStorage) List(ctx context.Context, prefix string) ([]service.StoredObject, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var objects []service.StoredObject
+	for key, obj := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, service.StoredObject{Key: key, Size: int64(len(obj.data)), ModifiedAt: obj.modifiedAt})
+		}
+	}
+	return objects, nil
+}
+
+func (f *FakeStorage) URL(key, contentType string) string {
+	return fakeBaseURL + key
+}
+
+func (f *FakeStorage) SignedURL(ctx context.Context, key, contentType string) (string, error) {
+	return f.URL(key, contentType) + "?signature=test", nil
+}
+
+func (f *FakeStorage) Driver() string {
+	return "fake"
+}
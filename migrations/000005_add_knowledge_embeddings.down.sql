@@ -0,0 +1,2 @@
+ALTER TABLE profiles DROP COLUMN IF EXISTS embedding;
+ALTER TABLE hobby_items DROP COLUMN IF EXISTS embedding;
@@ -0,0 +1,4 @@
+ALTER TABLE hobby_items
+ADD COLUMN IF NOT EXISTS embedding vector(768);
+ALTER TABLE profiles
+ADD COLUMN IF NOT EXISTS embedding vector(768);
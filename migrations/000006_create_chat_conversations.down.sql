@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS chat_messages;
+DROP TRIGGER IF EXISTS update_chat_conversations_updated_at ON chat_conversations;
+DROP TABLE IF EXISTS chat_conversations;
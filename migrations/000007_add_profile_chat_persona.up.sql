@@ -0,0 +1,2 @@
+ALTER TABLE profiles
+ADD COLUMN IF NOT EXISTS chat_persona TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    version INT NOT NULL,
+    system_template TEXT NOT NULL DEFAULT '',
+    user_template TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT prompt_templates_name_version_key UNIQUE (name, version)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_templates_active ON prompt_templates(name) WHERE is_active;
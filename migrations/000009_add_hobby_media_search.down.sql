@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS tags_name_ts_idx;
+
+DROP INDEX IF EXISTS media_ts_idx;
+DROP TRIGGER IF EXISTS tsvectorupdate_media ON media;
+DROP FUNCTION IF EXISTS media_tsvector_trigger();
+DROP FUNCTION IF EXISTS media_tsvector(JSONB);
+ALTER TABLE media DROP COLUMN IF EXISTS ts;
+
+DROP INDEX IF EXISTS hobby_items_ts_idx;
+DROP TRIGGER IF EXISTS tsvectorupdate_hobby_items ON hobby_items;
+DROP FUNCTION IF EXISTS hobby_items_tsvector_trigger();
+DROP FUNCTION IF EXISTS hobby_items_tsvector(TEXT, TEXT, TEXT);
+ALTER TABLE hobby_items DROP COLUMN IF EXISTS ts;
@@ -0,0 +1,41 @@
+-- Hobby items
+ALTER TABLE hobby_items
+ADD COLUMN ts tsvector;
+CREATE OR REPLACE FUNCTION hobby_items_tsvector(title TEXT, notes TEXT, category TEXT) RETURNS tsvector AS $$
+SELECT setweight(to_tsvector('simple', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('simple', COALESCE(notes, '')), 'B')
+    || setweight(to_tsvector('simple', COALESCE(category, '')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+CREATE OR REPLACE FUNCTION hobby_items_tsvector_trigger() RETURNS trigger AS $$ BEGIN NEW.ts := hobby_items_tsvector(NEW.title, NEW.notes, NEW.category);
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+CREATE TRIGGER tsvectorupdate_hobby_items BEFORE
+INSERT
+    OR
+UPDATE ON hobby_items FOR EACH ROW EXECUTE PROCEDURE hobby_items_tsvector_trigger();
+-- Backfill without touching updated_at.
+ALTER TABLE hobby_items DISABLE TRIGGER update_hobby_items_updated_at;
+UPDATE hobby_items SET ts = hobby_items_tsvector(title, notes, category);
+ALTER TABLE hobby_items ENABLE TRIGGER update_hobby_items_updated_at;
+CREATE INDEX hobby_items_ts_idx ON hobby_items USING GIN(ts);
+-- Media: caption and alt text live in metadata
+ALTER TABLE media
+ADD COLUMN ts tsvector;
+CREATE OR REPLACE FUNCTION media_tsvector(metadata JSONB) RETURNS tsvector AS $$
+SELECT setweight(to_tsvector('simple', COALESCE(metadata->>'caption', '')), 'A')
+    || setweight(to_tsvector('simple', COALESCE(metadata->>'alt', '')), 'B')
+    || setweight(to_tsvector('simple', COALESCE(metadata->>'original_filename', '')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+CREATE OR REPLACE FUNCTION media_tsvector_trigger() RETURNS trigger AS $$ BEGIN NEW.ts := media_tsvector(NEW.metadata);
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+CREATE TRIGGER tsvectorupdate_media BEFORE
+INSERT
+    OR
+UPDATE ON media FOR EACH ROW EXECUTE PROCEDURE media_tsvector_trigger();
+ALTER TABLE media DISABLE TRIGGER update_media_updated_at;
+UPDATE media SET ts = media_tsvector(metadata);
+ALTER TABLE media ENABLE TRIGGER update_media_updated_at;
+CREATE INDEX media_ts_idx ON media USING GIN(ts);
+-- Tags are short enough to match on the fly, but index the expression used by search.
+CREATE INDEX IF NOT EXISTS tags_name_ts_idx ON tags USING GIN(to_tsvector('simple', name));
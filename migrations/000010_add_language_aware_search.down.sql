@@ -0,0 +1,53 @@
+DROP INDEX IF EXISTS tags_name_ts_idx;
+CREATE INDEX tags_name_ts_idx ON tags USING GIN(to_tsvector('simple', name));
+
+CREATE OR REPLACE FUNCTION media_tsvector(metadata JSONB) RETURNS tsvector AS $$
+SELECT setweight(to_tsvector('simple', COALESCE(metadata->>'caption', '')), 'A')
+    || setweight(to_tsvector('simple', COALESCE(metadata->>'alt', '')), 'B')
+    || setweight(to_tsvector('simple', COALESCE(metadata->>'original_filename', '')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+CREATE OR REPLACE FUNCTION hobby_items_tsvector(title TEXT, notes TEXT, category TEXT) RETURNS tsvector AS $$
+SELECT setweight(to_tsvector('simple', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('simple', COALESCE(notes, '')), 'B')
+    || setweight(to_tsvector('simple', COALESCE(category, '')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION projects_tsvector_trigger() RETURNS trigger AS $$ BEGIN NEW.ts := setweight(
+        to_tsvector('simple', COALESCE(NEW.title, '')),
+        'A'
+    ) || setweight(
+        to_tsvector('simple', COALESCE(NEW.description, '')),
+        'B'
+    );
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+CREATE OR REPLACE FUNCTION posts_tsvector_trigger() RETURNS trigger AS $$ BEGIN NEW.ts := setweight(
+        to_tsvector('simple', COALESCE(NEW.title, '')),
+        'A'
+    ) || setweight(
+        to_tsvector('simple', COALESCE(NEW.content_markdown, '')),
+        'B'
+    );
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+
+ALTER TABLE posts DISABLE TRIGGER update_posts_updated_at;
+UPDATE posts SET ts = setweight(to_tsvector('simple', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('simple', COALESCE(content_markdown, '')), 'B');
+ALTER TABLE posts ENABLE TRIGGER update_posts_updated_at;
+ALTER TABLE projects DISABLE TRIGGER update_projects_updated_at;
+UPDATE projects SET ts = setweight(to_tsvector('simple', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('simple', COALESCE(description, '')), 'B');
+ALTER TABLE projects ENABLE TRIGGER update_projects_updated_at;
+ALTER TABLE hobby_items DISABLE TRIGGER update_hobby_items_updated_at;
+UPDATE hobby_items SET ts = hobby_items_tsvector(title, notes, category);
+ALTER TABLE hobby_items ENABLE TRIGGER update_hobby_items_updated_at;
+ALTER TABLE media DISABLE TRIGGER update_media_updated_at;
+UPDATE media SET ts = media_tsvector(metadata);
+ALTER TABLE media ENABLE TRIGGER update_media_updated_at;
+
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_language_check;
+ALTER TABLE posts DROP COLUMN IF EXISTS language;
+DROP FUNCTION IF EXISTS search_config(TEXT);
+DROP TEXT SEARCH CONFIGURATION IF EXISTS english_unaccent;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS simple_unaccent;
@@ -0,0 +1,70 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+-- Text search configurations that fold diacritics, so "Việt" matches "viet".
+-- Vietnamese has no stemmer, so it shares the unaccented simple configuration
+-- with content that has no language.
+CREATE TEXT SEARCH CONFIGURATION simple_unaccent (COPY = simple);
+ALTER TEXT SEARCH CONFIGURATION simple_unaccent
+    ALTER MAPPING FOR asciiword, asciihword, hword_asciipart, word, hword, hword_part WITH unaccent, simple;
+CREATE TEXT SEARCH CONFIGURATION english_unaccent (COPY = english);
+ALTER TEXT SEARCH CONFIGURATION english_unaccent
+    ALTER MAPPING FOR asciiword, asciihword, hword_asciipart, word, hword, hword_part WITH unaccent, english_stem;
+-- search_config maps a post language to its text search configuration.
+CREATE OR REPLACE FUNCTION search_config(lang TEXT) RETURNS regconfig AS $$
+SELECT CASE lang
+        WHEN 'en' THEN 'english_unaccent'::regconfig
+        ELSE 'simple_unaccent'::regconfig
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+-- Posts
+ALTER TABLE posts
+ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT 'en';
+ALTER TABLE posts
+ADD CONSTRAINT posts_language_check CHECK (language IN ('en', 'vi'));
+CREATE OR REPLACE FUNCTION posts_tsvector_trigger() RETURNS trigger AS $$ BEGIN NEW.ts := setweight(
+        to_tsvector(search_config(NEW.language), COALESCE(NEW.title, '')),
+        'A'
+    ) || setweight(
+        to_tsvector(search_config(NEW.language), COALESCE(NEW.content_markdown, '')),
+        'B'
+    );
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+-- Projects
+CREATE OR REPLACE FUNCTION projects_tsvector_trigger() RETURNS trigger AS $$ BEGIN NEW.ts := setweight(
+        to_tsvector('simple_unaccent', COALESCE(NEW.title, '')),
+        'A'
+    ) || setweight(
+        to_tsvector('simple_unaccent', COALESCE(NEW.description, '')),
+        'B'
+    );
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+-- Hobby items and media
+CREATE OR REPLACE FUNCTION hobby_items_tsvector(title TEXT, notes TEXT, category TEXT) RETURNS tsvector AS $$
+SELECT setweight(to_tsvector('simple_unaccent', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('simple_unaccent', COALESCE(notes, '')), 'B')
+    || setweight(to_tsvector('simple_unaccent', COALESCE(category, '')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+CREATE OR REPLACE FUNCTION media_tsvector(metadata JSONB) RETURNS tsvector AS $$
+SELECT setweight(to_tsvector('simple_unaccent', COALESCE(metadata->>'caption', '')), 'A')
+    || setweight(to_tsvector('simple_unaccent', COALESCE(metadata->>'alt', '')), 'B')
+    || setweight(to_tsvector('simple_unaccent', COALESCE(metadata->>'original_filename', '')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+-- Tags
+DROP INDEX IF EXISTS tags_name_ts_idx;
+CREATE INDEX tags_name_ts_idx ON tags USING GIN(to_tsvector('simple_unaccent', name));
+-- Rebuild existing vectors without touching updated_at.
+ALTER TABLE posts DISABLE TRIGGER update_posts_updated_at;
+UPDATE posts SET ts = setweight(to_tsvector(search_config(language), COALESCE(title, '')), 'A')
+    || setweight(to_tsvector(search_config(language), COALESCE(content_markdown, '')), 'B');
+ALTER TABLE posts ENABLE TRIGGER update_posts_updated_at;
+ALTER TABLE projects DISABLE TRIGGER update_projects_updated_at;
+UPDATE projects SET ts = setweight(to_tsvector('simple_unaccent', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('simple_unaccent', COALESCE(description, '')), 'B');
+ALTER TABLE projects ENABLE TRIGGER update_projects_updated_at;
+ALTER TABLE hobby_items DISABLE TRIGGER update_hobby_items_updated_at;
+UPDATE hobby_items SET ts = hobby_items_tsvector(title, notes, category);
+ALTER TABLE hobby_items ENABLE TRIGGER update_hobby_items_updated_at;
+ALTER TABLE media DISABLE TRIGGER update_media_updated_at;
+UPDATE media SET ts = media_tsvector(metadata);
+ALTER TABLE media ENABLE TRIGGER update_media_updated_at;
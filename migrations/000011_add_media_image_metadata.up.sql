@@ -0,0 +1,6 @@
+ALTER TABLE media
+ADD COLUMN IF NOT EXISTS width INTEGER,
+ADD COLUMN IF NOT EXISTS height INTEGER,
+ADD COLUMN IF NOT EXISTS dominant_color VARCHAR(7),
+ADD COLUMN IF NOT EXISTS blurhash TEXT,
+ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS media_public_taken_at_idx;
+ALTER TABLE media DROP COLUMN IF EXISTS taken_at;
@@ -0,0 +1,5 @@
+ALTER TABLE media
+ADD COLUMN IF NOT EXISTS taken_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS media_public_taken_at_idx ON media (taken_at DESC NULLS LAST, created_at DESC)
+WHERE is_public;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS media_blob_id_idx;
+ALTER TABLE media DROP COLUMN IF EXISTS blob_id;
+DROP TABLE IF EXISTS media_blobs;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS media_blobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sha256 CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    ref_count INTEGER NOT NULL DEFAULT 0 CHECK (ref_count >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (owner_id, sha256)
+);
+DROP TRIGGER IF EXISTS update_media_blobs_updated_at ON media_blobs;
+CREATE TRIGGER update_media_blobs_updated_at BEFORE
+UPDATE ON media_blobs FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+-- Media uploaded before deduplication keep a NULL blob and own their files.
+ALTER TABLE media
+ADD COLUMN IF NOT EXISTS blob_id UUID REFERENCES media_blobs(id);
+
+CREATE INDEX IF NOT EXISTS media_blob_id_idx ON media (blob_id);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS albums (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    slug VARCHAR(255) UNIQUE NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cover_media_id UUID REFERENCES media(id) ON DELETE SET NULL,
+    is_public BOOLEAN DEFAULT false NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_albums_owner_id ON albums(owner_id);
+DROP TRIGGER IF EXISTS update_albums_updated_at ON albums;
+CREATE TRIGGER update_albums_updated_at BEFORE
+UPDATE ON albums FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS album_media (
+    album_id UUID NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (album_id, media_id)
+);
+CREATE INDEX IF NOT EXISTS idx_album_media_media_id ON album_media(media_id);
@@ -0,0 +1,5 @@
+ALTER TABLE media
+DROP COLUMN IF EXISTS processed_at,
+DROP COLUMN IF EXISTS last_attempt_at,
+DROP COLUMN IF EXISTS processing_error,
+DROP COLUMN IF EXISTS processing_attempts;
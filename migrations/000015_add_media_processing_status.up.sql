@@ -0,0 +1,5 @@
+ALTER TABLE media
+ADD COLUMN IF NOT EXISTS processing_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS processing_error TEXT,
+ADD COLUMN IF NOT EXISTS last_attempt_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS processed_at TIMESTAMPTZ;
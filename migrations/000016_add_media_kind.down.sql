@@ -0,0 +1,5 @@
+ALTER TABLE media
+DROP COLUMN IF EXISTS audio_codec,
+DROP COLUMN IF EXISTS video_codec,
+DROP COLUMN IF EXISTS duration_ms,
+DROP COLUMN IF EXISTS kind;
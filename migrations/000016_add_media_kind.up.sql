@@ -0,0 +1,12 @@
+ALTER TABLE media
+ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'image',
+ADD COLUMN IF NOT EXISTS duration_ms BIGINT,
+ADD COLUMN IF NOT EXISTS video_codec VARCHAR(50),
+ADD COLUMN IF NOT EXISTS audio_codec VARCHAR(50);
+
+UPDATE media SET kind = CASE
+    WHEN metadata->>'content_type' LIKE 'video/%' THEN 'video'
+    WHEN metadata->>'content_type' LIKE 'audio/%' OR metadata->>'content_type' = 'application/ogg' THEN 'audio'
+    WHEN metadata->>'content_type' IS NULL OR metadata->>'content_type' LIKE 'image/%' THEN 'image'
+    ELSE 'file'
+END;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL,
+    part_size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    is_public BOOLEAN DEFAULT false NOT NULL,
+    keep_location BOOLEAN DEFAULT false NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
+DROP TRIGGER IF EXISTS update_upload_sessions_updated_at ON upload_sessions;
+CREATE TRIGGER update_upload_sessions_updated_at BEFORE
+UPDATE ON upload_sessions FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS upload_session_parts (
+    session_id UUID NOT NULL REFERENCES upload_sessions(id) ON DELETE CASCADE,
+    part_number INTEGER NOT NULL,
+    PRIMARY KEY (session_id, part_number)
+);
@@ -0,0 +1,17 @@
+-- A private blob with a public twin hands its references to the twin, so
+-- that one blob per content is left.
+UPDATE media m SET blob_id = p.id
+FROM media_blobs b
+JOIN media_blobs p ON p.owner_id = b.owner_id AND p.sha256 = b.sha256 AND NOT p.is_private
+WHERE m.blob_id = b.id AND b.is_private;
+
+DELETE FROM media_blobs b
+WHERE b.is_private
+AND EXISTS (SELECT 1 FROM media_blobs p WHERE p.owner_id = b.owner_id AND p.sha256 = b.sha256 AND NOT p.is_private);
+
+UPDATE media_blobs b SET ref_count = (SELECT count(*) FROM media m WHERE m.blob_id = b.id)
+WHERE NOT b.is_private;
+
+ALTER TABLE media_blobs DROP CONSTRAINT IF EXISTS media_blobs_owner_id_sha256_is_private_key;
+ALTER TABLE media_blobs ADD CONSTRAINT media_blobs_owner_id_sha256_key UNIQUE (owner_id, sha256);
+ALTER TABLE media_blobs DROP COLUMN IF EXISTS is_private;
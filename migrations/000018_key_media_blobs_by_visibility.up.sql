@@ -0,0 +1,11 @@
+-- Public and private media no longer share files, so a blob is either one.
+-- Existing blobs are private when no public media references them.
+ALTER TABLE media_blobs
+ADD COLUMN IF NOT EXISTS is_private BOOLEAN DEFAULT false NOT NULL;
+
+UPDATE media_blobs b SET is_private = true
+WHERE NOT EXISTS (SELECT 1 FROM media m WHERE m.blob_id = b.id AND m.is_public);
+
+ALTER TABLE media_blobs DROP CONSTRAINT IF EXISTS media_blobs_owner_id_sha256_key;
+ALTER TABLE media_blobs
+ADD CONSTRAINT media_blobs_owner_id_sha256_is_private_key UNIQUE (owner_id, sha256, is_private);
@@ -0,0 +1,2 @@
+-- The blobs made public cannot be told apart from the others afterwards, and
+-- the backfill has moved their private media off them.
@@ -0,0 +1,18 @@
+-- Blobs stored before private media was kept apart hold their files outside
+-- the private prefix, where they are served unsigned. They count as public
+-- until the private media backfill moves their private media to blobs of
+-- their own, and a public blob of the same content takes their references.
+UPDATE media m SET blob_id = p.id
+FROM media_blobs l
+JOIN media_blobs p ON p.owner_id = l.owner_id AND p.sha256 = l.sha256 AND NOT p.is_private
+WHERE m.blob_id = l.id AND l.is_private AND l.storage_key NOT LIKE 'private/%';
+
+DELETE FROM media_blobs l
+WHERE l.is_private AND l.storage_key NOT LIKE 'private/%'
+AND NOT EXISTS (SELECT 1 FROM media m WHERE m.blob_id = l.id);
+
+UPDATE media_blobs b SET ref_count = (SELECT count(*) FROM media m WHERE m.blob_id = b.id)
+WHERE NOT b.is_private;
+
+UPDATE media_blobs SET is_private = false
+WHERE is_private AND storage_key NOT LIKE 'private/%';
@@ -15,6 +15,9 @@ var (
 	ErrConflict     = errors.New("conflict")
 	ErrInternal     = errors.New("internal server error")
 	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("too many requests")
+	ErrTooLarge     = errors.New("payload too large")
+	ErrUnsupported  = errors.New("unsupported media type")
 )
 
 type AppError struct {
@@ -67,6 +70,22 @@ func NewPermissionDenied(details string) *AppError {
 	return NewAppError(ErrPermission, "Permission denied", details, nil)
 }
 
+func NewRateLimited(details string) *AppError {
+	return NewAppError(ErrRateLimited, "Too many requests, please slow down", details, nil)
+}
+
+// NewTooLarge reports an upload over its size limit. The message is shown to
+// the client, so it should state the limit.
+func NewTooLarge(message string) *AppError {
+	return NewAppError(ErrTooLarge, message, message, nil)
+}
+
+// NewUnsupportedType reports an upload whose content type is not accepted.
+// The message is shown to the client.
+func NewUnsupportedType(message string) *AppError {
+	return NewAppError(ErrUnsupported, message, message, nil)
+}
+
 func ToHTTPStatus(err error) int {
 	if errors.Is(err, ErrNotFound) {
 		return http.StatusNotFound
@@ -83,6 +102,15 @@ func ToHTTPStatus(err error) int {
 	if errors.Is(err, ErrConflict) {
 		return http.StatusConflict
 	}
+	if errors.Is(err, ErrRateLimited) {
+		return http.StatusTooManyRequests
+	}
+	if errors.Is(err, ErrTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	if errors.Is(err, ErrUnsupported) {
+		return http.StatusUnsupportedMediaType
+	}
 	return http.StatusInternalServerError
 }
 
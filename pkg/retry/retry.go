@@ -0,0 +1,33 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+const baseDelay = 500 * time.Millisecond
+
+// Do calls fn until it succeeds, ctx is done, or maxRetries retries have been
+// made. The delay between attempts doubles, starting at 500ms. The last
+// error from fn is returned.
+func Do(ctx context.Context, maxRetries int, fn func(ctx context.Context) error) error {
+	var err error
+	delay := baseDelay
+	for attempt := 0; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,64 @@
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("signed url has expired")
+)
+
+// Signer issues and checks expiring HMAC-SHA256 signatures over object keys,
+// so that a URL grants access to one object until it expires.
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func New(secret string, ttl time.Duration) *Signer {
+	return &Signer{secret: []byte(secret), ttl: ttl}
+}
+
+// TTL is how long a signed URL stays valid.
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+// Sign returns the query parameters granting access to key until now+TTL.
+func (s *Signer) Sign(key string, now time.Time) url.Values {
+	expires := now.Add(s.ttl).Unix()
+	return url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {s.signature(key, expires)},
+	}
+}
+
+// Verify checks the parameters produced by Sign for key.
+func (s *Signer) Verify(key string, query url.Values, now time.Time) error {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(s.signature(key, expires))) {
+		return ErrInvalidSignature
+	}
+	if now.Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+func (s *Signer) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,23 @@
+package signedurl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_Verify(t *testing.T) {
+	s := New("secret", time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	q := s.Sign("users/a/media/originals/x", now)
+
+	require.NoError(t, s.Verify("users/a/media/originals/x", q, now.Add(30*time.Second)))
+	assert.ErrorIs(t, s.Verify("users/a/media/originals/x", q, now.Add(2*time.Minute)), ErrExpired)
+	assert.ErrorIs(t, s.Verify("users/a/media/originals/y", q, now), ErrInvalidSignature)
+	assert.ErrorIs(t, New("other", time.Minute).Verify("users/a/media/originals/x", q, now), ErrInvalidSignature)
+
+	q.Set("expires", "9999999999")
+	assert.ErrorIs(t, s.Verify("users/a/media/originals/x", q, now), ErrInvalidSignature, "expiry is covered by the signature")
+}
@@ -0,0 +1,10 @@
+package text
+
+// Truncate cuts s to at most n runes, appending suffix when anything was cut.
+func Truncate(s string, n int, suffix string) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + suffix
+}
@@ -0,0 +1,13 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "héllo", Truncate("héllo", 5, "..."), "short strings are kept whole")
+	assert.Equal(t, "hé...", Truncate("héllo", 2, "..."))
+	assert.Equal(t, "日本", Truncate("日本語", 2, ""), "runes are never split")
+}
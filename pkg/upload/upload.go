@@ -0,0 +1,95 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/khoahotran/personal-os/pkg/apperror"
+)
+
+// sniffLen is how much http.DetectContentType looks at.
+const sniffLen = 512
+
+var (
+	ImageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+	VideoTypes = []string{"video/mp4", "video/webm", "video/avi", "video/quicktime"}
+	// AudioTypes are named as http.DetectContentType names them; Ogg files
+	// are "application/ogg" whether they hold audio or video.
+	AudioTypes = []string{"audio/mpeg", "audio/wave", "audio/aiff", "application/ogg"}
+	// MediaTypes leaves out camera RAW files: variants cannot be rendered
+	// from them, and their location cannot be stripped, so they are not
+	// accepted until both are supported.
+	MediaTypes = slices.Concat(ImageTypes, VideoTypes, AudioTypes, []string{"application/pdf"})
+)
+
+// Policy is what an upload endpoint accepts. Content types are sniffed from
+// the file itself; the client's Content-Type header is not trusted.
+type Policy struct {
+	MaxSize int64
+	Allowed []string
+}
+
+// Check validates a file of the given size and returns its sniffed content
+// type. It reads the start of r and rewinds it.
+func (p Policy) Check(size int64, r io.ReadSeeker) (string, error) {
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return "", p.TooLarge()
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", apperror.NewInternal("failed to read upload", err)
+	}
+	if n == 0 {
+		return "", apperror.NewInvalidInput("file is empty", nil)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", apperror.NewInternal("failed to rewind upload", err)
+	}
+
+	contentType := detectContentType(head[:n])
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	if !slices.Contains(p.Allowed, contentType) {
+		return "", apperror.NewUnsupportedType(fmt.Sprintf("file type %s is not allowed; accepted types: %s", contentType, strings.Join(p.Allowed, ", ")))
+	}
+	return contentType, nil
+}
+
+// detectContentType extends http.DetectContentType with the ISO media brands
+// it does not know, such as QuickTime's "qt  ".
+func detectContentType(head []byte) string {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" && string(head[8:12]) == "qt  " {
+		return "video/quicktime"
+	}
+	return http.DetectContentType(head)
+}
+
+// TooLarge is the error returned for files over MaxSize.
+func (p Policy) TooLarge() error {
+	return apperror.NewTooLarge(fmt.Sprintf("file exceeds the maximum size of %s", FormatSize(p.MaxSize)))
+}
+
+// FormatSize renders a byte count in binary units, e.g. "10 MB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	size := float64(n) / float64(div)
+	if size == float64(int64(size)) {
+		return fmt.Sprintf("%d %cB", int64(size), "KMGT"[exp])
+	}
+	return fmt.Sprintf("%.1f %cB", size, "KMGT"[exp])
+}
@@ -0,0 +1,64 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/khoahotran/personal-os/pkg/apperror"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestPolicy_Check(t *testing.T) {
+	p := Policy{MaxSize: 1 << 10, Allowed: ImageTypes}
+
+	r := bytes.NewReader(pngHeader)
+	contentType, err := p.Check(int64(len(pngHeader)), r)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	pos, _ := r.Seek(0, 1)
+	assert.Zero(t, pos, "reader is rewound")
+
+	_, err = p.Check(2<<10, bytes.NewReader(pngHeader))
+	assert.True(t, errors.Is(err, apperror.ErrTooLarge))
+	assert.Contains(t, err.Error(), "1 KB")
+
+	_, err = p.Check(8, bytes.NewReader([]byte("%PDF-1.7")))
+	assert.True(t, errors.Is(err, apperror.ErrUnsupported))
+	assert.Contains(t, err.Error(), "application/pdf")
+
+	_, err = Policy{Allowed: MediaTypes}.Check(8, bytes.NewReader([]byte("%PDF-1.7")))
+	assert.NoError(t, err)
+
+	_, err = p.Check(0, bytes.NewReader(nil))
+	assert.True(t, errors.Is(err, apperror.ErrInvalidInput))
+}
+
+func TestPolicy_Check_RejectsSVG(t *testing.T) {
+	// SVG can carry scripts and sniffs as XML, so it is never an image here.
+	svg := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+	_, err := Policy{Allowed: MediaTypes}.Check(int64(len(svg)), bytes.NewReader(svg))
+	assert.True(t, errors.Is(err, apperror.ErrUnsupported))
+}
+
+func TestPolicy_Check_QuickTime(t *testing.T) {
+	mov := []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00qt  ")
+	contentType, err := Policy{Allowed: MediaTypes}.Check(int64(len(mov)), bytes.NewReader(mov))
+	require.NoError(t, err)
+	assert.Equal(t, "video/quicktime", contentType)
+
+	mp4 := []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+	contentType, err = Policy{Allowed: MediaTypes}.Check(int64(len(mp4)), bytes.NewReader(mp4))
+	require.NoError(t, err)
+	assert.Equal(t, "video/mp4", contentType)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "10 MB", FormatSize(10<<20))
+	assert.Equal(t, "1.5 GB", FormatSize(3<<29))
+}